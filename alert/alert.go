@@ -0,0 +1,228 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"log-signal-processor/logprocessor"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Defaults for alerting
+const (
+	DefaultCooldown  = 5 * time.Minute
+	DefaultQueueSize = 100
+)
+
+// maxDetections bounds the detections listed in an alert
+const maxDetections = 3
+
+// notifyTimeout bounds each notifier call
+const notifyTimeout = 15 * time.Second
+
+// DefaultTemplate renders the message body of an alert
+const DefaultTemplate = `[{{.Severity}}] Anomaly in {{.Table}}.{{.Column}} ({{.Operation}}{{if .RowIdentifier}} row {{.RowIdentifier}}{{end}}{{if .Session}}, session {{.Session}}{{end}}) at {{.Timestamp.Format "2006-01-02T15:04:05Z07:00"}}
+Score: {{printf "%.2f" .Score}}
+{{range .Detections}}- {{.Detector}}: {{.Signal}} = {{printf "%.3f" .Value}} (score {{printf "%.2f" .Score}}{{if .Uncertainty}} ±{{printf "%.2f" .Uncertainty}}{{end}})
+{{end}}{{if .Contributions}}Contributions:{{range .Contributions}} {{.Signal}} {{printf "%.0f" .Percent}}%{{end}}
+{{end}}{{if .Rules}}Rules: {{join .Rules ", "}}
+{{end}}Before: {{.Before}}
+After: {{.After}}{{if .Suppressed}}
+{{.Suppressed}} similar alerts were suppressed since the last one{{end}}`
+
+// Alert is the notification for one flagged anomaly input
+type Alert struct {
+	Table         string                   `json:"table"`
+	Column        string                   `json:"column"`
+	Operation     string                   `json:"operation"`
+	RowIdentifier string                   `json:"row_identifier,omitempty"`
+	Session       string                   `json:"session,omitempty"`
+	Timestamp     time.Time                `json:"timestamp"`
+	Severity      logprocessor.Severity    `json:"severity"`
+	Tags          []string                 `json:"tags,omitempty"`       // Tags of the table from its configuration
+	Score         float64                  `json:"score"`                // Largest absolute detection score
+	Rules         []string                 `json:"rules,omitempty"`      // Matched rules
+	Detections    []logprocessor.Detection `json:"detections,omitempty"` // Top detections by absolute score
+	// Share of the flag attributed to each signal, largest first
+	Contributions []logprocessor.Contribution `json:"contributions,omitempty"`
+	Before        string                      `json:"before"`               // Masked sample of the value before the change
+	After         string                      `json:"after"`                // Masked sample of the value after the change
+	Suppressed    int                         `json:"suppressed,omitempty"` // Alerts for the column dropped during the cooldown
+}
+
+// NewAlert summarises a flagged anomaly input. Values are masked so alerts
+// never carry full column contents.
+func NewAlert(input logprocessor.AnomalyInput, tables map[string]logprocessor.TableConfig) Alert {
+	detections := append([]logprocessor.Detection{}, input.Detections...)
+	sort.SliceStable(detections, func(i, j int) bool {
+		return math.Abs(detections[i].Score) > math.Abs(detections[j].Score)
+	})
+	var score float64
+	if len(detections) > 0 {
+		score = math.Abs(detections[0].Score)
+	}
+	if len(detections) > maxDetections {
+		detections = detections[:maxDetections]
+	}
+
+	return Alert{
+		Table:         input.Table,
+		Column:        input.Column,
+		Operation:     input.Operation,
+		RowIdentifier: input.RowIdentifier,
+		Session:       input.Session,
+		Timestamp:     input.Timestamp,
+		Severity:      input.Severity,
+		Tags:          tables[input.Table].Tags,
+		Score:         score,
+		Rules:         input.MatchedRules,
+		Detections:    detections,
+		Contributions: input.Contributions,
+		Before:        MaskValue(input.BeforeValue),
+		After:         MaskValue(input.AfterValue),
+	}
+}
+
+// Summary describes the alert in one line, e.g. for subjects and titles
+func (a Alert) Summary() string {
+	return fmt.Sprintf("[%s] Anomalous %s of %s.%s (score %.2f)", a.Severity, strings.ToLower(a.Operation), a.Table, a.Column, a.Score)
+}
+
+// MaskValue renders a sample of a column value that keeps its first two
+// characters and length but hides the rest
+func MaskValue(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	runes := []rune(fmt.Sprint(v))
+	const keep, shown = 2, 12
+	if len(runes) <= keep {
+		return strings.Repeat("*", len(runes))
+	}
+	masked := string(runes[:keep]) + strings.Repeat("*", int(math.Min(float64(len(runes)-keep), shown-keep)))
+	return fmt.Sprintf("%s (%d chars)", masked, len(runes))
+}
+
+// ParseTemplate parses a message template. Templates are executed with an
+// Alert and may use join to combine string lists.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("alert").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+}
+
+// Notifier delivers an alert to one destination
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alert Alert, message string) error
+}
+
+// Config selects which anomaly inputs become alerts
+type Config struct {
+	MinSeverity logprocessor.Severity // Inputs need at least this severity; defaults to info
+	Tables      map[string]logprocessor.TableConfig
+	Cooldown    time.Duration      // Minimum interval between alerts for the same table and column
+	Template    *template.Template // Defaults to DefaultTemplate
+	QueueSize   int                // Alerts waiting to be sent; further alerts are dropped
+}
+
+// Alerter turns flagged anomaly inputs into notifications. Alerts are sent in
+// the background so slow destinations do not hold up processing.
+type Alerter struct {
+	config    Config
+	notifiers []Notifier
+
+	last       map[string]time.Time
+	suppressed map[string]int
+	dropped    int
+
+	queue chan Alert
+	wg    sync.WaitGroup
+}
+
+// NewAlerter starts an alerter sending to notifiers
+func NewAlerter(config Config, notifiers []Notifier) (*Alerter, error) {
+	if config.MinSeverity == "" {
+		config.MinSeverity = logprocessor.SeverityInfo
+	}
+	if config.Cooldown < 0 {
+		config.Cooldown = 0
+	}
+	if config.Template == nil {
+		tmpl, err := ParseTemplate(DefaultTemplate)
+		if err != nil {
+			return nil, err
+		}
+		config.Template = tmpl
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+
+	a := &Alerter{
+		config:     config,
+		notifiers:  notifiers,
+		last:       make(map[string]time.Time),
+		suppressed: make(map[string]int),
+		queue:      make(chan Alert, config.QueueSize),
+	}
+	a.wg.Add(1)
+	go a.run()
+	return a, nil
+}
+
+// Observe queues an alert for input if it was flagged with at least the
+// minimum severity and its table and column are not cooling down
+func (a *Alerter) Observe(input logprocessor.AnomalyInput) {
+	if !input.Anomalous || !input.Severity.AtLeast(a.config.MinSeverity) {
+		return
+	}
+	alert := NewAlert(input, a.config.Tables)
+
+	key := input.Table + "\x00" + input.Column
+	if last, ok := a.last[key]; ok && time.Since(last) < a.config.Cooldown {
+		a.suppressed[key]++
+		return
+	}
+	alert.Suppressed = a.suppressed[key]
+
+	select {
+	case a.queue <- alert:
+		a.last[key] = time.Now()
+		delete(a.suppressed, key)
+	default:
+		a.dropped++
+	}
+}
+
+// Close sends the queued alerts and stops the alerter
+func (a *Alerter) Close() {
+	close(a.queue)
+	a.wg.Wait()
+	if a.dropped > 0 {
+		log.Printf("Dropped %d alerts while the alert queue was full", a.dropped)
+	}
+}
+
+// run sends queued alerts to every notifier
+func (a *Alerter) run() {
+	defer a.wg.Done()
+	for alert := range a.queue {
+		var message bytes.Buffer
+		if err := a.config.Template.Execute(&message, alert); err != nil {
+			log.Printf("Failed to render alert: %v", err)
+			continue
+		}
+		for _, n := range a.notifiers {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			if err := n.Notify(ctx, alert, message.String()); err != nil {
+				log.Printf("Failed to send %s alert: %v", n.Name(), err)
+			}
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package baseline
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log-signal-processor/logprocessor"
+	"net/url"
+	"os"
+	"time"
+
+	// SQLite driver for Store
+	_ "modernc.org/sqlite"
+)
+
+// upsertStatement saves the state of one series, replacing any saved before
+const upsertStatement = `INSERT INTO baselines (database, detector, table_name, column_name, signal, state, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (database, detector, table_name, column_name, signal) DO UPDATE SET state = excluded.state, updated_at = excluded.updated_at`
+
+// Store keeps detector baselines in a SQLite file, one row per
+// (database, detector, table, column, signal). Loading them at startup means
+// a restarted process keeps its notion of normal traffic instead of relearning
+// it and re-alerting on routine changes.
+type Store struct {
+	db       *sql.DB
+	readOnly bool
+}
+
+// Open opens the baseline file at path, creating it if it does not exist
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection serialises writers on the file
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS baselines (
+	database TEXT NOT NULL,
+	detector TEXT NOT NULL,
+	table_name TEXT NOT NULL,
+	column_name TEXT NOT NULL,
+	signal TEXT NOT NULL,
+	state TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (database, detector, table_name, column_name, signal)
+)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating baselines table in %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// OpenReadOnly opens an existing baseline file for loading only; Save fails
+// and the file is never modified
+func OpenReadOnly(path string) (*Store, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+(&url.URL{Path: path}).EscapedPath()+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db, readOnly: true}, nil
+}
+
+// Load restores the baselines saved for detector when monitoring database
+func (s *Store) Load(database string, detector logprocessor.BaselineDetector) error {
+	rows, err := s.db.Query(
+		"SELECT table_name, column_name, signal, state FROM baselines WHERE database = ? AND detector = ?",
+		database, detector.Name())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	baselines := make(map[logprocessor.BaselineKey][]byte)
+	for rows.Next() {
+		var key logprocessor.BaselineKey
+		var state string
+		if err := rows.Scan(&key.Table, &key.Column, &key.Signal, &state); err != nil {
+			return err
+		}
+		baselines[key] = []byte(state)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := detector.RestoreBaselines(baselines); err != nil {
+		return fmt.Errorf("restoring %s baselines: %w", detector.Name(), err)
+	}
+	return nil
+}
+
+// Save writes the current baselines of detector in a single transaction,
+// replacing the saved state of each series it has learned
+func (s *Store) Save(database string, detector logprocessor.BaselineDetector) error {
+	if s.readOnly {
+		return fmt.Errorf("baseline file is read-only")
+	}
+	baselines, err := detector.Baselines()
+	if err != nil {
+		return fmt.Errorf("saving %s baselines: %w", detector.Name(), err)
+	}
+	if len(baselines) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(upsertStatement)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for key, state := range baselines {
+		if _, err := stmt.Exec(database, detector.Name(), key.Table, key.Column, key.Signal, string(state), now); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close closes the baseline file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// ExportFormat identifies an exported baselines document
+const ExportFormat = "log-signal-processor-baselines/v1"
+
+// Export is a portable copy of a baseline store, e.g. to promote baselines
+// trained in staging to production or to ship them for offline analysis
+type Export struct {
+	Format     string          `json:"format"` // Always ExportFormat
+	ExportedAt time.Time       `json:"exported_at"`
+	Baselines  []ExportedState `json:"baselines"`
+}
+
+// ExportedState is the saved state of one detector for one series
+type ExportedState struct {
+	Database  string          `json:"database"`
+	Detector  string          `json:"detector"`
+	Table     string          `json:"table"`
+	Column    string          `json:"column"`
+	Signal    string          `json:"signal,omitempty"`
+	State     json.RawMessage `json:"state"`
+	UpdatedAt string          `json:"updated_at"`
+}
+
+// Export writes every saved baseline to w as a JSON document
+func (s *Store) Export(w io.Writer) (int, error) {
+	rows, err := s.db.Query(`SELECT database, detector, table_name, column_name, signal, state, updated_at FROM baselines
+ORDER BY database, detector, table_name, column_name, signal`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	export := Export{Format: ExportFormat, ExportedAt: time.Now().UTC(), Baselines: []ExportedState{}}
+	for rows.Next() {
+		var state ExportedState
+		var raw string
+		if err := rows.Scan(&state.Database, &state.Detector, &state.Table, &state.Column, &state.Signal, &raw, &state.UpdatedAt); err != nil {
+			return 0, err
+		}
+		state.State = json.RawMessage(raw)
+		export.Baselines = append(export.Baselines, state)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return len(export.Baselines), encoder.Encode(export)
+}
+
+// Import reads an exported document from r and saves its baselines in a
+// single transaction, replacing the saved state of the same series
+func (s *Store) Import(r io.Reader) (int, error) {
+	if s.readOnly {
+		return 0, fmt.Errorf("baseline file is read-only")
+	}
+	var export Export
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return 0, fmt.Errorf("invalid baselines export: %w", err)
+	}
+	if export.Format != ExportFormat {
+		return 0, fmt.Errorf("unsupported baselines export format %q (expected %s)", export.Format, ExportFormat)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(upsertStatement)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, state := range export.Baselines {
+		if state.Detector == "" || !json.Valid(state.State) {
+			tx.Rollback()
+			return 0, fmt.Errorf("invalid baseline for %s %s.%s %s", state.Detector, state.Table, state.Column, state.Signal)
+		}
+		if state.UpdatedAt == "" {
+			state.UpdatedAt = export.ExportedAt.UTC().Format(time.RFC3339)
+		}
+		if _, err := stmt.Exec(state.Database, state.Detector, state.Table, state.Column, state.Signal, string(state.State), state.UpdatedAt); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	return len(export.Baselines), tx.Commit()
+}
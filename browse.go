@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"log-signal-processor/cli"
+	"log-signal-processor/logprocessor"
+	"log-signal-processor/output"
+)
+
+// Path of the browse command's output file
+var browseOutput string
+
+// browseOutputFile opens the anomaly inputs of an NDJSON output file in the
+// results browser
+func browseOutputFile(path string) error {
+	inputs, names, err := readOutputFile(path)
+	if err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		return fmt.Errorf("%s holds no anomaly inputs", path)
+	}
+
+	// Without a manifest, vectors as long as the layout of every signal are
+	// labelled with it, as they are written by default
+	if names == nil {
+		all := signalNames([]cli.SignalType{cli.SignalTypeAll})
+		names = all
+		for _, input := range inputs {
+			if len(input.SignalVector) != len(all) {
+				names = nil
+				break
+			}
+		}
+	}
+	return cli.Browse(path, inputs, names)
+}
+
+// readOutputFile reads the anomaly inputs of an NDJSON output file, with the
+// signal names of its manifest, if it starts with one
+func readOutputFile(path string) ([]logprocessor.AnomalyInput, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var inputs []logprocessor.AnomalyInput
+	var names []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		manifest, isManifest, err := output.ParseManifestJSON(scanner.Bytes())
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if isManifest {
+			names = manifest.Names()
+			continue
+		}
+		var input logprocessor.AnomalyInput
+		if err := json.Unmarshal(scanner.Bytes(), &input); err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, names, scanner.Err()
+}
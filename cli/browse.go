@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"fmt"
+	"log-signal-processor/logprocessor"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles of the results browser
+var (
+	headerStyle   = lipgloss.NewStyle().MarginLeft(2).Bold(true)
+	rowStyle      = lipgloss.NewStyle().MarginLeft(2)
+	detailStyle   = lipgloss.NewStyle().MarginLeft(2).Border(lipgloss.NormalBorder(), true, false, false, false).BorderForeground(lipgloss.Color("241"))
+	flaggedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	detectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+)
+
+// Layout of the results browser
+const (
+	browseDetailLines = 16 // Lines kept for the detail pane and help
+	browseValueWidth  = 70 // Longest before and after values shown
+)
+
+// browseModel lists anomaly inputs in a table, with the selected one's
+// details below it
+type browseModel struct {
+	title       string
+	inputs      []logprocessor.AnomalyInput
+	signalNames []string
+	rows        []int // Indexes of the inputs passing the filters
+	cursor      int   // Selected row
+	offset      int   // First row shown
+	height      int   // Rows shown
+	filterInput textinput.Model
+	filtering   bool
+	flaggedOnly bool
+}
+
+// Browse shows anomaly inputs, such as those of an output file, in a
+// scrollable and filterable table with a detail pane of the selected input's
+// values and labelled signal vector, until the user quits
+func Browse(title string, inputs []logprocessor.AnomalyInput, signalNames []string) error {
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.Placeholder = "table, column, row, operation, severity, detector or value"
+	filter.CharLimit = 256
+	filter.Width = 60
+
+	m := browseModel{
+		title:       title,
+		inputs:      inputs,
+		signalNames: signalNames,
+		height:      10,
+		filterInput: filter,
+	}
+	m.applyFilters()
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m browseModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height - browseDetailLines - 4
+		if m.height < 3 {
+			m.height = 3
+		}
+		m.scroll()
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "up", "k":
+			m.cursor--
+		case "down", "j":
+			m.cursor++
+		case "pgup", "b":
+			m.cursor -= m.height
+		case "pgdown", "f", " ":
+			m.cursor += m.height
+		case "home", "g":
+			m.cursor = 0
+		case "end", "G":
+			m.cursor = len(m.rows) - 1
+		case "/":
+			m.filtering = true
+			m.filterInput.Focus()
+			return m, textinput.Blink
+		case "a":
+			m.flaggedOnly = !m.flaggedOnly
+			m.applyFilters()
+		case "esc":
+			m.filterInput.SetValue("")
+			m.applyFilters()
+		}
+		m.scroll()
+	}
+	return m, nil
+}
+
+// updateFilter handles input while the filter is typed, filtering the rows
+// as it changes
+func (m browseModel) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "enter":
+		m.filtering = false
+		m.filterInput.Blur()
+		return m, nil
+	case "esc":
+		m.filtering = false
+		m.filterInput.Blur()
+		m.filterInput.SetValue("")
+		m.applyFilters()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	m.applyFilters()
+	return m, cmd
+}
+
+// applyFilters selects the rows of the inputs matching the filter, keeping
+// the selected input when it still matches
+func (m *browseModel) applyFilters() {
+	selected := -1
+	if m.cursor >= 0 && m.cursor < len(m.rows) {
+		selected = m.rows[m.cursor]
+	}
+	filter := strings.ToLower(strings.TrimSpace(m.filterInput.Value()))
+	m.rows = make([]int, 0, len(m.inputs))
+	m.cursor = 0
+	for i, input := range m.inputs {
+		if m.flaggedOnly && (!input.Anomalous || input.Suppressed != "") {
+			continue
+		}
+		if filter != "" && !strings.Contains(browseSearchText(input), filter) {
+			continue
+		}
+		if i == selected {
+			m.cursor = len(m.rows)
+		}
+		m.rows = append(m.rows, i)
+	}
+	m.scroll()
+}
+
+// scroll keeps the cursor within the rows and the window on the cursor
+func (m *browseModel) scroll() {
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+}
+
+// browseSearchText returns the lower-case text the filter is matched against
+func browseSearchText(input logprocessor.AnomalyInput) string {
+	parts := []string{input.Operation, input.Table, input.Column, input.RowIdentifier, input.Session, string(input.Severity), input.Suppressed,
+		fmt.Sprint(input.BeforeValue), fmt.Sprint(input.AfterValue)}
+	parts = append(parts, input.MatchedRules...)
+	for _, detection := range input.Detections {
+		parts = append(parts, detection.Detector, detection.Signal)
+	}
+	return strings.ToLower(strings.Join(parts, "\x00"))
+}
+
+func (m browseModel) View() string {
+	s := titleStyle.Render(m.title) + "\n"
+	status := fmt.Sprintf("%d of %d inputs", len(m.rows), len(m.inputs))
+	if m.flaggedOnly {
+		status += ", flagged only"
+	}
+	if filter := m.filterInput.Value(); filter != "" && !m.filtering {
+		status += fmt.Sprintf(", matching %q", filter)
+	}
+	s += infoStyle.Render(status) + "\n\n"
+
+	s += headerStyle.Render(fmt.Sprintf("  %-19s %-8s %-28s %-12s %-8s %s", "timestamp", "severity", "table.column", "row", "op", "detections")) + "\n"
+	for i := m.offset; i < m.offset+m.height; i++ {
+		if i >= len(m.rows) {
+			s += "\n"
+			continue
+		}
+		line := browseRow(m.inputs[m.rows[i]])
+		if i == m.cursor {
+			s += activeItemStyle.Render("> "+line) + "\n"
+		} else if input := m.inputs[m.rows[i]]; input.Anomalous && input.Suppressed == "" {
+			s += rowStyle.Render("  "+flaggedStyle.Render(line)) + "\n"
+		} else {
+			s += rowStyle.Render("  "+line) + "\n"
+		}
+	}
+
+	if m.cursor < len(m.rows) {
+		s += detailStyle.Render(m.detail(m.inputs[m.rows[m.cursor]])) + "\n"
+	}
+
+	if m.filtering {
+		s += "\n  " + m.filterInput.View() + "\n"
+		s += helpStyle.Render("Enter: Keep filter • Esc: Clear filter")
+	} else {
+		s += "\n" + helpStyle.Render("↑/↓: Navigate • PgUp/PgDn: Page • /: Filter • A: Flagged only • Esc: Clear filter • Q: Quit")
+	}
+	return s
+}
+
+// browseRow returns the table row of an input
+func browseRow(input logprocessor.AnomalyInput) string {
+	detectors := make([]string, 0, len(input.Detections)+len(input.MatchedRules))
+	seen := make(map[string]bool)
+	for _, detection := range input.Detections {
+		if !seen[detection.Detector] {
+			seen[detection.Detector] = true
+			detectors = append(detectors, detection.Detector)
+		}
+	}
+	detectors = append(detectors, input.MatchedRules...)
+	if input.Suppressed != "" {
+		detectors = append(detectors, "suppressed: "+input.Suppressed)
+	}
+	return fmt.Sprintf("%-19s %-8s %-28s %-12s %-8s %s",
+		input.Timestamp.UTC().Format("2006-01-02 15:04:05"),
+		input.Severity,
+		truncate(input.Table+"."+input.Column, 28),
+		truncate(input.RowIdentifier, 12),
+		truncate(input.Operation, 8),
+		strings.Join(detectors, ", "))
+}
+
+// detail describes the selected input: its values and labelled signal vector,
+// with the signals detectors flagged highlighted
+func (m browseModel) detail(input logprocessor.AnomalyInput) string {
+	s := fmt.Sprintf("%s %s.%s", input.Operation, input.Table, input.Column)
+	if input.RowIdentifier != "" {
+		s += " row " + input.RowIdentifier
+	}
+	s += " at " + input.Timestamp.Format(time.RFC3339Nano)
+	if input.Severity != "" {
+		s += fmt.Sprintf(" • severity %s", input.Severity)
+	}
+	if input.Suppressed != "" {
+		s += fmt.Sprintf(" • suppressed by %s", input.Suppressed)
+	}
+	s += fmt.Sprintf("\nBefore: %s\nAfter:  %s\n", truncate(fmt.Sprint(input.BeforeValue), browseValueWidth), truncate(fmt.Sprint(input.AfterValue), browseValueWidth))
+
+	detections := make(map[string][]string)
+	for _, detection := range input.Detections {
+		detections[detection.Signal] = append(detections[detection.Signal], fmt.Sprintf("%s score %.2f", detection.Detector, detection.Score))
+	}
+	for i, value := range input.SignalVector {
+		name := fmt.Sprintf("signal %d", i)
+		if i < len(m.signalNames) {
+			name = m.signalNames[i]
+		}
+		line := fmt.Sprintf("%-14s %12.4f", name, value)
+		if flagged := detections[name]; len(flagged) > 0 {
+			line = detectedStyle.Render(line + "  " + strings.Join(flagged, ", "))
+		}
+		s += line + "\n"
+	}
+	if len(input.Detections) > 0 {
+		all := make([]string, len(input.Detections))
+		for i, detection := range input.Detections {
+			all[i] = fmt.Sprintf("%s on %s score %.2f", detection.Detector, detection.Signal, detection.Score)
+		}
+		s += "Detections: " + strings.Join(all, ", ") + "\n"
+	}
+	if len(input.MatchedRules) > 0 {
+		s += "Rules: " + strings.Join(input.MatchedRules, ", ") + "\n"
+	}
+	if len(input.Contributions) > 0 {
+		contributions := make([]string, len(input.Contributions))
+		for i, c := range input.Contributions {
+			contributions[i] = fmt.Sprintf("%s %.0f%%", c.Signal, c.Percent)
+		}
+		s += "Contributions: " + strings.Join(contributions, ", ") + "\n"
+	}
+	return s
+}
+
+// truncate shortens s to n characters, marking the cut with an ellipsis
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"log-signal-processor/logsimulator"
+)
+
+// cipherStep is a follow-up step of an encryption type, choosing one of its
+// settings from a list
+type cipherStep struct {
+	title   string
+	info    string // Shown below the title; empty for none
+	options []cipherOption
+	initial int // Option the cursor starts at
+}
+
+// cipherOption is a choice of a cipherStep, applying its setting to the
+// configuration
+type cipherOption struct {
+	label       string
+	description string
+	apply       func(c *Config)
+}
+
+// cipherSteps lists the follow-up steps of each encryption type, asked in
+// order once it is selected. Types without steps go straight to the
+// encryption percentage, so an algorithm gains options by adding its steps
+// here.
+var cipherSteps = map[logsimulator.EncryptionType][]cipherStep{
+	logsimulator.EncryptionTypeAES: {
+		{
+			title: "Select AES mode of operation:",
+			info:  "Each mode offers different security properties",
+			options: []cipherOption{
+				aesModeOption(AESModeCBC, "Cipher Block Chaining (legacy)"),
+				aesModeOption(AESModeCTR, "Counter Mode (stream cipher)"),
+				aesModeOption(AESModeGCM, "Galois/Counter Mode (authenticated)"),
+				aesModeOption(AESModeECB, "Electronic Codebook (deterministic, equal values encrypt equally)"),
+			},
+		},
+		{
+			title: "Select AES key bit size:",
+			info:  "Larger keys provide more security but may be slightly slower",
+			options: []cipherOption{
+				aesKeyBitSizeOption(AESKeyBitSize128, "Fast, recommended minimum"),
+				aesKeyBitSizeOption(AESKeyBitSize192, "Medium strength"),
+				aesKeyBitSizeOption(AESKeyBitSize256, "Maximum security (recommended)"),
+			},
+			initial: 2, // 256-bit
+		},
+	},
+	logsimulator.EncryptionTypeChaCha20: {
+		{
+			title: "Select the ChaCha20 variant:",
+			info:  "Both use ChaCha20-Poly1305 with 256-bit keys",
+			options: []cipherOption{
+				chachaVariantOption(logsimulator.ChaChaVariantIETF, "96-bit nonces (RFC 8439)"),
+				chachaVariantOption(logsimulator.ChaChaVariantX, "192-bit nonces, safe to draw at random"),
+			},
+		},
+		{
+			title: "Simulate nonce reuse?",
+			info:  "Attackers reusing a nonce under one key encrypt equal values equally",
+			options: []cipherOption{
+				{label: "No", description: "A random nonce per value", apply: func(c *Config) { c.NonceReuse = false }},
+				{label: "Yes", description: "One nonce per key, as flawed ransomware does", apply: func(c *Config) { c.NonceReuse = true }},
+			},
+		},
+	},
+}
+
+// aesModeOption offers an AES mode of operation
+func aesModeOption(mode AESMode, description string) cipherOption {
+	return cipherOption{label: string(mode), description: description, apply: func(c *Config) { c.AESMode = mode }}
+}
+
+// aesKeyBitSizeOption offers an AES key size
+func aesKeyBitSizeOption(size AESKeyBitSize, description string) cipherOption {
+	return cipherOption{label: fmt.Sprintf("%d-bit", size), description: description, apply: func(c *Config) { c.AESKeyBitSize = size }}
+}
+
+// chachaVariantOption offers a ChaCha20 variant
+func chachaVariantOption(variant, description string) cipherOption {
+	return cipherOption{label: variant, description: description, apply: func(c *Config) { c.ChaChaVariant = variant }}
+}
@@ -3,8 +3,10 @@ package cli
 import (
 	"fmt"
 	"log-signal-processor/logsimulator"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -27,15 +29,23 @@ var (
 type Step int
 
 const (
-	DBSelectionStep Step = iota
+	PresetSelectionStep Step = iota // Quick-start presets shown first
+	DBSelectionStep
 	FieldSelectionStep
+	CustomFieldNameStep    // Name of a custom field added at the field step
+	CustomFieldTypeStep    // Generator of the custom field's values
+	CustomFieldPatternStep // Regular expression of a regex custom field
 	SignalSelectionStep
 	EncryptionSelectionStep
-	EncryptionModeStep // New step for encryption mode (AES, ChaCha20)
-	AESKeyBitSizeStep  // New step for AES key bit size
-	AESModeStep        // New step for AES mode of operation
+	CipherOptionStep // Follow-up settings of the selected encryption type, from cipherSteps
 	EncryptionPercentageStep
 	RowCountStep
+	OutputDestinationStep // Destinations of the anomaly inputs
+	OutputFileStep        // Path of the output file
+	OutputFormatStep      // Format of the output file
+	KafkaTopicStep
+	KafkaBrokersStep
+	WebhookURLStep
 	ConfigSummaryStep // New step to show summary before finishing
 	FinishedStep
 )
@@ -47,6 +57,8 @@ const (
 	SignalTypeAll         SignalType = "All"
 	SignalTypeLevenshtein SignalType = "Levenshtein"
 	SignalTypeEntropy     SignalType = "Entropy"
+	SignalTypeRowChanges  SignalType = "RowChanges"
+	SignalTypeRevert      SignalType = "Revert"
 )
 
 // AESMode represents AES mode of operation
@@ -56,6 +68,7 @@ const (
 	AESModeCBC AESMode = "CBC"
 	AESModeCTR AESMode = "CTR"
 	AESModeGCM AESMode = "GCM"
+	AESModeECB AESMode = "ECB"
 )
 
 // AESKeyBitSize represents AES key bit sizes
@@ -71,41 +84,151 @@ const (
 type OutputFormat string
 
 const (
-	OutputFormatJSON OutputFormat = "JSON"
+	OutputFormatJSON    OutputFormat = "JSON"
+	OutputFormatCSV     OutputFormat = "CSV"
+	OutputFormatParquet OutputFormat = "PARQUET"
+	OutputFormatAvro    OutputFormat = "AVRO"
+	OutputFormatProto   OutputFormat = "PROTO"
 )
 
+// Output destinations offered by the TUI
+const (
+	outputConsole = "Console"
+	outputFile    = "File"
+	outputKafka   = "Kafka topic"
+	outputWebhook = "Webhook"
+)
+
+// OutputConfig holds the destinations of the anomaly inputs chosen in the
+// TUI. They are applied as the -console, -output, -kafka-topic,
+// -kafka-brokers and -forward-url flags, unless those are given.
+type OutputConfig struct {
+	Console      bool   `json:"console" yaml:"console"`
+	File         string `json:"file,omitempty" yaml:"file,omitempty"`
+	KafkaTopic   string `json:"kafka_topic,omitempty" yaml:"kafka_topic,omitempty"`
+	KafkaBrokers string `json:"kafka_brokers,omitempty" yaml:"kafka_brokers,omitempty"` // Empty means the -kafka-brokers default
+	WebhookURL   string `json:"webhook_url,omitempty" yaml:"webhook_url,omitempty"`
+}
+
+// FlagValues returns the destinations as values of the flags selecting them
+func (o OutputConfig) FlagValues() map[string]string {
+	values := map[string]string{"console": strconv.FormatBool(o.Console)}
+	if o.File != "" {
+		values["output"] = o.File
+	}
+	if o.KafkaTopic != "" {
+		values["kafka-topic"] = o.KafkaTopic
+		if o.KafkaBrokers != "" {
+			values["kafka-brokers"] = o.KafkaBrokers
+		}
+	}
+	if o.WebhookURL != "" {
+		values["forward-url"] = o.WebhookURL
+	}
+	return values
+}
+
+// String lists the destinations
+func (o OutputConfig) String() string {
+	var destinations []string
+	if o.Console {
+		destinations = append(destinations, "console")
+	}
+	if o.File != "" {
+		destinations = append(destinations, "file "+o.File)
+	}
+	if o.KafkaTopic != "" {
+		destinations = append(destinations, "Kafka topic "+o.KafkaTopic)
+	}
+	if o.WebhookURL != "" {
+		destinations = append(destinations, "webhook "+o.WebhookURL)
+	}
+	if len(destinations) == 0 {
+		return "None"
+	}
+	return strings.Join(destinations, ", ")
+}
+
 // Config holds the user's configuration choices
 type Config struct {
-	DBType               string
-	SelectedFields       []string
-	SelectedSignals      []SignalType
-	EncryptionType       logsimulator.EncryptionType
-	AESMode              AESMode       // New field for AES mode
-	AESKeyBitSize        AESKeyBitSize // New field for AES key bit size
-	EncryptionPercentage int
-	RowCount             int
-	OutputFormat         OutputFormat // New field for output format
+	DBType               string                          `json:"db_type" yaml:"db_type"`
+	SelectedFields       []string                        `json:"fields" yaml:"fields"`
+	CustomFields         []logsimulator.ColumnSchema     `json:"custom_fields,omitempty" yaml:"custom_fields,omitempty"` // Fields entered in the TUI, simulated in the first table
+	SelectedSignals      []SignalType                    `json:"signals" yaml:"signals"`
+	EncryptionType       logsimulator.EncryptionType     `json:"encryption_type" yaml:"encryption_type"`
+	AESMode              AESMode                         `json:"aes_mode,omitempty" yaml:"aes_mode,omitempty"`         // New field for AES mode
+	AESKeyBitSize        AESKeyBitSize                   `json:"aes_key_bits,omitempty" yaml:"aes_key_bits,omitempty"` // New field for AES key bit size
+	EncryptionPercentage int                             `json:"encryption_percentage" yaml:"encryption_percentage"`
+	ChaChaVariant        string                          `json:"chacha_variant,omitempty" yaml:"chacha_variant,omitempty"`                         // ChaCha20 (default) or XChaCha20
+	NonceReuse           bool                            `json:"nonce_reuse,omitempty" yaml:"nonce_reuse,omitempty"`                               // ChaCha20 encrypts every value of a key under one nonce
+	Compress             bool                            `json:"compress,omitempty" yaml:"compress,omitempty"`                                     // Gzip values before encrypting them
+	EncryptionFields     map[string]int                  `json:"encryption_fields,omitempty" yaml:"encryption_fields,omitempty"`                   // Targeted fields by column or table.column, with their percentage of the encryption percentage
+	KeyRotation          int                             `json:"key_rotation,omitempty" yaml:"key_rotation,omitempty"`                             // Encrypted values after which the attacker rotates to a new key
+	CiphertextEncoding   string                          `json:"ciphertext_encoding,omitempty" yaml:"ciphertext_encoding,omitempty"`               // base64 (default), hex or raw
+	UnprefixedCiphertext bool                            `json:"unprefixed_ciphertext,omitempty" yaml:"unprefixed_ciphertext,omitempty"`           // Omit the AES-256-GCM: style cipher label
+	KeyReuse             string                          `json:"key_reuse,omitempty" yaml:"key_reuse,omitempty"`                                   // run (default) keeps one key per run, value draws a key per value
+	DDLPercentage        int                             `json:"ddl_percentage,omitempty" yaml:"ddl_percentage,omitempty"`                         // Share of logs that are DDL events
+	NoisePercentage      int                             `json:"noise_percentage,omitempty" yaml:"noise_percentage,omitempty"`                     // Share of logs preceded by a malformed copy
+	EditMode             logsimulator.EditMode           `json:"edit_mode,omitempty" yaml:"edit_mode,omitempty"`                                   // How unencrypted after-values are derived
+	ChangeProbability    float64                         `json:"change_probability,omitempty" yaml:"change_probability,omitempty"`                 // Chance of each field changing in an update
+	FieldProbabilities   map[string]float64              `json:"field_change_probabilities,omitempty" yaml:"field_change_probabilities,omitempty"` // Chances of single fields, by column or table.column
+	Correlations         []logsimulator.FieldCorrelation `json:"correlations,omitempty" yaml:"correlations,omitempty"`                             // Fields changing together
+	PopulationRows       int                             `json:"population_rows,omitempty" yaml:"population_rows,omitempty"`                       // Rows per table updated repeatedly; zero gives every update a new row
+	Scenario             string                          `json:"scenario,omitempty" yaml:"scenario,omitempty"`                                     // Scripted attack timeline replacing the random encryption mix
+	SecondCipher         string                          `json:"second_cipher,omitempty" yaml:"second_cipher,omitempty"`                           // Cipher of the second attacker in re-encryption phases, e.g. ChaCha20; empty means the same algorithm
+	RansomwareFamily     string                          `json:"ransomware_family,omitempty" yaml:"ransomware_family,omitempty"`                   // Known family whose ciphertext format and ransom notes the scenario emulates
+	EncryptionSchedule   string                          `json:"encryption_schedule,omitempty" yaml:"encryption_schedule,omitempty"`               // Encryption percentage over simulated time, e.g. 0s:0,2h:100
+	EventRate            float64                         `json:"events_per_second,omitempty" yaml:"events_per_second,omitempty"`                   // Average simulated events per second
+	Diurnal              bool                            `json:"diurnal,omitempty" yaml:"diurnal,omitempty"`                                       // Business-hour activity pattern
+	BurstsPerHour        float64                         `json:"bursts_per_hour,omitempty" yaml:"bursts_per_hour,omitempty"`                       // Average bursts of events per hour
+	BurstSize            int                             `json:"burst_size,omitempty" yaml:"burst_size,omitempty"`                                 // Events per burst
+	TransactionSize      int                             `json:"transaction_size,omitempty" yaml:"transaction_size,omitempty"`                     // Average changes per transaction
+	DeliveryJitter       float64                         `json:"delivery_jitter_seconds,omitempty" yaml:"delivery_jitter_seconds,omitempty"`       // Largest delivery delay, letting events arrive out of order
+	DuplicatePercentage  int                             `json:"duplicate_percentage,omitempty" yaml:"duplicate_percentage,omitempty"`             // Share of events delivered twice
+	Live                 bool                            `json:"live,omitempty" yaml:"live,omitempty"`                                             // Emit events in real time, without a row count limit
+	LiveDuration         string                          `json:"live_duration,omitempty" yaml:"live_duration,omitempty"`                           // How long a live run lasts, e.g. 8h; empty runs until interrupted
+	RowCount             int                             `json:"row_count" yaml:"row_count"`
+	OutputFormat         OutputFormat                    `json:"output_format" yaml:"output_format"` // New field for output format
+	Outputs              *OutputConfig                   `json:"outputs,omitempty" yaml:"outputs,omitempty"`
 }
 
 // Model represents the application state
 type Model struct {
 	step                 Step
+	presetOptions        []Preset
+	presetCursor         int
 	dbOptions            []string
 	dbCursor             int
 	fieldOptions         []string
 	fieldCursors         map[int]struct{} // Selected fields
 	fieldCursor          int              // Current cursor position
+	customField          logsimulator.ColumnSchema
+	customNameInput      textinput.Model
+	customTypeOptions    []string
+	customTypeCursor     int
+	customPatternInput   textinput.Model
 	signalOptions        []SignalType
 	signalCursors        map[int]struct{} // Selected signals
 	signalCursor         int              // Current signal cursor position
 	encryptionOptions    []logsimulator.EncryptionType
 	encryptionCursor     int
-	aesModeOptions       []AESMode // New field for AES modes
-	aesModeCursor        int
-	aesKeyBitSizeOptions []AESKeyBitSize // New field for AES key bit sizes
-	aesKeyBitSizeCursor  int
+	cipherStepIndex      int // Current step of cipherSteps[config.EncryptionType]
+	cipherCursor         int
 	encryptionPercentage textinput.Model
 	rowCountInput        textinput.Model
+	outputOptions        []string
+	outputCursors        map[int]struct{} // Selected destinations
+	outputCursor         int
+	outputFormatOptions  []OutputFormat
+	outputFormatCursor   int
+	outputFileInput      textinput.Model
+	kafkaTopicInput      textinput.Model
+	kafkaBrokersInput    textinput.Model
+	webhookURLInput      textinput.Model
+	savePathInput        textinput.Model        // Config file path entered at the summary
+	saving               bool                   // Whether the save path is being entered
+	saveMessage          string                 // Outcome of the last save
+	saveFlags            map[string]interface{} // Command-line flag values saved alongside the configuration
 
 	config Config
 	err    error
@@ -128,24 +251,71 @@ func InitialModel() Model {
 	encPercent.CharLimit = 3
 	encPercent.Width = 20
 
+	// Set up custom field inputs
+	customName := textinput.New()
+	customName.Placeholder = "e.g. sku"
+	customName.CharLimit = 64
+	customName.Width = 30
+
+	customPattern := textinput.New()
+	customPattern.Placeholder = "e.g. SKU-[A-Z]{3}-[0-9]{4}"
+	customPattern.CharLimit = 256
+	customPattern.Width = 40
+
+	// Set up output destination inputs
+	outputPath := textinput.New()
+	outputPath.Placeholder = "anomalies.ndjson"
+	outputPath.CharLimit = 256
+	outputPath.Width = 40
+
+	kafkaTopic := textinput.New()
+	kafkaTopic.Placeholder = "anomaly-inputs"
+	kafkaTopic.CharLimit = 249
+	kafkaTopic.Width = 40
+
+	kafkaBrokers := textinput.New()
+	kafkaBrokers.Placeholder = "localhost:9092"
+	kafkaBrokers.CharLimit = 256
+	kafkaBrokers.Width = 40
+
+	webhookURL := textinput.New()
+	webhookURL.Placeholder = "https://detector.example.com/ingest"
+	webhookURL.CharLimit = 512
+	webhookURL.Width = 50
+
+	// Set up config file path input
+	savePath := textinput.New()
+	savePath.Placeholder = "run.yaml"
+	savePath.CharLimit = 256
+	savePath.Width = 40
+
 	return Model{
-		step:                 DBSelectionStep,
+		step:                 PresetSelectionStep,
+		presetOptions:        GetPresets(),
+		presetCursor:         0,
 		dbOptions:            []string{"oracle", "postgres"},
 		dbCursor:             0,
-		fieldOptions:         []string{"bio", "email", "phone", "address"},
+		fieldOptions:         allFields(),
 		fieldCursors:         make(map[int]struct{}),
 		fieldCursor:          0,
-		signalOptions:        []SignalType{SignalTypeAll, SignalTypeLevenshtein, SignalTypeEntropy},
+		customNameInput:      customName,
+		customTypeOptions:    []string{"sentence", "email", "integer", "number", "uuid", "regex"},
+		customPatternInput:   customPattern,
+		signalOptions:        signalTypes,
 		signalCursors:        make(map[int]struct{}),
 		signalCursor:         0,
-		encryptionOptions:    []logsimulator.EncryptionType{logsimulator.EncryptionTypeNone, logsimulator.EncryptionTypeAES, logsimulator.EncryptionTypeChaCha20},
+		encryptionOptions:    []logsimulator.EncryptionType{logsimulator.EncryptionTypeNone, logsimulator.EncryptionTypeAES, logsimulator.EncryptionTypeChaCha20, logsimulator.EncryptionType3DES, logsimulator.EncryptionTypeBlowfish, logsimulator.EncryptionTypeRC4, logsimulator.EncryptionTypeXOR, logsimulator.EncryptionTypeBase64, logsimulator.EncryptionTypeHex, logsimulator.EncryptionTypeROT13},
 		encryptionCursor:     0,
-		aesModeOptions:       []AESMode{AESModeCBC, AESModeCTR, AESModeGCM},
-		aesModeCursor:        0,
-		aesKeyBitSizeOptions: []AESKeyBitSize{AESKeyBitSize128, AESKeyBitSize192, AESKeyBitSize256},
-		aesKeyBitSizeCursor:  2, // Default to 256-bit
 		encryptionPercentage: encPercent,
 		rowCountInput:        rowCount,
+		outputOptions:        []string{outputConsole, outputFile, outputKafka, outputWebhook},
+		outputCursors:        map[int]struct{}{0: {}}, // Console selected by default
+		outputFormatOptions:  outputFormats,
+		outputFileInput:      outputPath,
+		kafkaTopicInput:      kafkaTopic,
+		kafkaBrokersInput:    kafkaBrokers,
+		webhookURLInput:      webhookURL,
+		savePathInput:        savePath,
 		config:               Config{OutputFormat: OutputFormatJSON}, // Set default output format to JSON
 		previousSteps:        []Step{},
 	}
@@ -159,10 +329,18 @@ func (m Model) Init() tea.Cmd {
 func (m *Model) goToStep(newStep Step) {
 	m.previousSteps = append(m.previousSteps, m.step)
 	m.step = newStep
+	if input := m.textInput(); input != nil {
+		input.Focus()
+	}
 }
 
 // goBack returns to the previous step
 func (m *Model) goBack() {
+	if m.step == CipherOptionStep && m.cipherStepIndex > 0 {
+		m.cipherStepIndex--
+		m.cipherCursor = m.currentCipherStep().initial
+		return
+	}
 	if len(m.previousSteps) > 0 {
 		m.step = m.previousSteps[len(m.previousSteps)-1]
 		m.previousSteps = m.previousSteps[:len(m.previousSteps)-1]
@@ -172,6 +350,13 @@ func (m *Model) goBack() {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if m.saving {
+		return m.updateSavePath(msg)
+	}
+	if m.textInput() != nil {
+		return m.updateTextInput(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -180,13 +365,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "backspace", "esc":
 			// Back button functionality
-			if m.step > DBSelectionStep {
+			if m.step > PresetSelectionStep {
 				m.goBack()
 			}
 			return m, nil
 
 		case "enter":
 			switch m.step {
+			case PresetSelectionStep:
+				preset := m.presetOptions[m.presetCursor]
+				if preset.Config == nil {
+					m.goToStep(DBSelectionStep)
+				} else {
+					m.config = *preset.Config
+					m.goToStep(ConfigSummaryStep)
+				}
+
 			case DBSelectionStep:
 				m.config.DBType = m.dbOptions[m.dbCursor]
 				m.goToStep(FieldSelectionStep)
@@ -209,6 +403,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.config.SelectedFields = fields
 				m.goToStep(SignalSelectionStep)
 
+			case CustomFieldTypeStep:
+				m.customField.Type = m.customTypeOptions[m.customTypeCursor]
+				if m.customField.Type == "regex" {
+					m.customPatternInput.SetValue("")
+					m.customPatternInput.Focus()
+					m.goToStep(CustomFieldPatternStep)
+					return m, textinput.Blink
+				}
+				m.addCustomField()
+
 			case SignalSelectionStep:
 				// Convert selected signals to slice
 				signals := []SignalType{}
@@ -230,9 +434,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case EncryptionSelectionStep:
 				m.config.EncryptionType = m.encryptionOptions[m.encryptionCursor]
 
-				// If AES selected, go to AES-specific options
-				if m.config.EncryptionType == logsimulator.EncryptionTypeAES {
-					m.goToStep(AESModeStep)
+				// Clear the settings of other types, then ask for the
+				// type's own settings, if it has any
+				m.config.AESMode, m.config.AESKeyBitSize = "", 0
+				m.config.ChaChaVariant, m.config.NonceReuse = "", false
+				if steps := cipherSteps[m.config.EncryptionType]; len(steps) > 0 {
+					m.cipherStepIndex = 0
+					m.cipherCursor = steps[0].initial
+					m.goToStep(CipherOptionStep)
 				} else if m.config.EncryptionType == logsimulator.EncryptionTypeNone {
 					// Skip percentage step if "None" is selected
 					m.config.EncryptionPercentage = 0
@@ -244,14 +453,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.encryptionPercentage.Focus()
 				}
 
-			case AESModeStep:
-				m.config.AESMode = m.aesModeOptions[m.aesModeCursor]
-				m.goToStep(AESKeyBitSizeStep)
+			case CipherOptionStep:
+				m.currentCipherStep().options[m.cipherCursor].apply(&m.config)
 
-			case AESKeyBitSizeStep:
-				m.config.AESKeyBitSize = m.aesKeyBitSizeOptions[m.aesKeyBitSizeCursor]
-				m.goToStep(EncryptionPercentageStep)
-				m.encryptionPercentage.Focus()
+				// Later steps of the type share the step, without history,
+				// so that going back walks through them again
+				if steps := cipherSteps[m.config.EncryptionType]; m.cipherStepIndex+1 < len(steps) {
+					m.cipherStepIndex++
+					m.cipherCursor = steps[m.cipherStepIndex].initial
+				} else {
+					m.goToStep(EncryptionPercentageStep)
+					m.encryptionPercentage.Focus()
+				}
 
 			case EncryptionPercentageStep:
 				val, err := strconv.Atoi(m.encryptionPercentage.Value())
@@ -272,15 +485,54 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.err = nil
 				m.config.RowCount = val
-				m.goToStep(ConfigSummaryStep)
+				m.goToStep(OutputDestinationStep)
+
+			case OutputDestinationStep:
+				if len(m.outputCursors) == 0 {
+					m.err = fmt.Errorf("please select at least one destination")
+					return m, nil
+				}
+				m.err = nil
+				_, console := m.outputCursors[0]
+				m.config.Outputs = &OutputConfig{Console: console}
+				m.goToStep(m.nextOutputStep(OutputDestinationStep))
+
+			case OutputFormatStep:
+				m.config.OutputFormat = m.outputFormatOptions[m.outputFormatCursor]
+				m.goToStep(m.nextOutputStep(OutputFormatStep))
 
 			case ConfigSummaryStep:
 				m.goToStep(FinishedStep)
 				return m, tea.Quit
 			}
 
+		case "a":
+			if m.step == FieldSelectionStep {
+				m.err = nil
+				m.customField = logsimulator.ColumnSchema{}
+				m.customNameInput.SetValue("")
+				m.customNameInput.Focus()
+				m.goToStep(CustomFieldNameStep)
+				return m, textinput.Blink
+			}
+
+		case "s":
+			if m.step == ConfigSummaryStep {
+				m.saving = true
+				m.saveMessage = ""
+				m.err = nil
+				m.savePathInput.Focus()
+				return m, textinput.Blink
+			}
+
 		case "up", "k":
 			switch m.step {
+			case PresetSelectionStep:
+				m.presetCursor--
+				if m.presetCursor < 0 {
+					m.presetCursor = len(m.presetOptions) - 1
+				}
+
 			case DBSelectionStep:
 				m.dbCursor--
 				if m.dbCursor < 0 {
@@ -293,6 +545,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.fieldCursor = len(m.fieldOptions) - 1
 				}
 
+			case OutputDestinationStep:
+				m.outputCursor--
+				if m.outputCursor < 0 {
+					m.outputCursor = len(m.outputOptions) - 1
+				}
+
+			case OutputFormatStep:
+				m.outputFormatCursor--
+				if m.outputFormatCursor < 0 {
+					m.outputFormatCursor = len(m.outputFormatOptions) - 1
+				}
+
+			case CustomFieldTypeStep:
+				m.customTypeCursor--
+				if m.customTypeCursor < 0 {
+					m.customTypeCursor = len(m.customTypeOptions) - 1
+				}
+
 			case SignalSelectionStep:
 				m.signalCursor--
 				if m.signalCursor < 0 {
@@ -305,39 +575,42 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.encryptionCursor = len(m.encryptionOptions) - 1
 				}
 
-			case AESModeStep:
-				m.aesModeCursor--
-				if m.aesModeCursor < 0 {
-					m.aesModeCursor = len(m.aesModeOptions) - 1
-				}
-
-			case AESKeyBitSizeStep:
-				m.aesKeyBitSizeCursor--
-				if m.aesKeyBitSizeCursor < 0 {
-					m.aesKeyBitSizeCursor = len(m.aesKeyBitSizeOptions) - 1
+			case CipherOptionStep:
+				m.cipherCursor--
+				if m.cipherCursor < 0 {
+					m.cipherCursor = len(m.currentCipherStep().options) - 1
 				}
 
 			}
 
 		case "down", "j":
 			switch m.step {
+			case PresetSelectionStep:
+				m.presetCursor = (m.presetCursor + 1) % len(m.presetOptions)
+
 			case DBSelectionStep:
 				m.dbCursor = (m.dbCursor + 1) % len(m.dbOptions)
 
 			case FieldSelectionStep:
 				m.fieldCursor = (m.fieldCursor + 1) % len(m.fieldOptions)
 
+			case CustomFieldTypeStep:
+				m.customTypeCursor = (m.customTypeCursor + 1) % len(m.customTypeOptions)
+
+			case OutputDestinationStep:
+				m.outputCursor = (m.outputCursor + 1) % len(m.outputOptions)
+
+			case OutputFormatStep:
+				m.outputFormatCursor = (m.outputFormatCursor + 1) % len(m.outputFormatOptions)
+
 			case SignalSelectionStep:
 				m.signalCursor = (m.signalCursor + 1) % len(m.signalOptions)
 
 			case EncryptionSelectionStep:
 				m.encryptionCursor = (m.encryptionCursor + 1) % len(m.encryptionOptions)
 
-			case AESModeStep:
-				m.aesModeCursor = (m.aesModeCursor + 1) % len(m.aesModeOptions)
-
-			case AESKeyBitSizeStep:
-				m.aesKeyBitSizeCursor = (m.aesKeyBitSizeCursor + 1) % len(m.aesKeyBitSizeOptions)
+			case CipherOptionStep:
+				m.cipherCursor = (m.cipherCursor + 1) % len(m.currentCipherStep().options)
 
 			}
 
@@ -349,6 +622,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				} else {
 					m.fieldCursors[m.fieldCursor] = struct{}{}
 				}
+			} else if m.step == OutputDestinationStep {
+				// Toggle selection
+				if _, ok := m.outputCursors[m.outputCursor]; ok {
+					delete(m.outputCursors, m.outputCursor)
+				} else {
+					m.outputCursors[m.outputCursor] = struct{}{}
+				}
 			} else if m.step == SignalSelectionStep {
 				// Toggle selection
 				if m.signalCursor == 0 { // "All" option
@@ -396,6 +676,21 @@ func (m Model) View() string {
 	s = titleStyle.Render("Log Signal Processor Configuration") + "\n\n"
 
 	switch m.step {
+	case PresetSelectionStep:
+		s += titleStyle.Render("How would you like to start?") + "\n\n"
+
+		for i, option := range m.presetOptions {
+			cursor := " "
+			if m.presetCursor == i {
+				cursor = ">"
+				s += activeItemStyle.Render(fmt.Sprintf("%s %s - %s", cursor, option.Name, option.Description)) + "\n"
+			} else {
+				s += itemStyle.Render(fmt.Sprintf("%s %s - %s", cursor, option.Name, option.Description)) + "\n"
+			}
+		}
+
+		s += "\n" + helpStyle.Render("↑/↓: Navigate • Enter: Select • Skip this screen with --preset <name>, see --list-presets")
+
 	case DBSelectionStep:
 		s += titleStyle.Render("Which database logs do you want to simulate?") + "\n\n"
 
@@ -409,7 +704,7 @@ func (m Model) View() string {
 			}
 		}
 
-		s += "\n" + helpStyle.Render("↑/↓: Navigate • Enter: Select")
+		s += "\n" + helpStyle.Render("↑/↓: Navigate • Enter: Select • Esc: Back")
 
 	case FieldSelectionStep:
 		s += titleStyle.Render("Select fields to simulate (use spacebar to select):") + "\n\n"
@@ -424,6 +719,9 @@ func (m Model) View() string {
 			if _, ok := m.fieldCursors[i]; ok {
 				checked = "✓"
 			}
+			if fieldType := m.config.customFieldType(option); fieldType != "" {
+				option = fmt.Sprintf("%s (custom %s)", option, fieldType)
+			}
 
 			if m.fieldCursor == i {
 				s += activeItemStyle.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, option)) + "\n"
@@ -436,7 +734,57 @@ func (m Model) View() string {
 			s += "\n" + errorStyle.Render(m.err.Error())
 		}
 
-		s += "\n" + helpStyle.Render("↑/↓: Navigate • Space: Toggle • Enter: Confirm • Esc: Back")
+		s += "\n" + helpStyle.Render("↑/↓: Navigate • Space: Toggle • A: Add custom field • Enter: Confirm • Esc: Back")
+
+	case CustomFieldNameStep:
+		s += titleStyle.Render("Name of the custom field:") + "\n\n"
+		s += m.customNameInput.View() + "\n"
+		if m.err != nil {
+			s += "\n" + errorStyle.Render(m.err.Error())
+		}
+		s += "\n" + helpStyle.Render("Enter: Confirm • Esc: Back")
+
+	case CustomFieldTypeStep:
+		s += titleStyle.Render(fmt.Sprintf("Select the values to generate for %s:", m.customField.Name)) + "\n\n"
+
+		for i, option := range m.customTypeOptions {
+			cursor := " "
+			if m.customTypeCursor == i {
+				cursor = ">"
+			}
+
+			description := ""
+			switch option {
+			case "sentence":
+				description = "- Sentences of five words"
+			case "email":
+				description = "- Email addresses"
+			case "integer":
+				description = "- Whole numbers from 0 to 1000"
+			case "number":
+				description = "- Decimal numbers from 0 to 1000"
+			case "uuid":
+				description = "- Random UUIDs"
+			case "regex":
+				description = "- Strings matching a regular expression"
+			}
+
+			if m.customTypeCursor == i {
+				s += activeItemStyle.Render(fmt.Sprintf("%s %s %s", cursor, option, description)) + "\n"
+			} else {
+				s += itemStyle.Render(fmt.Sprintf("%s %s %s", cursor, option, description)) + "\n"
+			}
+		}
+
+		s += "\n" + helpStyle.Render("↑/↓: Navigate • Enter: Select • Esc: Back")
+
+	case CustomFieldPatternStep:
+		s += titleStyle.Render(fmt.Sprintf("Regular expression generating the values of %s:", m.customField.Name)) + "\n\n"
+		s += m.customPatternInput.View() + "\n"
+		if m.err != nil {
+			s += "\n" + errorStyle.Render(m.err.Error())
+		}
+		s += "\n" + helpStyle.Render("Enter: Add field • Esc: Back")
 
 	case SignalSelectionStep:
 		s += titleStyle.Render("Select signal generators to use:") + "\n\n"
@@ -480,75 +828,103 @@ func (m Model) View() string {
 
 		s += "\n" + helpStyle.Render("↑/↓: Navigate • Enter: Select • Esc: Back")
 
-	case AESModeStep:
-		s += titleStyle.Render("Select AES mode of operation:") + "\n\n"
-		s += infoStyle.Render("Each mode offers different security properties") + "\n\n"
+	case CipherOptionStep:
+		step := m.currentCipherStep()
+		s += titleStyle.Render(step.title) + "\n\n"
+		if step.info != "" {
+			s += infoStyle.Render(step.info) + "\n\n"
+		}
 
-		for i, option := range m.aesModeOptions {
+		for i, option := range step.options {
 			cursor := " "
-			if m.aesModeCursor == i {
+			if m.cipherCursor == i {
 				cursor = ">"
-			}
-
-			description := ""
-			switch option {
-			case AESModeCBC:
-				description = "- Cipher Block Chaining (legacy)"
-			case AESModeCTR:
-				description = "- Counter Mode (stream cipher)"
-			case AESModeGCM:
-				description = "- Galois/Counter Mode (authenticated)"
-			}
-
-			if m.aesModeCursor == i {
-				s += activeItemStyle.Render(fmt.Sprintf("%s %s %s", cursor, option, description)) + "\n"
+				s += activeItemStyle.Render(fmt.Sprintf("%s %s - %s", cursor, option.label, option.description)) + "\n"
 			} else {
-				s += itemStyle.Render(fmt.Sprintf("%s %s %s", cursor, option, description)) + "\n"
+				s += itemStyle.Render(fmt.Sprintf("%s %s - %s", cursor, option.label, option.description)) + "\n"
 			}
 		}
 
 		s += "\n" + helpStyle.Render("↑/↓: Navigate • Enter: Select • Esc: Back")
 
-	case AESKeyBitSizeStep:
-		s += titleStyle.Render("Select AES key bit size:") + "\n\n"
-		s += infoStyle.Render("Larger keys provide more security but may be slightly slower") + "\n\n"
+	case EncryptionPercentageStep:
+		s += titleStyle.Render("What percentage of rows should be encrypted? (0-100)") + "\n\n"
+		s += m.encryptionPercentage.View() + "\n"
+		if m.err != nil {
+			s += "\n" + errorStyle.Render(m.err.Error())
+		}
+		s += "\n" + helpStyle.Render("Enter: Confirm • Esc: Back")
+
+	case RowCountStep:
+		s += titleStyle.Render("How many rows do you want to generate?") + "\n\n"
+		s += m.rowCountInput.View() + "\n"
+		if m.err != nil {
+			s += "\n" + errorStyle.Render(m.err.Error())
+		}
+		s += "\n" + helpStyle.Render("Enter: Confirm • Esc: Back")
 
-		for i, option := range m.aesKeyBitSizeOptions {
+	case OutputDestinationStep:
+		s += titleStyle.Render("Where should the anomaly inputs go? (use spacebar to select):") + "\n\n"
+
+		for i, option := range m.outputOptions {
 			cursor := " "
-			if m.aesKeyBitSizeCursor == i {
+			if m.outputCursor == i {
 				cursor = ">"
 			}
 
-			description := ""
-			switch option {
-			case AESKeyBitSize128:
-				description = "- Fast, recommended minimum"
-			case AESKeyBitSize192:
-				description = "- Medium strength"
-			case AESKeyBitSize256:
-				description = "- Maximum security (recommended)"
+			checked := " "
+			if _, ok := m.outputCursors[i]; ok {
+				checked = "✓"
 			}
 
-			if m.aesKeyBitSizeCursor == i {
-				s += activeItemStyle.Render(fmt.Sprintf("%s %d-bit %s", cursor, option, description)) + "\n"
+			if m.outputCursor == i {
+				s += activeItemStyle.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, option)) + "\n"
 			} else {
-				s += itemStyle.Render(fmt.Sprintf("%s %d-bit %s", cursor, option, description)) + "\n"
+				s += itemStyle.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, option)) + "\n"
+			}
+		}
+
+		if m.err != nil {
+			s += "\n" + errorStyle.Render(m.err.Error())
+		}
+		s += "\n" + helpStyle.Render("↑/↓: Navigate • Space: Toggle • Enter: Confirm • Esc: Back")
+
+	case OutputFileStep:
+		s += titleStyle.Render("Path of the output file:") + "\n\n"
+		s += m.outputFileInput.View() + "\n"
+		s += "\n" + helpStyle.Render("Enter: Confirm (empty for the placeholder) • Esc: Back")
+
+	case OutputFormatStep:
+		s += titleStyle.Render("Select the format of the output file:") + "\n\n"
+
+		for i, option := range m.outputFormatOptions {
+			cursor := " "
+			if m.outputFormatCursor == i {
+				cursor = ">"
+				s += activeItemStyle.Render(fmt.Sprintf("%s %s", cursor, option)) + "\n"
+			} else {
+				s += itemStyle.Render(fmt.Sprintf("%s %s", cursor, option)) + "\n"
 			}
 		}
 
 		s += "\n" + helpStyle.Render("↑/↓: Navigate • Enter: Select • Esc: Back")
 
-	case EncryptionPercentageStep:
-		s += titleStyle.Render("What percentage of rows should be encrypted? (0-100)") + "\n\n"
-		s += m.encryptionPercentage.View() + "\n"
+	case KafkaTopicStep:
+		s += titleStyle.Render("Kafka topic to produce the anomaly inputs to:") + "\n\n"
+		s += m.kafkaTopicInput.View() + "\n"
 		if m.err != nil {
 			s += "\n" + errorStyle.Render(m.err.Error())
 		}
 		s += "\n" + helpStyle.Render("Enter: Confirm • Esc: Back")
 
-	case RowCountStep:
-		s += titleStyle.Render("How many rows do you want to generate?") + "\n\n"
-		s += m.rowCountInput.View() + "\n"
+	case KafkaBrokersStep:
+		s += titleStyle.Render("Kafka brokers, comma-separated:") + "\n\n"
+		s += m.kafkaBrokersInput.View() + "\n"
+		s += "\n" + helpStyle.Render("Enter: Confirm (empty for the -kafka-brokers default) • Esc: Back")
+
+	case WebhookURLStep:
+		s += titleStyle.Render("Webhook URL to POST the anomaly inputs to:") + "\n\n"
+		s += m.webhookURLInput.View() + "\n"
 		if m.err != nil {
 			s += "\n" + errorStyle.Render(m.err.Error())
 		}
@@ -557,20 +933,235 @@ func (m Model) View() string {
 	case ConfigSummaryStep:
 		s += titleStyle.Render("Configuration Summary:") + "\n\n"
 		s += m.config.String() + "\n\n"
-		s += helpStyle.Render("Enter: Start Processing • Esc: Go Back")
+		if m.saving {
+			s += titleStyle.Render("Save configuration to:") + "\n\n"
+			s += m.savePathInput.View() + "\n"
+			if m.err != nil {
+				s += "\n" + errorStyle.Render(m.err.Error())
+			}
+			s += "\n" + helpStyle.Render("Enter: Save (.yaml or .json) • Esc: Cancel")
+			return s
+		}
+		if m.saveMessage != "" {
+			s += infoStyle.Render(m.saveMessage) + "\n\n"
+		}
+		s += helpStyle.Render("Enter: Start Processing • S: Save to a config file • Esc: Go Back")
 	}
 
 	// Add navigation help if not on first screen
-	if m.step > DBSelectionStep && m.step != FinishedStep {
+	if m.step > PresetSelectionStep && m.step != FinishedStep {
 		s += "\n" + navigationStyle.Render("Press Esc to go back to previous step")
 	}
 
 	return s
 }
 
-// GetConfig returns the configuration after the user has made their selections
-func GetConfig() (Config, error) {
-	p := tea.NewProgram(InitialModel())
+// currentCipherStep returns the step of cipherSteps shown at
+// CipherOptionStep
+func (m Model) currentCipherStep() cipherStep {
+	return cipherSteps[m.config.EncryptionType][m.cipherStepIndex]
+}
+
+// textInput returns the text input of the current step, or nil for steps
+// without one
+func (m *Model) textInput() *textinput.Model {
+	switch m.step {
+	case CustomFieldNameStep:
+		return &m.customNameInput
+	case CustomFieldPatternStep:
+		return &m.customPatternInput
+	case OutputFileStep:
+		return &m.outputFileInput
+	case KafkaTopicStep:
+		return &m.kafkaTopicInput
+	case KafkaBrokersStep:
+		return &m.kafkaBrokersInput
+	case WebhookURLStep:
+		return &m.webhookURLInput
+	}
+	return nil
+}
+
+// updateTextInput handles input at the steps with a text input, so that every
+// key can be typed, confirming the value on Enter
+func (m Model) updateTextInput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	input := m.textInput()
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+
+		case "esc":
+			m.err = nil
+			m.goBack()
+			return m, nil
+
+		case "enter":
+			if err := m.confirmText(strings.TrimSpace(input.Value())); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.err = nil
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	*input, cmd = input.Update(msg)
+	return m, cmd
+}
+
+// confirmText applies the value entered at the current step and moves on to
+// the next one
+func (m *Model) confirmText(value string) error {
+	switch m.step {
+	case CustomFieldNameStep:
+		if err := m.validateCustomFieldName(value); err != nil {
+			return err
+		}
+		m.customField.Name = value
+		m.goToStep(CustomFieldTypeStep)
+
+	case CustomFieldPatternStep:
+		m.customField.Pattern = value
+		if _, err := m.customField.Field(); err != nil {
+			return err
+		}
+		m.addCustomField()
+
+	case OutputFileStep:
+		if value == "" {
+			value = m.outputFileInput.Placeholder
+		}
+		m.config.Outputs.File = value
+		m.goToStep(OutputFormatStep)
+
+	case KafkaTopicStep:
+		if value == "" || strings.ContainsAny(value, " \t/") {
+			return fmt.Errorf("please enter a topic name without spaces or slashes")
+		}
+		m.config.Outputs.KafkaTopic = value
+		m.goToStep(KafkaBrokersStep)
+
+	case KafkaBrokersStep:
+		m.config.Outputs.KafkaBrokers = value
+		m.goToStep(m.nextOutputStep(KafkaBrokersStep))
+
+	case WebhookURLStep:
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("please enter an http or https URL")
+		}
+		m.config.Outputs.WebhookURL = value
+		m.goToStep(m.nextOutputStep(WebhookURLStep))
+	}
+	return nil
+}
+
+// nextOutputStep returns the step after step among those of the selected
+// output destinations, ending at the summary
+func (m Model) nextOutputStep(step Step) Step {
+	selected := func(option string) bool {
+		for i, o := range m.outputOptions {
+			if o == option {
+				_, ok := m.outputCursors[i]
+				return ok
+			}
+		}
+		return false
+	}
+	if step < OutputFileStep && selected(outputFile) {
+		return OutputFileStep
+	}
+	if step < KafkaTopicStep && selected(outputKafka) {
+		return KafkaTopicStep
+	}
+	if step < WebhookURLStep && selected(outputWebhook) {
+		return WebhookURLStep
+	}
+	return ConfigSummaryStep
+}
+
+// validateCustomFieldName checks that a custom field's name is a new column
+// name
+func (m Model) validateCustomFieldName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t.,") {
+		return fmt.Errorf("please enter a name without spaces, dots or commas")
+	}
+	for _, option := range m.fieldOptions {
+		if option == name {
+			return fmt.Errorf("%s is already a field", name)
+		}
+	}
+	return nil
+}
+
+// addCustomField offers the custom field being added alongside the other
+// fields, selected, and returns to the field step
+func (m *Model) addCustomField() {
+	m.config.CustomFields = append(m.config.CustomFields, m.customField)
+	m.fieldOptions = append(m.fieldOptions, m.customField.Name)
+	m.fieldCursor = len(m.fieldOptions) - 1
+	m.fieldCursors[m.fieldCursor] = struct{}{}
+	for m.step != FieldSelectionStep {
+		m.goBack()
+	}
+}
+
+// customFieldType returns the generator of a custom field, or "" for the
+// simulator's fields
+func (c Config) customFieldType(name string) string {
+	for _, field := range c.CustomFields {
+		if field.Name == name {
+			return field.Type
+		}
+	}
+	return ""
+}
+
+// updateSavePath handles input while the config file path is entered at the
+// summary, writing the configuration and the flags on Enter
+func (m Model) updateSavePath(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+
+		case "esc":
+			m.saving = false
+			m.err = nil
+			return m, nil
+
+		case "enter":
+			path := strings.TrimSpace(m.savePathInput.Value())
+			if path == "" {
+				path = m.savePathInput.Placeholder
+			}
+			config := m.config
+			file := ConfigFile{Simulator: &config, Flags: m.saveFlags}
+			if err := file.Save(path); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.saving = false
+			m.err = nil
+			m.saveMessage = fmt.Sprintf("Saved to %s, run it again with --config %s", path, path)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.savePathInput, cmd = m.savePathInput.Update(msg)
+	return m, cmd
+}
+
+// GetConfig returns the configuration after the user has made their
+// selections. The flag values are saved alongside it when the user saves
+// the configuration to a file from the summary.
+func GetConfig(flags map[string]interface{}) (Config, error) {
+	model := InitialModel()
+	model.saveFlags = flags
+	p := tea.NewProgram(model)
 	m, err := p.Run()
 	if err != nil {
 		return Config{}, err
@@ -592,17 +1183,141 @@ func (c *Config) GetEncryptionConfig() logsimulator.EncryptionConfig {
 		keySize := int(c.AESKeyBitSize) / 8
 
 		return logsimulator.EncryptionConfig{
-			Type:       c.EncryptionType,
-			Percentage: c.EncryptionPercentage,
-			AESMode:    string(c.AESMode),
-			KeySize:    keySize,
+			Type:             c.EncryptionType,
+			Percentage:       c.EncryptionPercentage,
+			AESMode:          string(c.AESMode),
+			KeySize:          keySize,
+			Compress:         c.Compress,
+			KeyRotation:      c.KeyRotation,
+			KeyReuse:         c.KeyReuse,
+			Encoding:         c.CiphertextEncoding,
+			Unprefixed:       c.UnprefixedCiphertext,
+			FieldPercentages: c.EncryptionFields,
 		}
 	}
 
 	// For other encryption types
 	return logsimulator.EncryptionConfig{
-		Type:       c.EncryptionType,
-		Percentage: c.EncryptionPercentage,
+		Type:             c.EncryptionType,
+		Percentage:       c.EncryptionPercentage,
+		Variant:          c.ChaChaVariant,
+		NonceReuse:       c.NonceReuse,
+		Compress:         c.Compress,
+		KeyRotation:      c.KeyRotation,
+		KeyReuse:         c.KeyReuse,
+		Encoding:         c.CiphertextEncoding,
+		Unprefixed:       c.UnprefixedCiphertext,
+		FieldPercentages: c.EncryptionFields,
+	}
+}
+
+// GetDDLConfig converts the DDL settings to the simulator's config format
+func (c *Config) GetDDLConfig() logsimulator.DDLConfig {
+	return logsimulator.DDLConfig{Percentage: c.DDLPercentage}
+}
+
+// GetNoiseConfig converts the noise settings to the simulator's config format
+func (c *Config) GetNoiseConfig() logsimulator.NoiseConfig {
+	return logsimulator.NoiseConfig{Percentage: c.NoisePercentage}
+}
+
+// GetLiveDuration returns how long a live run lasts, zero meaning until it is
+// interrupted
+func (c *Config) GetLiveDuration() (time.Duration, error) {
+	if c.LiveDuration == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(c.LiveDuration)
+	if err != nil {
+		return 0, fmt.Errorf("invalid live duration %q: %w", c.LiveDuration, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("invalid live duration %q: must not be negative", c.LiveDuration)
+	}
+	return duration, nil
+}
+
+// GetTransactionConfig converts the transaction settings to the simulator's
+// config format
+func (c *Config) GetTransactionConfig() logsimulator.TransactionConfig {
+	return logsimulator.TransactionConfig{Size: c.TransactionSize}
+}
+
+// GetDeliveryConfig converts the delivery settings to the simulator's config
+// format
+func (c *Config) GetDeliveryConfig() logsimulator.DeliveryConfig {
+	return logsimulator.DeliveryConfig{
+		Jitter:              time.Duration(c.DeliveryJitter * float64(time.Second)),
+		DuplicatePercentage: c.DuplicatePercentage,
+	}
+}
+
+// GetScenario builds the configured scenario over the row count, or returns
+// nil when logs mix encryption at random. An encryption schedule replaces
+// the scenario's ramps, or without a scenario makes a slow-roll timeline. A
+// ransomware family without a scenario emulates the family in a ransomware
+// timeline.
+func (c *Config) GetScenario() (*logsimulator.Scenario, error) {
+	if c.Scenario == "" && c.EncryptionSchedule == "" && c.RansomwareFamily == "" {
+		return nil, nil
+	}
+	var schedule logsimulator.EncryptionSchedule
+	if c.EncryptionSchedule != "" {
+		var err error
+		schedule, err = logsimulator.ParseEncryptionSchedule(c.EncryptionSchedule)
+		if err != nil {
+			return nil, fmt.Errorf("encryption_schedule: %w", err)
+		}
+	}
+	var scenario logsimulator.Scenario
+	if schedule != nil && (c.Scenario == "" || c.Scenario == logsimulator.ScenarioSlowRoll) {
+		scenario = logsimulator.ScheduledScenario(c.RowCount, c.GetEncryptionConfig(), schedule)
+	} else {
+		name := c.Scenario
+		if name == "" {
+			name = logsimulator.ScenarioRansomware
+		}
+		var err error
+		scenario, err = logsimulator.BuildScenario(name, c.RowCount, c.GetEncryptionConfig())
+		if err != nil {
+			return nil, err
+		}
+		if schedule != nil {
+			scenario.Schedule = schedule
+		}
+	}
+	if c.RansomwareFamily != "" {
+		if err := logsimulator.ApplyFamily(&scenario, c.RansomwareFamily); err != nil {
+			return nil, fmt.Errorf("ransomware_family: %w", err)
+		}
+	}
+	if c.SecondCipher != "" {
+		second, err := logsimulator.ParseCipher(c.SecondCipher)
+		if err != nil {
+			return nil, fmt.Errorf("second_cipher: %w", err)
+		}
+		scenario.Reencryption = &second
+	}
+	return &scenario, nil
+}
+
+// GetClockConfig converts the timing settings to the simulator's config format
+func (c *Config) GetClockConfig() logsimulator.ClockConfig {
+	return logsimulator.ClockConfig{
+		Rate:          c.EventRate,
+		Diurnal:       c.Diurnal,
+		BurstsPerHour: c.BurstsPerHour,
+		BurstSize:     c.BurstSize,
+	}
+}
+
+// GetEditConfig converts the edit mode to the simulator's config format
+func (c *Config) GetEditConfig() logsimulator.EditConfig {
+	return logsimulator.EditConfig{
+		Mode:               c.EditMode,
+		ChangeProbability:  c.ChangeProbability,
+		FieldProbabilities: c.FieldProbabilities,
+		Correlations:       c.Correlations,
 	}
 }
 
@@ -617,17 +1332,139 @@ func (c Config) String() string {
 				c.AESMode,
 				c.EncryptionPercentage)
 		} else {
-			encryptionDetails = fmt.Sprintf("%s (%d%%)", c.EncryptionType, c.EncryptionPercentage)
+			encryptionDetails = fmt.Sprintf("%s (%d%%)", logsimulator.CipherName(c.GetEncryptionConfig()), c.EncryptionPercentage)
+		}
+		if c.EncryptionType == logsimulator.EncryptionTypeChaCha20 && c.NonceReuse {
+			encryptionDetails += ", nonce reused"
+		}
+		if c.Compress {
+			encryptionDetails += ", compressed first"
+		}
+		if c.CiphertextEncoding != "" && c.CiphertextEncoding != logsimulator.EncodingBase64 {
+			encryptionDetails += fmt.Sprintf(", %s encoded", c.CiphertextEncoding)
+		}
+		if c.UnprefixedCiphertext {
+			encryptionDetails += ", unprefixed"
+		}
+		if len(c.EncryptionFields) > 0 {
+			encryptionDetails += fmt.Sprintf(", %d targeted fields", len(c.EncryptionFields))
+		}
+		if c.KeyReuse == logsimulator.KeyPerValue {
+			encryptionDetails += ", new key per value"
+		} else if c.KeyRotation > 0 {
+			encryptionDetails += fmt.Sprintf(", new key every %d values", c.KeyRotation)
+		}
+	}
+
+	ddlDetails := "None"
+	if c.DDLPercentage > 0 {
+		ddlDetails = fmt.Sprintf("%s (%d%%)", strings.Join(logsimulator.DDLOperations, ", "), c.DDLPercentage)
+	}
+
+	noiseDetails := "None"
+	if c.NoisePercentage > 0 {
+		kinds := make([]string, len(logsimulator.NoiseKinds))
+		for i, kind := range logsimulator.NoiseKinds {
+			kinds[i] = string(kind)
+		}
+		noiseDetails = fmt.Sprintf("%s (%d%%)", strings.Join(kinds, ", "), c.NoisePercentage)
+	}
+
+	editMode := string(c.EditMode)
+	if editMode == "" {
+		editMode = string(logsimulator.EditModeReplace)
+	}
+	if c.ChangeProbability > 0 && c.ChangeProbability < 1 {
+		editMode += fmt.Sprintf(", %g%% of fields change", c.ChangeProbability*100)
+	}
+	if len(c.FieldProbabilities) > 0 {
+		editMode += fmt.Sprintf(", %d field probabilities", len(c.FieldProbabilities))
+	}
+	if len(c.Correlations) > 0 {
+		editMode += fmt.Sprintf(", %d correlations", len(c.Correlations))
+	}
+	if c.PopulationRows > 0 {
+		editMode += fmt.Sprintf(", %d rows per table", c.PopulationRows)
+	}
+
+	rate := c.EventRate
+	if rate <= 0 {
+		rate = logsimulator.DefaultClockRate
+	}
+	timing := fmt.Sprintf("Poisson, %g events/s", rate)
+	if c.Diurnal {
+		timing += " at peak, business hours"
+	}
+	if c.BurstsPerHour > 0 {
+		size := c.BurstSize
+		if size <= 0 {
+			size = logsimulator.DefaultBurstSize
+		}
+		timing += fmt.Sprintf(", %g bursts/h of %d events", c.BurstsPerHour, size)
+	}
+	if c.TransactionSize > 1 {
+		timing += fmt.Sprintf(", %d changes per transaction", c.TransactionSize)
+	}
+	if c.DeliveryJitter > 0 {
+		timing += fmt.Sprintf(", delivered up to %gs late", c.DeliveryJitter)
+	}
+	if c.DuplicatePercentage > 0 {
+		timing += fmt.Sprintf(", %d%% duplicated", c.DuplicatePercentage)
+	}
+
+	rowCount := strconv.Itoa(c.RowCount)
+	if c.Live {
+		rowCount = "unlimited, live until interrupted"
+		if c.LiveDuration != "" {
+			rowCount = fmt.Sprintf("unlimited, live for %s", c.LiveDuration)
+		}
+	}
+
+	scenario := "None"
+	if c.Scenario != "" {
+		scenario = c.Scenario
+	}
+	if c.EncryptionSchedule != "" {
+		if c.Scenario == "" {
+			scenario = logsimulator.ScenarioSlowRoll
+		}
+		scenario = fmt.Sprintf("%s (encryption schedule %s)", scenario, c.EncryptionSchedule)
+	}
+	if c.RansomwareFamily != "" {
+		if c.Scenario == "" && c.EncryptionSchedule == "" {
+			scenario = logsimulator.ScenarioRansomware
+		}
+		scenario = fmt.Sprintf("%s, emulating %s", scenario, c.RansomwareFamily)
+	}
+	if c.SecondCipher != "" {
+		scenario = fmt.Sprintf("%s, re-encrypted with %s", scenario, c.SecondCipher)
+	}
+
+	outputs := ""
+	if c.Outputs != nil {
+		outputs = "\nOutputs: " + c.Outputs.String()
+	}
+
+	fields := make([]string, len(c.SelectedFields))
+	for i, field := range c.SelectedFields {
+		fields[i] = field
+		if fieldType := c.customFieldType(field); fieldType != "" {
+			fields[i] = fmt.Sprintf("%s (custom %s)", field, fieldType)
 		}
 	}
 
-	return fmt.Sprintf("DB Type: %s\nSelected Fields: %s\nSelected Signals: %s\nEncryption: %s\nRow Count: %d\nOutput Format: %s",
+	return fmt.Sprintf("DB Type: %s\nSelected Fields: %s\nSelected Signals: %s\nEncryption: %s\nDDL: %s\nNoise: %s\nEdits: %s\nScenario: %s\nTiming: %s\nRow Count: %s\nOutput Format: %s",
 		c.DBType,
-		strings.Join(c.SelectedFields, ", "),
+		strings.Join(fields, ", "),
 		formatSignalTypes(c.SelectedSignals),
 		encryptionDetails,
-		c.RowCount,
-		c.OutputFormat)
+		ddlDetails,
+		noiseDetails,
+		editMode,
+		scenario,
+		timing,
+		rowCount,
+		c.OutputFormat) + outputs
 }
 
 // formatSignalTypes converts a slice of signal types to a readable string
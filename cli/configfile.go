@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat represents a serialization format for Config
+type ConfigFormat string
+
+const (
+	ConfigFormatYAML ConfigFormat = "yaml"
+	ConfigFormatJSON ConfigFormat = "json"
+)
+
+// Encode serializes the configuration so it can be saved and reused
+func (c Config) Encode(format ConfigFormat) ([]byte, error) {
+	switch format {
+	case ConfigFormatYAML, "":
+		return yaml.Marshal(c)
+	case ConfigFormatJSON:
+		data, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", format)
+	}
+}
+
+// ConfigFile is a complete run configuration loaded with --config: the
+// simulator settings the TUI collects, and the values of any command-line
+// flags by name, such as the source, sinks and detectors.
+//
+//	source: kafka
+//	kafka-brokers: localhost:9092
+//	detectors: [zscore, ransomware]
+//	simulator:
+//	  db_type: postgres
+//	  row_count: 1000
+type ConfigFile struct {
+	Simulator *Config                `yaml:"simulator,omitempty"`
+	Flags     map[string]interface{} `yaml:",inline"`
+}
+
+// LoadConfigFile reads a run configuration from a YAML, JSON or TOML file,
+// chosen by its .toml extension or otherwise parsed as YAML
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		values, err := parseTOML(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if data, err = yaml.Marshal(values); err != nil {
+			return nil, err
+		}
+	}
+	var file ConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// ConfigFormatForPath returns the format a config file is saved in, JSON
+// for a .json extension and YAML otherwise
+func ConfigFormatForPath(path string) ConfigFormat {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ConfigFormatJSON
+	}
+	return ConfigFormatYAML
+}
+
+// Encode serializes the run configuration in the layout LoadConfigFile reads
+func (f ConfigFile) Encode(format ConfigFormat) ([]byte, error) {
+	values := make(map[string]interface{}, len(f.Flags)+1)
+	for name, value := range f.Flags {
+		values[name] = value
+	}
+	if f.Simulator != nil {
+		values["simulator"] = f.Simulator
+	}
+	switch format {
+	case ConfigFormatYAML, "":
+		return yaml.Marshal(values)
+	case ConfigFormatJSON:
+		data, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", format)
+	}
+}
+
+// Save writes the run configuration to path as YAML, or as JSON for a .json
+// extension, so it can be loaded again with --config
+func (f ConfigFile) Save(path string) error {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return fmt.Errorf("%s: config files are saved as YAML or JSON", path)
+	}
+	data, err := f.Encode(ConfigFormatForPath(path))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// FlagValues returns the flag values of the file by flag name as
+// command-line strings, joining lists with commas
+func (f *ConfigFile) FlagValues() (map[string]string, error) {
+	values := make(map[string]string, len(f.Flags))
+	for name, value := range f.Flags {
+		s, err := flagValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		values[name] = s
+	}
+	return values, nil
+}
+
+// flagValue formats a scalar or list value of a config file as a flag value
+func flagValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := flagValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("flags take a value or a list, not a table")
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"fmt"
+	"log-signal-processor/logsimulator"
+	"strings"
+)
+
+// Preset is a named configuration that pre-fills the whole TUI flow
+type Preset struct {
+	ID          string // Name selecting the preset with --preset, e.g. quick-demo
+	Name        string
+	Description string
+	Config      *Config // nil means walk through every step manually
+}
+
+// fieldOptions replaces the simulator's default fields when set
+var fieldOptions []string
+
+// SetFieldOptions offers fields other than the simulator's default fields in
+// the field step and presets, e.g. the columns of a schema file
+func SetFieldOptions(fields []string) {
+	fieldOptions = fields
+}
+
+// allFields lists every field offered by the simulator
+func allFields() []string {
+	if fieldOptions != nil {
+		return append([]string(nil), fieldOptions...)
+	}
+	fields := make([]string, 0, len(logsimulator.GetFields()))
+	for _, field := range logsimulator.GetFields() {
+		fields = append(fields, field.Name)
+	}
+	return fields
+}
+
+// GetPresets returns the quick-start presets shown on the first TUI screen
+func GetPresets() []Preset {
+	return []Preset{
+		{
+			Name:        "Custom",
+			Description: "Choose every option step by step",
+		},
+		{
+			ID:          "quick-demo",
+			Name:        "Quick demo",
+			Description: "100 rows, all signals, AES-256-GCM on 50% of rows",
+			Config: &Config{
+				DBType:               "postgres",
+				SelectedFields:       allFields(),
+				SelectedSignals:      []SignalType{SignalTypeAll},
+				EncryptionType:       logsimulator.EncryptionTypeAES,
+				AESMode:              AESModeGCM,
+				AESKeyBitSize:        AESKeyBitSize256,
+				EncryptionPercentage: 50,
+				EditMode:             logsimulator.EditModeBenign,
+				ChangeProbability:    0.15,
+				Correlations:         logsimulator.DefaultCorrelations,
+				PopulationRows:       10,
+				RowCount:             100,
+				OutputFormat:         OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "baseline-clean",
+			Name:        "Clean baseline",
+			Description: "1,000 rows, all signals, normal traffic without encryption or DDL, for measuring false positives",
+			Config: &Config{
+				DBType:            "postgres",
+				SelectedFields:    allFields(),
+				SelectedSignals:   []SignalType{SignalTypeAll},
+				EncryptionType:    logsimulator.EncryptionTypeNone,
+				EditMode:          logsimulator.EditModeBenign,
+				ChangeProbability: 0.15,
+				Correlations:      logsimulator.DefaultCorrelations,
+				PopulationRows:    100,
+				RowCount:          1000,
+				OutputFormat:      OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "ransomware-gcm-50pct",
+			Name:        "Ransomware GCM 50%",
+			Description: "1,000 rows, all signals, AES-256-GCM on 50% of rows",
+			Config: &Config{
+				DBType:               "postgres",
+				SelectedFields:       allFields(),
+				SelectedSignals:      []SignalType{SignalTypeAll},
+				EncryptionType:       logsimulator.EncryptionTypeAES,
+				AESMode:              AESModeGCM,
+				AESKeyBitSize:        AESKeyBitSize256,
+				EncryptionPercentage: 50,
+				EditMode:             logsimulator.EditModeBenign,
+				ChangeProbability:    0.15,
+				Correlations:         logsimulator.DefaultCorrelations,
+				PopulationRows:       100,
+				RowCount:             1000,
+				OutputFormat:         OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "large-benchmark",
+			Name:        "Large benchmark",
+			Description: "50,000 rows, all signals, AES-256-CTR on 10% of rows",
+			Config: &Config{
+				DBType:               "postgres",
+				SelectedFields:       allFields(),
+				SelectedSignals:      []SignalType{SignalTypeAll},
+				EncryptionType:       logsimulator.EncryptionTypeAES,
+				AESMode:              AESModeCTR,
+				AESKeyBitSize:        AESKeyBitSize256,
+				EncryptionPercentage: 10,
+				EditMode:             logsimulator.EditModeBenign,
+				ChangeProbability:    0.15,
+				Correlations:         logsimulator.DefaultCorrelations,
+				PopulationRows:       5000,
+				RowCount:             50000,
+				OutputFormat:         OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "evaluation-corpus",
+			Name:        "Evaluation corpus",
+			Description: "10,000 Oracle rows, all signals, ChaCha20 on 25% of rows, business-hour traffic with bursts",
+			Config: &Config{
+				DBType:               "oracle",
+				SelectedFields:       allFields(),
+				SelectedSignals:      []SignalType{SignalTypeAll},
+				EncryptionType:       logsimulator.EncryptionTypeChaCha20,
+				EncryptionPercentage: 25,
+				EditMode:             logsimulator.EditModeBenign,
+				ChangeProbability:    0.15,
+				Correlations:         logsimulator.DefaultCorrelations,
+				Diurnal:              true,
+				BurstsPerHour:        0.5,
+				PopulationRows:       1000,
+				RowCount:             10000,
+				OutputFormat:         OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "wiper-attack",
+			Name:        "Wiper attack",
+			Description: "1,000 rows, all signals, AES-256-CBC on 60% of rows, 5% TRUNCATE/DROP/ALTER",
+			Config: &Config{
+				DBType:               "postgres",
+				SelectedFields:       allFields(),
+				SelectedSignals:      []SignalType{SignalTypeAll},
+				EncryptionType:       logsimulator.EncryptionTypeAES,
+				AESMode:              AESModeCBC,
+				AESKeyBitSize:        AESKeyBitSize256,
+				EncryptionPercentage: 60,
+				DDLPercentage:        5,
+				EditMode:             logsimulator.EditModeBenign,
+				ChangeProbability:    0.15,
+				Correlations:         logsimulator.DefaultCorrelations,
+				PopulationRows:       100,
+				RowCount:             1000,
+				OutputFormat:         OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "ransomware-timeline",
+			Name:        "Ransomware timeline",
+			Description: "2,000 rows, all signals, normal traffic then AES-256-CBC ramping from 0% to 100%, then a ransom note",
+			Config: &Config{
+				DBType:            "postgres",
+				SelectedFields:    allFields(),
+				SelectedSignals:   []SignalType{SignalTypeAll},
+				EncryptionType:    logsimulator.EncryptionTypeAES,
+				AESMode:           AESModeCBC,
+				AESKeyBitSize:     AESKeyBitSize256,
+				EditMode:          logsimulator.EditModeBenign,
+				ChangeProbability: 0.15,
+				Correlations:      logsimulator.DefaultCorrelations,
+				Scenario:          logsimulator.ScenarioRansomware,
+				PopulationRows:    200,
+				RowCount:          2000,
+				OutputFormat:      OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "lockbit-emulation",
+			Name:        "LockBit emulation",
+			Description: "2,000 rows, all signals, normal traffic then LockBit-style .lockbit ciphertext ramping from 0% to 100% and a dropped ransom note table",
+			Config: &Config{
+				DBType:            "postgres",
+				SelectedFields:    allFields(),
+				SelectedSignals:   []SignalType{SignalTypeAll},
+				EncryptionType:    logsimulator.EncryptionTypeAES,
+				AESMode:           AESModeCTR,
+				AESKeyBitSize:     AESKeyBitSize256,
+				EditMode:          logsimulator.EditModeBenign,
+				ChangeProbability: 0.15,
+				Correlations:      logsimulator.DefaultCorrelations,
+				Scenario:          logsimulator.ScenarioRansomware,
+				RansomwareFamily:  "lockbit",
+				PopulationRows:    200,
+				RowCount:          2000,
+				OutputFormat:      OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "double-encryption",
+			Name:        "Double encryption timeline",
+			Description: "3,000 rows, all signals, normal traffic, AES-256-CBC encryption ramping to 100%, then a second attacker encrypting the ciphertexts again with ChaCha20",
+			Config: &Config{
+				DBType:            "postgres",
+				SelectedFields:    allFields(),
+				SelectedSignals:   []SignalType{SignalTypeAll},
+				EncryptionType:    logsimulator.EncryptionTypeAES,
+				AESMode:           AESModeCBC,
+				AESKeyBitSize:     AESKeyBitSize256,
+				EditMode:          logsimulator.EditModeBenign,
+				ChangeProbability: 0.15,
+				Correlations:      logsimulator.DefaultCorrelations,
+				Scenario:          logsimulator.ScenarioDoubleEncrypt,
+				SecondCipher:      "ChaCha20",
+				PopulationRows:    200,
+				RowCount:          3000,
+				OutputFormat:      OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "compress-encrypt",
+			Name:        "Compress-then-encrypt timeline",
+			Description: "2,000 rows, all signals, normal traffic then gzipped values encrypted with AES-256-CTR ramping from 0% to 100%",
+			Config: &Config{
+				DBType:            "postgres",
+				SelectedFields:    allFields(),
+				SelectedSignals:   []SignalType{SignalTypeAll},
+				EncryptionType:    logsimulator.EncryptionTypeAES,
+				AESMode:           AESModeCTR,
+				AESKeyBitSize:     AESKeyBitSize256,
+				Compress:          true,
+				EditMode:          logsimulator.EditModeBenign,
+				ChangeProbability: 0.15,
+				Correlations:      logsimulator.DefaultCorrelations,
+				Scenario:          logsimulator.ScenarioCompressEncrypt,
+				PopulationRows:    200,
+				RowCount:          2000,
+				OutputFormat:      OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "wiper-timeline",
+			Name:        "Wiper timeline",
+			Description: "2,000 rows, all signals, normal traffic then a column nulled and every row deleted within minutes",
+			Config: &Config{
+				DBType:            "postgres",
+				SelectedFields:    allFields(),
+				SelectedSignals:   []SignalType{SignalTypeAll},
+				EncryptionType:    logsimulator.EncryptionTypeNone,
+				EditMode:          logsimulator.EditModeBenign,
+				ChangeProbability: 0.15,
+				Correlations:      logsimulator.DefaultCorrelations,
+				Scenario:          logsimulator.ScenarioWiper,
+				PopulationRows:    200,
+				RowCount:          2000,
+				OutputFormat:      OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "slow-roll",
+			Name:        "Slow-roll encryption",
+			Description: "7,200 rows over 2 simulated hours, all signals, AES-256-CTR ramping from 0% to 100%",
+			Config: &Config{
+				DBType:            "postgres",
+				SelectedFields:    allFields(),
+				SelectedSignals:   []SignalType{SignalTypeAll},
+				EncryptionType:    logsimulator.EncryptionTypeAES,
+				AESMode:           AESModeCTR,
+				AESKeyBitSize:     AESKeyBitSize256,
+				EditMode:          logsimulator.EditModeBenign,
+				ChangeProbability: 0.15,
+				Correlations:      logsimulator.DefaultCorrelations,
+				Scenario:          logsimulator.ScenarioSlowRoll,
+				PopulationRows:    720,
+				RowCount:          7200,
+				OutputFormat:      OutputFormatJSON,
+			},
+		},
+		{
+			ID:          "live-soak",
+			Name:        "Live soak test",
+			Description: "Real-time events at 5/s with business-hour activity for 8 hours, all signals, AES-256-GCM on 2% of rows",
+			Config: &Config{
+				DBType:               "postgres",
+				SelectedFields:       allFields(),
+				SelectedSignals:      []SignalType{SignalTypeAll},
+				EncryptionType:       logsimulator.EncryptionTypeAES,
+				AESMode:              AESModeGCM,
+				AESKeyBitSize:        AESKeyBitSize256,
+				EncryptionPercentage: 2,
+				EditMode:             logsimulator.EditModeBenign,
+				ChangeProbability:    0.15,
+				Correlations:         logsimulator.DefaultCorrelations,
+				PopulationRows:       5000,
+				EventRate:            5,
+				Diurnal:              true,
+				BurstsPerHour:        0.5,
+				TransactionSize:      3,
+				Live:                 true,
+				LiveDuration:         "8h",
+				OutputFormat:         OutputFormatJSON,
+			},
+		},
+	}
+}
+
+// FindPreset returns the preset with an ID or name, ignoring case
+func FindPreset(name string) (Preset, error) {
+	for _, preset := range GetPresets() {
+		if preset.Config == nil {
+			continue
+		}
+		if strings.EqualFold(preset.ID, name) || strings.EqualFold(preset.Name, name) {
+			return preset, nil
+		}
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(PresetIDs(), ", "))
+}
+
+// PresetIDs lists the IDs of the presets that can be selected with --preset
+func PresetIDs() []string {
+	var ids []string
+	for _, preset := range GetPresets() {
+		if preset.Config != nil {
+			ids = append(ids, preset.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles of the progress screen
+var (
+	barStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
+	barEmptyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
+	anomalyStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+)
+
+// progressBarWidth is the width of the progress bar in cells
+const progressBarWidth = 40
+
+// ProgressStats is a snapshot of a run shown on the progress screen
+type ProgressStats struct {
+	Rows        int // Logs read so far
+	Total       int // Logs the run reads; zero when unknown, e.g. in live runs
+	Elapsed     time.Duration
+	ParseErrors int
+	Anomalies   int
+	Suppressed  int
+	Signals     []SignalStats
+}
+
+// SignalStats are the running statistics of one signal
+type SignalStats struct {
+	Name     string
+	Count    int
+	Mean     float64
+	Min      float64
+	Max      float64
+	Detected int // Detections of the signal in anomalies
+}
+
+// progressDone ends the progress screen after its last update
+type progressDone struct{}
+
+// progressModel renders the latest ProgressStats of a run
+type progressModel struct {
+	title string
+	stats ProgressStats
+	done  bool
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ProgressStats:
+		m.stats = msg
+	case progressDone:
+		m.done = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m progressModel) View() string {
+	stats := m.stats
+	s := titleStyle.Render(m.title) + "\n\n"
+
+	rate := 0.0
+	if stats.Elapsed > 0 {
+		rate = float64(stats.Rows) / stats.Elapsed.Seconds()
+	}
+	counts := fmt.Sprintf("%d rows", stats.Rows)
+	if stats.Total > 0 {
+		done := float64(stats.Rows) / float64(stats.Total)
+		if done > 1 || m.done {
+			done = 1
+		}
+		filled := int(done * progressBarWidth)
+		bar := barStyle.Render(strings.Repeat("█", filled)) + barEmptyStyle.Render(strings.Repeat("░", progressBarWidth-filled))
+		s += itemStyle.Render(fmt.Sprintf("%s %3.0f%%", bar, done*100)) + "\n"
+		counts = fmt.Sprintf("%d / %d rows", stats.Rows, stats.Total)
+	}
+	s += itemStyle.Render(fmt.Sprintf("%s • %.0f rows/s • %s elapsed", counts, rate, stats.Elapsed.Truncate(time.Second))) + "\n\n"
+
+	anomalies := fmt.Sprintf("%d anomalies flagged", stats.Anomalies)
+	if stats.Anomalies > 0 {
+		anomalies = anomalyStyle.Render(anomalies)
+	}
+	if stats.Suppressed > 0 {
+		anomalies += fmt.Sprintf(", %d suppressed", stats.Suppressed)
+	}
+	if stats.ParseErrors > 0 {
+		anomalies += fmt.Sprintf(" • %d parse errors", stats.ParseErrors)
+	}
+	s += itemStyle.Render(anomalies) + "\n"
+
+	if len(stats.Signals) > 0 {
+		s += "\n" + itemStyle.Render(fmt.Sprintf("%-14s %8s %10s %10s %10s %8s", "signal", "count", "mean", "min", "max", "detected")) + "\n"
+		for _, signal := range stats.Signals {
+			s += itemStyle.Render(fmt.Sprintf("%-14s %8d %10.3f %10.3f %10.3f %8d", signal.Name, signal.Count, signal.Mean, signal.Min, signal.Max, signal.Detected)) + "\n"
+		}
+	}
+
+	if !m.done {
+		s += "\n" + helpStyle.Render("Ctrl+C: Stop the run, keeping the output written so far")
+	}
+	return s + "\n"
+}
+
+// Progress is the progress screen of a run, shown while it processes logs
+type Progress struct {
+	program  *tea.Program
+	finished chan struct{}
+}
+
+// StartProgress shows the progress screen below the terminal's output. It
+// reads no input, so interrupting the process stops the run as before.
+func StartProgress(title string) *Progress {
+	p := &Progress{
+		program:  tea.NewProgram(progressModel{title: title}, tea.WithInput(nil), tea.WithoutSignalHandler()),
+		finished: make(chan struct{}),
+	}
+	go func() {
+		defer close(p.finished)
+		p.program.Run()
+	}()
+	return p
+}
+
+// Update shows the latest statistics of the run
+func (p *Progress) Update(stats ProgressStats) {
+	p.program.Send(stats)
+}
+
+// Println prints a line above the progress screen, e.g. a log message
+func (p *Progress) Println(line string) {
+	p.program.Println(line)
+}
+
+// Finish shows the final statistics of the run and closes the screen,
+// leaving them on the terminal
+func (p *Progress) Finish(stats ProgressStats) {
+	p.program.Send(stats)
+	p.program.Send(progressDone{})
+	<-p.finished
+}
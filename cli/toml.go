@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parseTOML reads the subset of TOML used by config files into nested maps:
+// [table] and [table.sub] headers, key = value pairs with bare or quoted
+// keys, and values that are strings, integers, floats, booleans, or
+// single-line arrays and inline tables of them. Comments start with #.
+func parseTOML(r io.Reader) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	table := root
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: unsupported table header %s", n, line)
+			}
+			table = root
+			for _, name := range strings.Split(line[1:len(line)-1], ".") {
+				name = unquoteTOMLKey(strings.TrimSpace(name))
+				sub, ok := table[name].(map[string]interface{})
+				if !ok {
+					if _, exists := table[name]; exists {
+						return nil, fmt.Errorf("line %d: %s is not a table", n, name)
+					}
+					sub = make(map[string]interface{})
+					table[name] = sub
+				}
+				table = sub
+			}
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key := unquoteTOMLKey(strings.TrimSpace(line[:eq]))
+		value, err := parseTOMLValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		table[key] = value
+	}
+	return root, scanner.Err()
+}
+
+// parseTOMLValue parses a string, number, boolean, array or inline table
+func parseTOMLValue(s string) (interface{}, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value")
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	case s == "true" || s == "false":
+		return s == "true", nil
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("arrays must be on one line")
+		}
+		values := []interface{}{}
+		for _, item := range splitTOMLItems(s[1 : len(s)-1]) {
+			value, err := parseTOMLValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case strings.HasPrefix(s, "{"):
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("inline tables must be on one line")
+		}
+		table := make(map[string]interface{})
+		for _, item := range splitTOMLItems(s[1 : len(s)-1]) {
+			eq := strings.Index(item, "=")
+			if eq < 0 {
+				return nil, fmt.Errorf("expected key = value in %s", s)
+			}
+			value, err := parseTOMLValue(strings.TrimSpace(item[eq+1:]))
+			if err != nil {
+				return nil, err
+			}
+			table[unquoteTOMLKey(strings.TrimSpace(item[:eq]))] = value
+		}
+		return table, nil
+	}
+	number := strings.ReplaceAll(s, "_", "")
+	if i, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported value %s", s)
+}
+
+// splitTOMLItems splits the items of an array or inline table at commas
+// outside strings and nested values, dropping a trailing comma
+func splitTOMLItems(s string) []string {
+	var items []string
+	var quote rune
+	depth := 0
+	start := 0
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote && (quote == '\'' || i == 0 || s[i-1] != '\\') {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+// stripTOMLComment removes a # comment outside strings from a line
+func stripTOMLComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote && (quote == '\'' || line[i-1] != '\\') {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquoteTOMLKey returns a bare or quoted key's name
+func unquoteTOMLKey(key string) string {
+	if unquoted, err := strconv.Unquote(key); err == nil {
+		return unquoted
+	}
+	return strings.Trim(key, "'")
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log-signal-processor/logsimulator"
+	"net/url"
+	"strings"
+)
+
+// signalTypes lists the signal generators that can be selected
+var signalTypes = []SignalType{SignalTypeAll, SignalTypeLevenshtein, SignalTypeEntropy, SignalTypeRowChanges, SignalTypeRevert}
+
+// outputFormats lists the formats of the output file
+var outputFormats = []OutputFormat{OutputFormatJSON, OutputFormatCSV, OutputFormatParquet, OutputFormatAvro, OutputFormatProto}
+
+// Validate checks the configuration before a run, so misconfigurations fail
+// before any log is simulated. Each problem is reported by its config key;
+// the database type is checked by the caller, which owns the parsers.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.DBType == "" {
+		fail("db_type: missing")
+	}
+
+	// Fields must be offered by the schema or entered as custom fields
+	known := make(map[string]bool)
+	for _, field := range allFields() {
+		known[field] = true
+	}
+	for _, column := range c.CustomFields {
+		if _, err := column.Field(); err != nil {
+			fail("custom_fields: %v", err)
+		}
+		known[column.Name] = true
+	}
+	if len(c.SelectedFields) == 0 {
+		fail("fields: none selected")
+	}
+	for _, field := range c.SelectedFields {
+		if !known[field] {
+			fail("fields: unknown field %q", field)
+		}
+	}
+
+	if len(c.SelectedSignals) == 0 {
+		fail("signals: none selected")
+	}
+	for _, signal := range c.SelectedSignals {
+		if !containsSignal(signalTypes, signal) {
+			fail("signals: unknown signal %q (expected one of %s)", signal, joinSignals(signalTypes))
+		}
+	}
+
+	if c.EncryptionType != "" && c.EncryptionType != logsimulator.EncryptionTypeNone {
+		if _, err := logsimulator.GetEncryptor(c.GetEncryptionConfig()); err != nil {
+			fail("encryption_type %s: %v", c.EncryptionType, err)
+		}
+	}
+	if c.CiphertextEncoding != "" && !containsString(logsimulator.Encodings, c.CiphertextEncoding) {
+		fail("ciphertext_encoding: unknown encoding %q (expected one of %s)", c.CiphertextEncoding, strings.Join(logsimulator.Encodings, ", "))
+	}
+	if c.KeyReuse != "" && !containsString(logsimulator.KeyReuseModes, c.KeyReuse) {
+		fail("key_reuse: unknown mode %q (expected one of %s)", c.KeyReuse, strings.Join(logsimulator.KeyReuseModes, ", "))
+	}
+	percentages := []struct {
+		key   string
+		value int
+	}{
+		{"encryption_percentage", c.EncryptionPercentage},
+		{"ddl_percentage", c.DDLPercentage},
+		{"noise_percentage", c.NoisePercentage},
+		{"duplicate_percentage", c.DuplicatePercentage},
+	}
+	for _, percentage := range percentages {
+		if percentage.value < 0 || percentage.value > 100 {
+			fail("%s: %d is not between 0 and 100", percentage.key, percentage.value)
+		}
+	}
+
+	if c.EditMode != "" && c.EditMode != logsimulator.EditModeReplace && c.EditMode != logsimulator.EditModeBenign {
+		fail("edit_mode: unknown mode %q (expected %s or %s)", c.EditMode, logsimulator.EditModeReplace, logsimulator.EditModeBenign)
+	}
+	if c.ChangeProbability < 0 || c.ChangeProbability > 1 {
+		fail("change_probability: %g is not between 0 and 1", c.ChangeProbability)
+	}
+	for field, p := range c.FieldProbabilities {
+		if p < 0 || p > 1 {
+			fail("field_change_probabilities: %s: %g is not between 0 and 1", field, p)
+		}
+	}
+	for _, correlation := range c.Correlations {
+		if err := correlation.Validate(); err != nil {
+			fail("correlations: %v", err)
+		}
+	}
+
+	if !c.Live && c.RowCount <= 0 {
+		fail("row_count: must be positive unless the run is live")
+	}
+	if _, err := c.GetLiveDuration(); err != nil {
+		fail("live_duration: %v", err)
+	}
+	if _, err := c.GetScenario(); err != nil {
+		fail("scenario: %v", err)
+	}
+
+	if c.OutputFormat != "" && !containsFormat(outputFormats, c.OutputFormat) {
+		fail("output_format: unknown format %q (expected one of %s)", c.OutputFormat, joinFormats(outputFormats))
+	}
+	if c.Outputs != nil && c.Outputs.WebhookURL != "" {
+		if u, err := url.Parse(c.Outputs.WebhookURL); err != nil || u.Scheme == "" || u.Host == "" {
+			fail("outputs: invalid webhook_url %q", c.Outputs.WebhookURL)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// containsSignal reports whether signals includes signal
+func containsSignal(signals []SignalType, signal SignalType) bool {
+	for _, s := range signals {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
+
+// joinSignals lists signal types for error messages
+func joinSignals(signals []SignalType) string {
+	names := make([]string, len(signals))
+	for i, signal := range signals {
+		names[i] = string(signal)
+	}
+	return strings.Join(names, ", ")
+}
+
+// containsFormat reports whether formats includes format
+func containsFormat(formats []OutputFormat, format OutputFormat) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// joinFormats lists output formats for error messages
+func joinFormats(formats []OutputFormat) string {
+	names := make([]string, len(formats))
+	for i, format := range formats {
+		names[i] = string(format)
+	}
+	return strings.Join(names, ", ")
+}
+
+// containsString reports whether values includes value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// command is a subcommand running one mode of the processor with the flags
+// of that mode
+type command struct {
+	name    string
+	args    string // Positional arguments, for the usage line
+	summary string
+	takes   func(name string) bool    // Whether the command takes a flag of the command line
+	start   func(args []string) error // Applies the positional arguments and checks the mode's flags
+}
+
+// Flags only some modes take. Every other flag configures processing and
+// output, which the simulate, process, replay and serve commands share.
+var (
+	simulatorFlags = []string{"preset", "progress", "list-presets", "print-config", "save-config", "schema-file", "actors-file", "simulator-output", "simulator-format", "labels-output", "key-escrow"}
+	sourceFlags    = []string{"source", "db-type", "syslog-network", "syslog-addr", "kinesis-stream", "kinesis-start", "source-url", "source-glob", "source-endpoint", "mysql-addr", "mysql-user", "mysql-server-id", "mysql-flavor", "mysql-gtid", "mysql-position", "replay-file", "replay-speed", "replay-timestamp-field", "checkpoint-file", "checkpoint-interval", "queue-size", "queue-overflow", "queue-spill-dir"}
+	replayFlags    = []string{"db-type", "replay-file", "replay-speed", "replay-timestamp-field", "checkpoint-file", "checkpoint-interval", "queue-size", "queue-overflow", "queue-spill-dir"}
+	toolFlags      = []string{"serve", "print-schema", "validate-output", "mark-false-positive", "export-baselines", "import-baselines"}
+)
+
+// commandLine is the flag set the command line was parsed with: the flags of
+// the subcommand, or every flag without one
+var commandLine = flag.CommandLine
+
+// defaultServeAddr is the address the serve command listens at without one
+const defaultServeAddr = ":8080"
+
+// Paths of the evaluate command's output and labels files
+var evaluateOutput, evaluateLabels string
+
+// commands lists the subcommands in the order of the usage message
+var commands = []command{
+	{
+		name:    "simulate",
+		summary: "generate simulated database logs, configured in the TUI, a preset or a config file, and process them",
+		takes: func(name string) bool {
+			return !hasName(sourceFlags, name) && !hasName(toolFlags, name)
+		},
+		start: noArgs,
+	},
+	{
+		name:    "process",
+		summary: "process the logs of a live source (mysql, objectstore, kinesis, syslog, replay)",
+		takes: func(name string) bool {
+			return !hasName(simulatorFlags, name) && !hasName(toolFlags, name)
+		},
+		start: func(args []string) error {
+			if err := noArgs(args); err != nil {
+				return err
+			}
+			if *sourceType == "" {
+				return fmt.Errorf("-source is required")
+			}
+			return nil
+		},
+	},
+	{
+		name:    "replay",
+		args:    "[capture file]",
+		summary: "process a capture of logs recorded earlier, e.g. with -simulator-output",
+		takes: func(name string) bool {
+			return !hasName(simulatorFlags, name) && !hasName(toolFlags, name) &&
+				(!hasName(sourceFlags, name) || hasName(replayFlags, name))
+		},
+		start: func(args []string) error {
+			switch len(args) {
+			case 0:
+			case 1:
+				*replayFile = args[0]
+			default:
+				return fmt.Errorf("expected one capture file, got %d arguments", len(args))
+			}
+			if *replayFile == "" {
+				return fmt.Errorf("a capture file is required")
+			}
+			*sourceType = "replay"
+			return nil
+		},
+	},
+	{
+		name:    "serve",
+		args:    "[address]",
+		summary: "serve the scoring API, " + defaultServeAddr + " by default",
+		takes: func(name string) bool {
+			// -serve also sets the address, e.g. from a -config file
+			return !hasName(simulatorFlags, name) && !hasName(sourceFlags, name) &&
+				(!hasName(toolFlags, name) || name == "serve")
+		},
+		start: func(args []string) error {
+			switch len(args) {
+			case 0:
+				if *serveAddr == "" {
+					*serveAddr = defaultServeAddr
+				}
+			case 1:
+				*serveAddr = args[0]
+			default:
+				return fmt.Errorf("expected one address, got %d arguments", len(args))
+			}
+			return nil
+		},
+	},
+	{
+		name:    "evaluate",
+		args:    "<output file> <labels file>",
+		summary: "score the detections of an NDJSON output file against the -labels-output ground truth of its run",
+		takes: func(name string) bool {
+			return false
+		},
+		start: func(args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("expected an output file and a labels file, got %d arguments", len(args))
+			}
+			evaluateOutput, evaluateLabels = args[0], args[1]
+			return nil
+		},
+	},
+	{
+		name:    "browse",
+		args:    "<output file>",
+		summary: "browse the anomaly inputs of an NDJSON output file in a scrollable, filterable table with their values and signal vectors",
+		takes: func(name string) bool {
+			return false
+		},
+		start: func(args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected an output file, got %d arguments", len(args))
+			}
+			browseOutput = args[0]
+			return nil
+		},
+	},
+	{
+		name:    "completion",
+		args:    "<bash|zsh|fish>",
+		summary: "print a completion script for the shell, covering the commands, their flags and the values of flags such as -db-type, -source and -detectors",
+		takes: func(name string) bool {
+			return false
+		},
+		start: func(args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected a shell (bash, zsh or fish), got %d arguments", len(args))
+			}
+			completionShell = args[0]
+			return nil
+		},
+	},
+}
+
+// parseCommandLine parses the flags of the subcommand named by the first
+// argument, or every flag when the command line starts without one, and
+// returns the subcommand with its positional arguments
+func parseCommandLine() (*command, []string) {
+	flag.Usage = usage
+	if len(os.Args) < 2 {
+		flag.Parse()
+		return nil, nil
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == os.Args[1] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		flag.Parse()
+		return nil, nil
+	}
+
+	// The command's flags share their values with the command line's, so
+	// the modes read them the same way with or without a command
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	hasFlags := false
+	flag.VisitAll(func(f *flag.Flag) {
+		if cmd.takes(f.Name) {
+			fs.Var(f.Value, f.Name, f.Usage)
+			hasFlags = true
+		}
+	})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] %s\n\n%s\n", os.Args[0], cmd.name, cmd.args, cmd.summary)
+		if hasFlags {
+			fmt.Fprintf(fs.Output(), "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+	fs.Parse(os.Args[2:])
+	commandLine = fs
+	return cmd, fs.Args()
+}
+
+// usage prints the subcommands and the flags of the command line without one
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "\nRun %s <command> -h for the flags of a command. Without a command, every flag is accepted and the mode follows from them:\n\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+// noArgs rejects positional arguments of commands that take none
+func noArgs(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+	return nil
+}
+
+// hasName reports whether names contains name
+func hasName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"log-signal-processor/cli"
+	"log-signal-processor/logprocessor"
+	"log-signal-processor/logsimulator"
+	"log-signal-processor/output"
+	"log-signal-processor/sources"
+)
+
+// completionShell is the shell the completion command writes a script for
+var completionShell string
+
+// completionShells lists the shells the completion command supports
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// Flags taking a path, completed with file names, and a directory
+var (
+	completionFileFlags = []string{"actors-file", "alert-template", "baseline-file", "checkpoint-file", "config", "drift-output", "export-baselines", "feedback-file", "import-baselines", "key-escrow", "labels-output", "mark-false-positive", "output", "replay-file", "rules", "save-config", "schema-file", "simulator-output", "stix-file", "suppression-file", "table-config", "table-output", "validate-output", "window-output"}
+	completionDirFlags  = []string{"forward-spool-dir", "queue-spill-dir"}
+)
+
+// flagChoices returns the values of the flags taking one of a fixed set,
+// completed by the completion scripts
+func flagChoices() map[string][]string {
+	var presets []string
+	for _, preset := range cli.GetPresets() {
+		if preset.Config != nil {
+			presets = append(presets, preset.ID)
+		}
+	}
+	var wireFormats []string
+	for _, format := range logsimulator.WireFormats {
+		wireFormats = append(wireFormats, string(format))
+	}
+	return map[string][]string{
+		"source":              {"mysql", "objectstore", "kinesis", "syslog", "replay"},
+		"db-type":             {"oracle", "postgres", "mysql", "dms", "dynamodb"},
+		"syslog-network":      {"udp", "tcp"},
+		"kinesis-start":       {sources.KinesisStartTrimHorizon, sources.KinesisStartLatest},
+		"mysql-flavor":        {"mysql", "mariadb"},
+		"queue-overflow":      {string(sources.OverflowBlock), string(sources.OverflowDropOldest), string(sources.OverflowSpill)},
+		"detectors":           logprocessor.DetectorNames(),
+		"mode":                {modeTrain, modeDetect},
+		"alert-min-severity":  {string(logprocessor.SeverityInfo), string(logprocessor.SeverityWarn), string(logprocessor.SeverityCritical)},
+		"preset":              presets,
+		"print-config":        {"yaml", "json"},
+		"simulator-format":    wireFormats,
+		"output-format":       {"json", "csv", "parquet", "avro", "proto"},
+		"output-compression":  {output.CompressionNone, output.CompressionGzip, output.CompressionZstd},
+		"parquet-compression": {"none", "snappy", "gzip", "zstd", "lz4"},
+		"kafka-encoding":      {"json", "avro", "proto"},
+		"kafka-partition-by":  {output.KafkaPartitionByTable, output.KafkaPartitionByRow},
+		"archive-format":      {output.S3FormatNDJSON, output.S3FormatParquet},
+		"sql-driver":          {output.SQLDriverSQLite, output.SQLDriverPostgres},
+		"siem-network":        {"udp", "tcp", "tls"},
+		"siem-format":         {"cef", "leef"},
+	}
+}
+
+// writeCompletion writes the completion script of a shell, generated from
+// the commands and flags so it stays in step with them
+func writeCompletion(w io.Writer, shell string) error {
+	program := filepath.Base(os.Args[0])
+	switch shell {
+	case "bash":
+		return writeBashCompletion(w, program)
+	case "zsh":
+		return writeZshCompletion(w, program)
+	case "fish":
+		return writeFishCompletion(w, program)
+	default:
+		return fmt.Errorf("unsupported shell %q (expected %s)", shell, strings.Join(completionShells, ", "))
+	}
+}
+
+// commandFlags returns the flags a command takes, or every flag for a nil
+// command, in lexical order
+func commandFlags(cmd *command) []*flag.Flag {
+	var flags []*flag.Flag
+	flag.VisitAll(func(f *flag.Flag) {
+		if cmd == nil || cmd.takes(f.Name) {
+			flags = append(flags, f)
+		}
+	})
+	return flags
+}
+
+// isBoolFlag reports whether a flag is set without a value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// commandFiles reports whether a command's positional arguments are files
+func commandFiles(name string) bool {
+	return name == "replay" || name == "evaluate" || name == "browse"
+}
+
+// writeBashCompletion writes the bash script completing the commands, the
+// flags of each command and the values of flags
+func writeBashCompletion(w io.Writer, program string) error {
+	fn := "_" + regexp.MustCompile(`[^A-Za-z0-9]`).ReplaceAllString(program, "_")
+	var s strings.Builder
+	fmt.Fprintf(&s, "# bash completion for %s, generated by `%s completion bash`\n", program, program)
+	fmt.Fprintf(&s, "%s() {\n", fn)
+	s.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\" cmd=\"\" eq=\"\"\n")
+	s.WriteString("    if [[ $cur == \"=\" ]]; then\n        cur=\"\"\n        eq=1\n    elif [[ $prev == \"=\" ]]; then\n        prev=\"${COMP_WORDS[COMP_CWORD-2]}\"\n        eq=1\n    fi\n")
+	s.WriteString("    prev=\"${prev#-}\"\n    prev=\"${prev#-}\"\n")
+
+	// Values of the flag before the cursor; flags set without a value only
+	// take one after an equals sign
+	s.WriteString("    case \"$prev\" in\n")
+	choices := flagChoices()
+	flag.VisitAll(func(f *flag.Flag) {
+		values, ok := choices[f.Name]
+		switch {
+		case ok && isBoolFlag(f):
+			fmt.Fprintf(&s, "    %s)\n        if [[ -n $eq ]]; then\n            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n            return\n        fi ;;\n", f.Name, strings.Join(values, " "))
+		case ok:
+			fmt.Fprintf(&s, "    %s)\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n        return ;;\n", f.Name, strings.Join(values, " "))
+		}
+	})
+	fmt.Fprintf(&s, "    %s)\n        COMPREPLY=($(compgen -d -- \"$cur\"))\n        return ;;\n", strings.Join(completionDirFlags, "|"))
+	fmt.Fprintf(&s, "    %s)\n        COMPREPLY=($(compgen -f -- \"$cur\"))\n        return ;;\n", strings.Join(completionFileFlags, "|"))
+	s.WriteString("    esac\n")
+
+	// The command is the first word, unless it is a flag
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.name
+	}
+	fmt.Fprintf(&s, "    if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n        return\n    fi\n", strings.Join(names, " "))
+	s.WriteString("    [[ $COMP_CWORD -gt 1 ]] && cmd=\"${COMP_WORDS[1]}\"\n")
+
+	s.WriteString("    local flags\n    case \"$cmd\" in\n")
+	for i := range commands {
+		cmd := &commands[i]
+		fmt.Fprintf(&s, "    %s)\n", cmd.name)
+		if cmd.name == "completion" {
+			fmt.Fprintf(&s, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n        return ;;\n", strings.Join(completionShells, " "))
+			continue
+		}
+		fmt.Fprintf(&s, "        flags=%q ;;\n", bashFlagWords(commandFlags(cmd)))
+	}
+	fmt.Fprintf(&s, "    *)\n        flags=%q ;;\n    esac\n", bashFlagWords(commandFlags(nil)))
+
+	s.WriteString("    if [[ $cur == -* ]]; then\n        local dashes=\"-\"\n        [[ $cur == --* ]] && dashes=\"--\"\n")
+	s.WriteString("        COMPREPLY=($(compgen -P \"$dashes\" -W \"$flags\" -- \"${cur#$dashes}\"))\n")
+	s.WriteString("    else\n        COMPREPLY=($(compgen -f -- \"$cur\"))\n    fi\n}\n")
+	fmt.Fprintf(&s, "complete -o default -F %s %s\n", fn, program)
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// bashFlagWords lists flag names for compgen
+func bashFlagWords(flags []*flag.Flag) string {
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = f.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// writeZshCompletion writes the zsh script completing the commands, the
+// flags of each command with their descriptions and the values of flags
+func writeZshCompletion(w io.Writer, program string) error {
+	fn := "_" + regexp.MustCompile(`[^A-Za-z0-9]`).ReplaceAllString(program, "_")
+	var s strings.Builder
+	fmt.Fprintf(&s, "#compdef %s\n# zsh completion for %s, generated by `%s completion zsh`\n", program, program, program)
+	fmt.Fprintf(&s, "%s() {\n    local -a commands\n    commands=(\n", fn)
+	for _, cmd := range commands {
+		fmt.Fprintf(&s, "        %s\n", shellQuote(cmd.name+":"+cmd.summary))
+	}
+	s.WriteString("    )\n")
+	s.WriteString("    if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then\n        _describe command commands\n        return\n    fi\n")
+
+	choices := flagChoices()
+	s.WriteString("    case $words[2] in\n")
+	for i := range commands {
+		cmd := &commands[i]
+		fmt.Fprintf(&s, "    %s)\n        shift words\n        (( CURRENT-- ))\n        _arguments -S", cmd.name)
+		for _, f := range commandFlags(cmd) {
+			s.WriteString(" \\\n            " + zshFlagSpec(f, choices))
+		}
+		switch {
+		case cmd.name == "completion":
+			fmt.Fprintf(&s, " \\\n            %s", shellQuote(":shell:("+strings.Join(completionShells, " ")+")"))
+		case commandFiles(cmd.name):
+			s.WriteString(" \\\n            '*:file:_files'")
+		}
+		s.WriteString(" ;;\n")
+	}
+	s.WriteString("    *)\n        _arguments -S")
+	for _, f := range commandFlags(nil) {
+		s.WriteString(" \\\n            " + zshFlagSpec(f, choices))
+	}
+	s.WriteString(" ;;\n    esac\n}\n")
+	fmt.Fprintf(&s, "compdef %s %s\n", fn, program)
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// zshFlagSpec returns the _arguments spec of a flag: its description and
+// how its value is completed
+func zshFlagSpec(f *flag.Flag, choices map[string][]string) string {
+	description := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(f.Usage)
+	values, hasChoices := choices[f.Name]
+	switch {
+	case isBoolFlag(f) && hasChoices:
+		// An optional value, given after an equals sign
+		return shellQuote(fmt.Sprintf("-%s=-[%s]::value:(%s)", f.Name, description, strings.Join(values, " ")))
+	case isBoolFlag(f):
+		return shellQuote(fmt.Sprintf("-%s[%s]", f.Name, description))
+	case hasChoices:
+		return shellQuote(fmt.Sprintf("-%s=[%s]:value:(%s)", f.Name, description, strings.Join(values, " ")))
+	case hasName(completionDirFlags, f.Name):
+		return shellQuote(fmt.Sprintf("-%s=[%s]:directory:_files -/", f.Name, description))
+	case hasName(completionFileFlags, f.Name):
+		return shellQuote(fmt.Sprintf("-%s=[%s]:file:_files", f.Name, description))
+	default:
+		return shellQuote(fmt.Sprintf("-%s=[%s]:value: ", f.Name, description))
+	}
+}
+
+// shellQuote single-quotes a word for zsh and fish, closing the quotes
+// around any quote inside it
+func shellQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// writeFishCompletion writes the fish script completing the commands, the
+// flags of each command with their descriptions and the values of flags
+func writeFishCompletion(w io.Writer, program string) error {
+	var s strings.Builder
+	fmt.Fprintf(&s, "# fish completion for %s, generated by `%s completion fish`\n", program, program)
+	fmt.Fprintf(&s, "complete -c %s -f\n", program)
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+		fmt.Fprintf(&s, "complete -c %s -n __fish_use_subcommand -a %s -d %s\n", program, cmd.name, shellQuote(cmd.summary))
+	}
+	fmt.Fprintf(&s, "complete -c %s -n '__fish_seen_subcommand_from completion' -a %s\n", program, shellQuote(strings.Join(completionShells, " ")))
+	var fileCommands []string
+	for _, name := range names {
+		if commandFiles(name) {
+			fileCommands = append(fileCommands, name)
+		}
+	}
+	fmt.Fprintf(&s, "complete -c %s -n '__fish_seen_subcommand_from %s' -F\n", program, strings.Join(fileCommands, " "))
+
+	// A flag is offered unless a command not taking it was given
+	choices := flagChoices()
+	for _, f := range commandFlags(nil) {
+		var excluded []string
+		for i := range commands {
+			if !commands[i].takes(f.Name) {
+				excluded = append(excluded, commands[i].name)
+			}
+		}
+		line := fmt.Sprintf("complete -c %s -o %s", program, f.Name)
+		if len(excluded) > 0 {
+			line += fmt.Sprintf(" -n 'not __fish_seen_subcommand_from %s'", strings.Join(excluded, " "))
+		}
+		switch values, ok := choices[f.Name]; {
+		case isBoolFlag(f):
+		case ok:
+			line += " -x -a " + shellQuote(strings.Join(values, " "))
+		case hasName(completionDirFlags, f.Name):
+			line += " -x -a '(__fish_complete_directories)'"
+		case hasName(completionFileFlags, f.Name):
+			line += " -r -F"
+		default:
+			line += " -x"
+		}
+		s.WriteString(line + " -d " + shellQuote(f.Usage) + "\n")
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
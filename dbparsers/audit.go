@@ -0,0 +1,172 @@
+package dbparsers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log-signal-processor/logprocessor"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SyslogAuditParser handles database audit records received over syslog:
+// pgaudit lines ("AUDIT: SESSION,...") and Oracle Unified Auditing records
+// (KEY:"value" pairs). Audit logs record the statement rather than row images,
+// so After holds the literal values assigned by the statement and Before holds
+// empty strings for the same columns; signals therefore measure the written
+// values themselves.
+type SyslogAuditParser struct{}
+
+func (p *SyslogAuditParser) ParseLog(rawLog interface{}) (logprocessor.LogData, error) {
+	logMap, ok := rawLog.(map[string]interface{})
+	if !ok {
+		return logprocessor.LogData{}, errors.New("invalid log format")
+	}
+	message, _ := logMap["message"].(string)
+	timestamp := asTime(logMap["timestamp"])
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	if idx := strings.Index(message, "AUDIT: "); idx >= 0 {
+		return parsePgAudit(message[idx+len("AUDIT: "):], timestamp)
+	}
+	if strings.Contains(message, "ACTION:") {
+		return parseOracleUnifiedAudit(message, timestamp)
+	}
+	return logprocessor.LogData{}, errors.New("unrecognized audit record")
+}
+
+// parsePgAudit parses the CSV body of a pgaudit record:
+// AUDIT_TYPE,STATEMENT_ID,SUBSTATEMENT_ID,CLASS,COMMAND,OBJECT_TYPE,OBJECT_NAME,STATEMENT,PARAMETER
+func parsePgAudit(body string, timestamp time.Time) (logprocessor.LogData, error) {
+	r := csv.NewReader(strings.NewReader(body))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	fields, err := r.Read()
+	if err != nil {
+		return logprocessor.LogData{}, fmt.Errorf("invalid pgaudit record: %w", err)
+	}
+	if len(fields) < 8 {
+		return logprocessor.LogData{}, fmt.Errorf("invalid pgaudit record: expected 8+ fields, got %d", len(fields))
+	}
+
+	logData := statementLogData(fields[7], timestamp)
+	logData.Operation = strings.ToUpper(fields[4])
+	if fields[6] != "" {
+		logData.Table = fields[6]
+	}
+	return logData, nil
+}
+
+var oracleFieldPattern = regexp.MustCompile(`(\w+):"([^"]*)"`)
+
+// oracleActions maps Oracle audit action codes to operations
+var oracleActions = map[string]string{
+	"2":  "INSERT",
+	"3":  "SELECT",
+	"6":  "UPDATE",
+	"7":  "DELETE",
+	"12": "DROP TABLE",
+	"15": "ALTER TABLE",
+	"85": "TRUNCATE TABLE",
+}
+
+// parseOracleUnifiedAudit parses an Oracle Unified Auditing syslog record
+func parseOracleUnifiedAudit(message string, timestamp time.Time) (logprocessor.LogData, error) {
+	values := make(map[string]string)
+	for _, m := range oracleFieldPattern.FindAllStringSubmatch(message, -1) {
+		values[strings.ToUpper(m[1])] = m[2]
+	}
+
+	logData := statementLogData(values["SQLTEXT"], timestamp)
+	if op, ok := oracleActions[values["ACTION"]]; ok {
+		logData.Operation = op
+	} else if logData.Operation == "" {
+		logData.Operation = "ACTION " + values["ACTION"]
+	}
+	logData.Session = values["SESSIONID"]
+	if values["OBJNAME"] != "" {
+		logData.Table = values["OBJNAME"]
+		if values["SCHEMA"] != "" {
+			logData.Table = values["SCHEMA"] + "." + values["OBJNAME"]
+		}
+	}
+	return logData, nil
+}
+
+var (
+	updatePattern = regexp.MustCompile(`(?is)^\s*UPDATE\s+(\S+)\s+SET\s+(.+?)(?:\s+WHERE\s+(.+?))?\s*;?\s*$`)
+	insertPattern = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+(\S+)\s*\(([^)]*)\)\s*VALUES\s*\((.*)\)\s*;?\s*$`)
+	assignPattern = regexp.MustCompile(`(?s)(\w+)\s*=\s*('(?:[^']|'')*'|[^,\s]+)`)
+)
+
+// statementLogData extracts the table, row predicate and literal column values
+// from an UPDATE or INSERT statement. Other statements yield only the operation.
+func statementLogData(statement string, timestamp time.Time) logprocessor.LogData {
+	logData := logprocessor.LogData{Timestamp: timestamp}
+	after := make(map[string]interface{})
+
+	if m := updatePattern.FindStringSubmatch(statement); m != nil {
+		logData.Operation = "UPDATE"
+		logData.Table = m[1]
+		logData.RowIdentifier = strings.TrimSpace(m[3])
+		for _, a := range assignPattern.FindAllStringSubmatch(m[2], -1) {
+			after[a[1]] = unquoteSQL(a[2])
+		}
+	} else if m := insertPattern.FindStringSubmatch(statement); m != nil {
+		logData.Operation = "INSERT"
+		logData.Table = m[1]
+		columns := strings.Split(m[2], ",")
+		values := splitSQLValues(m[3])
+		for i, col := range columns {
+			if i < len(values) {
+				after[strings.TrimSpace(col)] = unquoteSQL(values[i])
+			}
+		}
+	} else if fields := strings.Fields(statement); len(fields) > 0 {
+		logData.Operation = strings.ToUpper(fields[0])
+	}
+
+	if len(after) > 0 {
+		logData.After = after
+		logData.Before = make(map[string]interface{}, len(after))
+		for col := range after {
+			logData.Columns = append(logData.Columns, col)
+			logData.Before[col] = ""
+		}
+		sort.Strings(logData.Columns)
+	}
+	return logData
+}
+
+// splitSQLValues splits a VALUES list on commas outside quoted strings
+func splitSQLValues(s string) []string {
+	var values []string
+	var current strings.Builder
+	inQuote := false
+	for _, c := range s {
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			current.WriteRune(c)
+		case c == ',' && !inQuote:
+			values = append(values, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	return append(values, strings.TrimSpace(current.String()))
+}
+
+// unquoteSQL strips quotes from a SQL string literal
+func unquoteSQL(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
@@ -0,0 +1,164 @@
+package dbparsers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log-signal-processor/logprocessor"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DMSLogParser handles AWS DMS change records as delivered to Kinesis or S3.
+// Before values are only present when the task enables BeforeImageSettings, and
+// DMS does not identify the primary key, so RowIdentifier is left empty, and
+// the transaction ID stands in for the session.
+type DMSLogParser struct{}
+
+func (p *DMSLogParser) ParseLog(rawLog interface{}) (logprocessor.LogData, error) {
+	logMap, ok := rawLog.(map[string]interface{})
+	if !ok {
+		return logprocessor.LogData{}, errors.New("invalid log format")
+	}
+	metadata, ok := logMap["metadata"].(map[string]interface{})
+	if !ok {
+		return logprocessor.LogData{}, errors.New("missing DMS metadata")
+	}
+	operation, _ := metadata["operation"].(string)
+	table, _ := metadata["table-name"].(string)
+	timestamp := asTime(metadata["timestamp"])
+	after, _ := logMap["data"].(map[string]interface{})
+	before, _ := logMap["before-image"].(map[string]interface{})
+
+	logData := logprocessor.LogData{
+		Operation:   strings.ToUpper(operation),
+		Table:       table,
+		Session:     asString(metadata["transaction-id"]),
+		Transaction: asString(metadata["transaction-id"]),
+		Columns:     diffColumns(before, after),
+		Timestamp:   timestamp,
+		Before:      before,
+		After:       after,
+	}
+	// A delete carries the removed row in "data"
+	if logData.Operation == "DELETE" {
+		logData.Before, logData.After = after, nil
+	}
+	return logData, nil
+}
+
+// DynamoDBStreamParser handles DynamoDB Streams records, including those
+// forwarded through Kinesis Data Streams.
+type DynamoDBStreamParser struct{}
+
+func (p *DynamoDBStreamParser) ParseLog(rawLog interface{}) (logprocessor.LogData, error) {
+	logMap, ok := rawLog.(map[string]interface{})
+	if !ok {
+		return logprocessor.LogData{}, errors.New("invalid log format")
+	}
+	record, ok := logMap["dynamodb"].(map[string]interface{})
+	if !ok {
+		return logprocessor.LogData{}, errors.New("missing dynamodb record")
+	}
+
+	var operation string
+	switch logMap["eventName"] {
+	case "INSERT":
+		operation = "INSERT"
+	case "MODIFY":
+		operation = "UPDATE"
+	case "REMOVE":
+		operation = "DELETE"
+	default:
+		return logprocessor.LogData{}, fmt.Errorf("unsupported dynamodb event: %v", logMap["eventName"])
+	}
+
+	table, _ := logMap["tableName"].(string)
+	keys := fromAttributeMap(record["Keys"])
+	before := fromAttributeMap(record["OldImage"])
+	after := fromAttributeMap(record["NewImage"])
+
+	// ApproximateCreationDateTime is epoch milliseconds in Kinesis payloads
+	var timestamp time.Time
+	if ms, ok := record["ApproximateCreationDateTime"].(float64); ok {
+		timestamp = time.UnixMilli(int64(ms))
+	}
+
+	return logprocessor.LogData{
+		Operation:     operation,
+		Table:         table,
+		RowIdentifier: formatKeys(keys),
+		Columns:       diffColumns(before, after),
+		Timestamp:     timestamp,
+		Before:        before,
+		After:         after,
+	}, nil
+}
+
+// fromAttributeMap converts a DynamoDB attribute-value map into plain values.
+// Scalars become strings or bools; sets, lists and maps are kept as JSON text.
+func fromAttributeMap(v interface{}) map[string]interface{} {
+	attrs, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(attrs))
+	for name, attr := range attrs {
+		typed, ok := attr.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for kind, val := range typed {
+			switch kind {
+			case "S", "N", "B":
+				values[name] = fmt.Sprint(val)
+			case "BOOL":
+				values[name] = val
+			case "NULL":
+				values[name] = nil
+			default:
+				encoded, _ := json.Marshal(val)
+				values[name] = string(encoded)
+			}
+		}
+	}
+	return values
+}
+
+// formatKeys renders key attributes as "name=value" pairs in name order
+func formatKeys(keys map[string]interface{}) string {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%v", name, keys[name])
+	}
+	return strings.Join(parts, ",")
+}
+
+// diffColumns lists the columns that differ between two row images, or every
+// column of the image that exists when only one is present
+func diffColumns(before, after map[string]interface{}) []string {
+	seen := make(map[string]struct{})
+	for name := range before {
+		seen[name] = struct{}{}
+	}
+	for name := range after {
+		seen[name] = struct{}{}
+	}
+
+	columns := make([]string, 0, len(seen))
+	for name := range seen {
+		if before == nil || after == nil || fmt.Sprint(before[name]) != fmt.Sprint(after[name]) {
+			columns = append(columns, name)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
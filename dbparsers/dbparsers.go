@@ -3,6 +3,7 @@ package dbparsers
 import (
 	"errors"
 	"log-signal-processor/logprocessor"
+	"strconv"
 	"time"
 )
 
@@ -20,14 +21,18 @@ func (p *OracleLogParser) ParseLog(rawLog interface{}) (logprocessor.LogData, er
 	operation, _ := logMap["action"].(string)
 	table, _ := logMap["table_name"].(string)
 	rowID, _ := logMap["rowid"].(string)
-	columns, _ := logMap["changed_columns"].([]string)
-	timestamp, _ := logMap["timestamp"].(time.Time)
+	session := asString(logMap["session_id"])
+	transaction := asString(logMap["xid"])
+	columns := asStrings(logMap["changed_columns"])
+	timestamp := asTime(logMap["timestamp"])
 	before, _ := logMap["before_values"].(map[string]interface{})
 	after, _ := logMap["after_values"].(map[string]interface{})
 	return logprocessor.LogData{
 		Operation:     operation,
 		Table:         table,
 		RowIdentifier: rowID,
+		Session:       session,
+		Transaction:   transaction,
 		Columns:       columns,
 		Timestamp:     timestamp,
 		Before:        before,
@@ -45,17 +50,97 @@ func (p *PostgresLogParser) ParseLog(rawLog interface{}) (logprocessor.LogData,
 	operation, _ := logMap["operation"].(string)
 	table, _ := logMap["table"].(string)
 	primaryKey, _ := logMap["primary_key"].(string)
-	columns, _ := logMap["changed_columns"].([]string)
-	timestamp, _ := logMap["timestamp"].(time.Time)
+	session := asString(logMap["session_id"])
+	transaction := asString(logMap["xid"])
+	columns := asStrings(logMap["changed_columns"])
+	timestamp := asTime(logMap["timestamp"])
 	before, _ := logMap["old_values"].(map[string]interface{})
 	after, _ := logMap["new_values"].(map[string]interface{})
 	return logprocessor.LogData{
 		Operation:     operation,
 		Table:         table,
 		RowIdentifier: primaryKey,
+		Session:       session,
+		Transaction:   transaction,
 		Columns:       columns,
 		Timestamp:     timestamp,
 		Before:        before,
 		After:         after,
 	}, nil
 }
+
+type MySQLLogParser struct{}
+
+func (p *MySQLLogParser) ParseLog(rawLog interface{}) (logprocessor.LogData, error) {
+	logMap, ok := rawLog.(map[string]interface{})
+	if !ok {
+		return logprocessor.LogData{}, errors.New("invalid log format")
+	}
+	operation, _ := logMap["type"].(string)
+	table, _ := logMap["table"].(string)
+	primaryKey, _ := logMap["primary_key"].(string)
+	session := asString(logMap["thread_id"])
+	transaction := asString(logMap["xid"])
+	columns := asStrings(logMap["changed_columns"])
+	timestamp := asTime(logMap["timestamp"])
+	before, _ := logMap["before"].(map[string]interface{})
+	after, _ := logMap["after"].(map[string]interface{})
+	return logprocessor.LogData{
+		Operation:     operation,
+		Table:         table,
+		RowIdentifier: primaryKey,
+		Session:       session,
+		Transaction:   transaction,
+		Columns:       columns,
+		Timestamp:     timestamp,
+		Before:        before,
+		After:         after,
+	}, nil
+}
+
+// asStrings accepts a []string or, for logs decoded from JSON, a []interface{} of strings
+func asStrings(v interface{}) []string {
+	switch vals := v.(type) {
+	case []string:
+		return vals
+	case []interface{}:
+		strs := make([]string, 0, len(vals))
+		for _, val := range vals {
+			if s, ok := val.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	}
+	return nil
+}
+
+// asTime accepts a time.Time or, for logs decoded from JSON, an RFC 3339 string
+func asTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case string:
+		parsed, _ := time.Parse(time.RFC3339Nano, t)
+		return parsed
+	}
+	return time.Time{}
+}
+
+// asString accepts a string or a number, since session and thread IDs are
+// numeric in some logs; numbers decoded from JSON are float64
+func asString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(s)
+	case int64:
+		return strconv.FormatInt(s, 10)
+	case uint64:
+		return strconv.FormatUint(s, 10)
+	}
+	return ""
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"log-signal-processor/alert"
+	"log-signal-processor/cli"
+	"log-signal-processor/logprocessor"
+	"log-signal-processor/logsimulator"
+)
+
+// dryRun validates the run instead of processing logs
+var dryRun = flag.Bool("dry-run", false, "load and validate the configuration, check that the parser, files and remote outputs are usable, print the resolved plan and exit without processing logs")
+
+// dryRunDialTimeout bounds each reachability check of a dry run
+const dryRunDialTimeout = 3 * time.Second
+
+// dryRunPlan collects the resolved plan of a dry run and the results of its
+// checks
+type dryRunPlan struct {
+	plan     []string
+	checks   []string
+	problems int
+}
+
+// describe adds a line to the plan
+func (p *dryRunPlan) describe(name, value string) {
+	p.plan = append(p.plan, fmt.Sprintf("  %-12s %s", name, value))
+}
+
+// check records the result of checking what, failing when err is not nil
+func (p *dryRunPlan) check(what string, err error) {
+	if err == nil {
+		p.checks = append(p.checks, "  ok    "+what)
+		return
+	}
+	// Joined errors are listed one per line
+	for _, line := range strings.Split(err.Error(), "\n") {
+		p.checks = append(p.checks, fmt.Sprintf("  FAIL  %s: %s", what, line))
+		p.problems++
+	}
+}
+
+// skip records a setting that a dry run cannot check
+func (p *dryRunPlan) skip(what, reason string) {
+	p.checks = append(p.checks, fmt.Sprintf("  skip  %s: %s", what, reason))
+}
+
+// finish prints the plan and the checks, exiting with an error if any failed
+func (p *dryRunPlan) finish(mode string) {
+	fmt.Printf("Dry run of %s:\n%s\n\nChecks:\n%s\n\n", mode, strings.Join(p.plan, "\n"), strings.Join(p.checks, "\n"))
+	if p.problems > 0 {
+		noun := "problems"
+		if p.problems == 1 {
+			noun = "problem"
+		}
+		log.Fatalf("Dry run found %d %s; nothing was processed", p.problems, noun)
+	}
+	fmt.Println("Configuration is valid; nothing was processed.")
+}
+
+// dryRunSimulate validates a simulator run of the configuration over schema
+// and prints its plan
+func dryRunSimulate(config cli.Config, schema *logsimulator.Schema, actors *logsimulator.ActorScenario) {
+	p := &dryRunPlan{}
+	p.check("simulator configuration", config.Validate())
+	_, err := newParser(config.DBType)
+	p.check("parser for "+config.DBType, err)
+	p.check("custom fields", schema.AddColumns(config.CustomFields))
+	if *simulatorOutput != "" {
+		_, err := logsimulator.NewWireEncoder(logsimulator.WireFormat(*simulatorFormat), config.DBType)
+		p.check("simulator output format "+*simulatorFormat, err)
+		p.check("simulator output "+*simulatorOutput, checkFileDir(*simulatorOutput))
+	}
+	if *labelsOutput != "" {
+		p.check("labels output "+*labelsOutput, checkFileDir(*labelsOutput))
+	}
+	if *keyEscrow != "" {
+		p.check("key escrow "+*keyEscrow, checkFileDir(*keyEscrow))
+	}
+
+	switch {
+	case actors != nil:
+		p.describe("Mode", fmt.Sprintf("actors of %s", *actorsFile))
+	case config.Live:
+		p.describe("Mode", "live")
+	default:
+		p.describe("Mode", fmt.Sprintf("%d rows", config.RowCount))
+	}
+	names := signalNames(config.SelectedSignals)
+	p.describe("Signals", strings.Join(names, ", "))
+	p.describe("Simulator", strings.ReplaceAll(config.String(), "\n", "\n"+strings.Repeat(" ", 15)))
+	checkProcessing(p, names, config.OutputFormat)
+	p.finish("simulate")
+}
+
+// dryRunSource validates a run of the -source and prints its plan
+func dryRunSource() {
+	p := &dryRunPlan{}
+	dbType, err := sourceLogFormat(*sourceType)
+	p.check("source "+*sourceType, err)
+	if err == nil {
+		_, err = newParser(dbType)
+		p.check("parser for "+dbType, err)
+	}
+	switch *sourceType {
+	case "mysql":
+		p.check("MySQL primary "+*mysqlAddr, checkDial(*mysqlAddr))
+	case "syslog":
+		p.check("syslog listener "+*syslogAddr, checkListen(*syslogNetwork, *syslogAddr))
+	case "replay":
+		_, err := os.Stat(*replayFile)
+		if *replayFile == "" {
+			err = fmt.Errorf("-replay-file is required")
+		}
+		p.check("replay file "+*replayFile, err)
+	case "objectstore", "kinesis":
+		p.skip(*sourceType+" source", "cloud credentials and access are checked when the run starts")
+	}
+	if *checkpointFile != "" {
+		p.check("checkpoint file "+*checkpointFile, checkFileDir(*checkpointFile))
+	}
+	if *queueSpillDir != "" {
+		p.check("queue spill directory "+*queueSpillDir, checkDir(*queueSpillDir))
+	}
+
+	p.describe("Source", *sourceType)
+	if dbType != "" {
+		p.describe("Parser", dbType)
+	}
+	names := signalNames([]cli.SignalType{cli.SignalTypeAll})
+	p.describe("Signals", strings.Join(names, ", "))
+	checkProcessing(p, names, cli.OutputFormatJSON)
+	p.finish(*sourceType)
+}
+
+// dryRunServe validates the scoring API and prints its plan
+func dryRunServe() {
+	p := &dryRunPlan{}
+	p.check("scoring API address "+*serveAddr, checkListen("tcp", *serveAddr))
+	p.describe("Scoring API", *serveAddr)
+	names := signalNames([]cli.SignalType{cli.SignalTypeAll})
+	p.describe("Signals", strings.Join(names, ", "))
+	checkProcessing(p, names, cli.OutputFormatJSON)
+	p.finish("serve")
+}
+
+// checkProcessing checks the rules, detectors, classification, alerts and
+// outputs applied to vectors laid out as names, and describes them
+func checkProcessing(p *dryRunPlan, names []string, format cli.OutputFormat) {
+	if *rulesFile != "" {
+		_, err := logprocessor.LoadRuleFile(*rulesFile, names)
+		if err == nil && *outputSchemaVersion < 2 {
+			err = fmt.Errorf("-rules requires -output-schema-version 2 or later")
+		}
+		p.check("rules "+*rulesFile, err)
+	}
+
+	selected := splitList(*detectorList)
+	if *ransomwareMode && !slices.Contains(selected, "ransomware") {
+		selected = append(selected, "ransomware")
+	}
+	for _, name := range selected {
+		_, err := logprocessor.NewDetector(name, names)
+		if err == nil && *outputSchemaVersion < 2 {
+			err = fmt.Errorf("-detectors requires -output-schema-version 2 or later")
+		}
+		p.check("detector "+name, err)
+	}
+	if len(selected) > 0 {
+		p.describe("Detectors", strings.Join(selected, ", "))
+	}
+	switch *runMode {
+	case "":
+	case modeTrain, modeDetect:
+		if *baselineFile == "" {
+			p.check("mode "+*runMode, fmt.Errorf("-mode %s requires -baseline-file", *runMode))
+		} else if *runMode == modeDetect {
+			_, err := os.Stat(*baselineFile)
+			p.check("baseline file "+*baselineFile, err)
+		} else {
+			p.check("baseline file "+*baselineFile, checkFileDir(*baselineFile))
+		}
+		if len(selected) == 0 && *driftOutput == "" {
+			p.check("mode "+*runMode, fmt.Errorf("-mode requires -detectors or -drift-output"))
+		}
+	default:
+		p.check("mode", fmt.Errorf("unknown mode %q (expected train or detect)", *runMode))
+	}
+
+	if *feedbackFile != "" {
+		_, err := logprocessor.LoadFeedbackFile(*feedbackFile)
+		p.check("feedback file "+*feedbackFile, err)
+	} else if *feedbackAddr != "" {
+		p.check("feedback endpoint", fmt.Errorf("-feedback-addr requires -feedback-file"))
+	}
+	if *suppressionFile != "" {
+		_, err := logprocessor.LoadSuppressionFile(*suppressionFile)
+		p.check("suppression rules "+*suppressionFile, err)
+	}
+	if *tableConfigFile != "" {
+		_, err := logprocessor.LoadTableConfigFile(*tableConfigFile)
+		p.check("table config "+*tableConfigFile, err)
+	}
+	if *metricsAddr != "" {
+		p.check("metrics address "+*metricsAddr, checkListen("tcp", *metricsAddr))
+	}
+
+	checkAlerts(p)
+	checkOutputs(p, format)
+}
+
+// checkAlerts checks the alert settings and that alert webhooks are reachable
+func checkAlerts(p *dryRunPlan) {
+	var destinations []string
+	if *alertSlackWebhook != "" {
+		destinations = append(destinations, "Slack")
+		p.check("Slack alert webhook", checkURL(*alertSlackWebhook))
+	}
+	if *alertWebhook != "" {
+		destinations = append(destinations, "webhook "+*alertWebhook)
+		p.check("alert webhook "+*alertWebhook, checkURL(*alertWebhook))
+	}
+	if *alertPagerDutyKey != "" {
+		destinations = append(destinations, "PagerDuty")
+		p.skip("PagerDuty alerts", "the routing key is checked when an alert is sent")
+	}
+	if *alertSMTP != "" {
+		destinations = append(destinations, "email")
+		_, err := alert.NewEmailNotifier(*alertSMTP, *alertEmailFrom, splitList(*alertEmailTo))
+		p.check("email alerts", err)
+	}
+	if len(destinations) == 0 {
+		return
+	}
+	p.describe("Alerts", strings.Join(destinations, ", "))
+	_, err := logprocessor.ParseSeverity(*alertMinSeverity)
+	p.check("alert minimum severity", err)
+	if *alertTemplate != "" {
+		text, err := os.ReadFile(*alertTemplate)
+		if err == nil {
+			_, err = alert.ParseTemplate(string(text))
+		}
+		p.check("alert template "+*alertTemplate, err)
+	}
+}
+
+// checkOutputs checks that output files can be created in existing
+// directories and that remote outputs are reachable, and describes them
+func checkOutputs(p *dryRunPlan, format cli.OutputFormat) {
+	var outputs []string
+	if *consoleOutput {
+		outputs = append(outputs, "console")
+	}
+	if *outputPath != "" {
+		if *outputFormat != "" {
+			format = cli.OutputFormat(strings.ToUpper(*outputFormat))
+		}
+		outputs = append(outputs, fmt.Sprintf("file %s (%s)", *outputPath, format))
+		switch format {
+		case cli.OutputFormatJSON, cli.OutputFormatCSV, cli.OutputFormatParquet, cli.OutputFormatAvro, cli.OutputFormatProto:
+			p.check("output format "+string(format), nil)
+		default:
+			p.check("output format", fmt.Errorf("unsupported output format: %s", format))
+		}
+		if *outputPath != "-" {
+			p.check("output "+*outputPath, checkFileDir(*outputPath))
+		}
+	}
+	files := []struct{ name, path string }{
+		{"window output", *windowOutput},
+		{"table output", *tableOutput},
+		{"drift output", *driftOutput},
+		{"STIX file", *stixFile},
+	}
+	for _, file := range files {
+		if file.path != "" {
+			outputs = append(outputs, file.name+" "+file.path)
+			p.check(file.name+" "+file.path, checkFileDir(file.path))
+		}
+	}
+	if *kafkaTopic != "" {
+		outputs = append(outputs, "Kafka topic "+*kafkaTopic)
+		for _, broker := range splitList(*kafkaBrokers) {
+			p.check("Kafka broker "+broker, checkDial(broker))
+		}
+		if *schemaRegistry != "" {
+			p.check("schema registry "+*schemaRegistry, checkURL(*schemaRegistry))
+		}
+	}
+	if *forwardURL != "" {
+		outputs = append(outputs, "forward "+redactURL(*forwardURL))
+		p.check("forward URL "+redactURL(*forwardURL), checkURL(*forwardURL))
+	}
+	if *clickhouseURL != "" {
+		outputs = append(outputs, "ClickHouse "+redactURL(*clickhouseURL))
+		p.check("ClickHouse "+redactURL(*clickhouseURL), checkURL(*clickhouseURL))
+	}
+	if *siemAddr != "" {
+		outputs = append(outputs, fmt.Sprintf("SIEM %s over %s", *siemAddr, *siemNetwork))
+		if *siemNetwork == "udp" {
+			p.skip("SIEM collector "+*siemAddr, "udp is connectionless")
+		} else {
+			p.check("SIEM collector "+*siemAddr, checkDial(*siemAddr))
+		}
+	}
+	if *archiveURL != "" {
+		outputs = append(outputs, "archive "+*archiveURL)
+		p.skip("archive "+*archiveURL, "cloud credentials and access are checked when the run starts")
+	}
+	if *sqlDSN != "" {
+		outputs = append(outputs, "SQL table "+*sqlTable)
+		p.skip("SQL database", "the connection is opened when the run starts")
+	}
+	if len(outputs) == 0 {
+		outputs = append(outputs, "none")
+	}
+	p.describe("Outputs", strings.Join(outputs, ", "))
+}
+
+// sourceLogFormat returns the log format a source delivers, parsed by the
+// parser of that database type
+func sourceLogFormat(source string) (string, error) {
+	switch source {
+	case "mysql":
+		return "mysql", nil
+	case "syslog":
+		return "audit", nil
+	case "objectstore", "kinesis", "replay":
+		return *sourceDBType, nil
+	default:
+		return "", fmt.Errorf("unsupported source: %s (expected mysql, objectstore, kinesis, syslog or replay)", source)
+	}
+}
+
+// checkDir checks that dir is an existing directory
+func checkDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	return nil
+}
+
+// checkFileDir checks that the directory a file is created in exists
+func checkFileDir(path string) error {
+	if err := checkDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("cannot create %s: %w", path, err)
+	}
+	return nil
+}
+
+// checkDial checks that a TCP connection to addr can be opened
+func checkDial(addr string) error {
+	conn, err := net.DialTimeout("tcp", addr, dryRunDialTimeout)
+	if err != nil {
+		return fmt.Errorf("unreachable: %w", err)
+	}
+	return conn.Close()
+}
+
+// checkURL checks that the host of an HTTP URL is reachable
+func checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL %q has no host", redactURL(raw))
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return checkDial(net.JoinHostPort(u.Hostname(), port))
+}
+
+// checkListen checks that a listener can be opened at addr
+func checkListen(network, addr string) error {
+	if network == "udp" {
+		conn, err := net.ListenPacket(network, addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	return listener.Close()
+}
+
+// redactURL hides the password of a URL's user info
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"log-signal-processor/logprocessor"
+	"log-signal-processor/logsimulator"
+	"log-signal-processor/output"
+)
+
+// anyDetection names the row scoring every flagged input, whichever
+// detector or rule flagged it
+const anyDetection = "any"
+
+// confusion counts the inputs a detector flagged or missed
+type confusion struct {
+	truePositives, falsePositives, falseNegatives int
+}
+
+// precision is the share of flagged inputs that were attack changes
+func (c confusion) precision() float64 {
+	if c.truePositives+c.falsePositives == 0 {
+		return 0
+	}
+	return float64(c.truePositives) / float64(c.truePositives+c.falsePositives)
+}
+
+// recall is the share of attack changes that were flagged
+func (c confusion) recall() float64 {
+	if c.truePositives+c.falseNegatives == 0 {
+		return 0
+	}
+	return float64(c.truePositives) / float64(c.truePositives+c.falseNegatives)
+}
+
+// f1 is the harmonic mean of precision and recall
+func (c confusion) f1() float64 {
+	p, r := c.precision(), c.recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+// labelKey identifies the change of one field, to the second, since not
+// every log format keeps sub-second timestamps
+func labelKey(table, row, field string, timestamp time.Time) string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%d", table, row, field, timestamp.Unix())
+}
+
+// evaluateDetections scores the detections of an NDJSON output file against
+// the ground truth a -labels-output file recorded in the same run, printing
+// the precision and recall of every detector and of all of them together.
+// Suppressed inputs count as not flagged.
+func evaluateDetections(outputPath, labelsPath string) error {
+	attacked, err := readLabelKeys(labelsPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(outputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scores := make(map[string]*confusion)
+	score := func(name string) *confusion {
+		if scores[name] == nil {
+			scores[name] = &confusion{}
+		}
+		return scores[name]
+	}
+	score(anyDetection)
+	var inputs, positives int
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if _, isManifest, _ := output.ParseManifestJSON(scanner.Bytes()); isManifest {
+			continue
+		}
+		var input logprocessor.AnomalyInput
+		if err := json.Unmarshal(scanner.Bytes(), &input); err != nil {
+			return fmt.Errorf("%s:%d: %w", outputPath, line, err)
+		}
+		inputs++
+
+		attack := attacked[labelKey(input.Table, input.RowIdentifier, logprocessor.DDLAllColumns, input.Timestamp)]
+		for _, column := range strings.Split(input.Column, ",") {
+			attack = attack || attacked[labelKey(input.Table, input.RowIdentifier, column, input.Timestamp)]
+		}
+		flagged := make(map[string]bool)
+		if input.Anomalous && input.Suppressed == "" {
+			flagged[anyDetection] = true
+			for _, detection := range input.Detections {
+				flagged[detection.Detector] = true
+			}
+		}
+		for name := range flagged {
+			if attack {
+				score(name).truePositives++
+			} else {
+				score(name).falsePositives++
+			}
+		}
+		if attack {
+			positives++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for _, c := range scores {
+		c.falseNegatives = positives - c.truePositives
+	}
+
+	names := make([]string, 0, len(scores))
+	for name := range scores {
+		if name != anyDetection {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append(names, anyDetection)
+
+	fmt.Printf("%d inputs, %d of them attack changes of %d labelled fields\n\n", inputs, positives, len(attacked))
+	fmt.Printf("%-14s %8s %8s %8s %10s %8s %8s\n", "detector", "tp", "fp", "fn", "precision", "recall", "f1")
+	for _, name := range names {
+		c := scores[name]
+		fmt.Printf("%-14s %8d %8d %8d %10.3f %8.3f %8.3f\n", name, c.truePositives, c.falsePositives, c.falseNegatives, c.precision(), c.recall(), c.f1())
+	}
+	return nil
+}
+
+// readLabelKeys reads a -labels-output file into the keys of the fields the
+// attacks changed
+func readLabelKeys(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	keys := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var label logsimulator.Label
+		if err := json.Unmarshal(scanner.Bytes(), &label); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		for _, field := range label.Fields {
+			keys[labelKey(label.Table, label.Row, field, label.Timestamp)] = true
+		}
+	}
+	return keys, scanner.Err()
+}
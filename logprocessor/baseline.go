@@ -0,0 +1,51 @@
+package logprocessor
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// BaselineKey identifies a piece of a detector's learned state: the series of
+// one signal of a table and column, or the whole signal vector when Signal is
+// empty
+type BaselineKey struct {
+	Table  string
+	Column string
+	Signal string
+}
+
+// BaselineDetector is implemented by detectors whose learned statistics can
+// be persisted, so a restarted process does not have to relearn what normal
+// traffic looks like. States are opaque JSON documents. Freeze stops the
+// detector learning, so it only scores against its baselines and traffic
+// being scored cannot shift them.
+type BaselineDetector interface {
+	Detector
+	Baselines() (map[BaselineKey][]byte, error)
+	RestoreBaselines(baselines map[BaselineKey][]byte) error
+	Freeze()
+}
+
+// baselineKey converts a series key to its persisted form, naming the signal
+func baselineKey(key seriesKey, names []string) BaselineKey {
+	return BaselineKey{Table: key.table, Column: key.column, Signal: signalLabel(names, key.signal)}
+}
+
+// seriesKeyOf converts a persisted key back, reporting false for signals
+// that are not in the current layout
+func seriesKeyOf(key BaselineKey, names []string) (seriesKey, bool) {
+	for i, name := range names {
+		if name == key.Signal {
+			return seriesKey{table: key.Table, column: key.Column, signal: i}, true
+		}
+	}
+	return seriesKey{}, false
+}
+
+// decodeBaseline unmarshals a persisted state into state
+func decodeBaseline(key BaselineKey, data []byte, state interface{}) error {
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("invalid baseline for %s.%s %s: %w", key.Table, key.Column, key.Signal, err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package logprocessor
+
+import (
+	"fmt"
+	"time"
+)
+
+// Defaults for the burst detector
+const (
+	DefaultBurstWindow       = time.Minute
+	DefaultBurstCount        = 20
+	DefaultBurstEntropyDelta = 1.0
+)
+
+// BurstDetector flags encryption sweeps: bursts of updates that raise a
+// value's entropy sharply, the signature of ransomware rewriting a table. It
+// counts such updates per table, and per session when the log records one,
+// over a sliding window of their timestamps, and flags inputs once more than
+// Count of them fell in the window. A row whose columns are reported as
+// separate inputs counts once.
+type BurstDetector struct {
+	window   time.Duration
+	count    int
+	minDelta float64
+	entropy  int // Position of the entropy change signal
+	name     string
+
+	tables    map[string]*burstCounter
+	sessions  map[string]*burstCounter
+	lastSweep time.Time
+}
+
+// burstCounter holds the timestamps of the suspicious updates in a window
+type burstCounter struct {
+	times   []time.Time
+	lastRow string
+}
+
+// NewBurstDetector creates a detector for vectors laid out as signalNames,
+// which must include the Entropy signal. Non-positive settings select the
+// defaults.
+func NewBurstDetector(window time.Duration, count int, minDelta float64, signalNames []string) (*BurstDetector, error) {
+	entropy := -1
+	for i, name := range signalNames {
+		if name == "Entropy" {
+			entropy = i
+		}
+	}
+	if entropy < 0 {
+		return nil, fmt.Errorf("burst detector requires the Entropy signal")
+	}
+	if window <= 0 {
+		window = DefaultBurstWindow
+	}
+	if count <= 0 {
+		count = DefaultBurstCount
+	}
+	if minDelta <= 0 {
+		minDelta = DefaultBurstEntropyDelta
+	}
+	return &BurstDetector{
+		window:   window,
+		count:    count,
+		minDelta: minDelta,
+		entropy:  entropy,
+		name:     signalNames[entropy],
+		tables:   make(map[string]*burstCounter),
+		sessions: make(map[string]*burstCounter),
+	}, nil
+}
+
+// Name identifies the detector in detections
+func (d *BurstDetector) Name() string {
+	return "burst"
+}
+
+// Detect counts input if its entropy rose by at least the minimum delta and
+// flags it if its table or session is in a burst. The score is the number of
+// suspicious updates in the window, preferring the session's count.
+func (d *BurstDetector) Detect(input AnomalyInput) []Detection {
+	if d.entropy >= len(input.SignalVector) {
+		return nil
+	}
+	delta := input.SignalVector[d.entropy]
+	if delta < d.minDelta {
+		return nil
+	}
+	ts := input.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	d.sweep(ts)
+
+	row := input.RowIdentifier + "\x00" + ts.String()
+	counted := countBurst(d.tables, input.Table, row, ts, d.window)
+	if input.Session != "" {
+		if n := countBurst(d.sessions, input.Table+"\x00"+input.Session, row, ts, d.window); n > d.count {
+			counted = n
+		}
+	}
+	if counted <= d.count {
+		return nil
+	}
+	return []Detection{{
+		Detector: d.Name(),
+		Signal:   d.name,
+		Value:    delta,
+		Score:    float64(counted),
+	}}
+}
+
+// countBurst records an update of row at ts in the counter for key, returning
+// the number of updates in the window
+func countBurst(counters map[string]*burstCounter, key, row string, ts time.Time, window time.Duration) int {
+	c, ok := counters[key]
+	if !ok {
+		c = &burstCounter{}
+		counters[key] = c
+	}
+	if row != c.lastRow {
+		c.times = append(c.times, ts)
+		c.lastRow = row
+	}
+
+	cutoff := ts.Add(-window)
+	expired := 0
+	for expired < len(c.times) && !c.times[expired].After(cutoff) {
+		expired++
+	}
+	c.times = c.times[expired:]
+	return len(c.times)
+}
+
+// sweep forgets tables and sessions without updates in the last window, at
+// most once per window, so ended sessions do not accumulate
+func (d *BurstDetector) sweep(ts time.Time) {
+	if ts.Sub(d.lastSweep) < d.window {
+		return
+	}
+	d.lastSweep = ts
+	sweepBursts(d.tables, ts, d.window)
+	sweepBursts(d.sessions, ts, d.window)
+}
+
+// sweepBursts forgets counters without updates in the window ending at ts
+func sweepBursts(counters map[string]*burstCounter, ts time.Time, window time.Duration) {
+	cutoff := ts.Add(-window)
+	for key, c := range counters {
+		if len(c.times) == 0 || !c.times[len(c.times)-1].After(cutoff) {
+			delete(counters, key)
+		}
+	}
+}
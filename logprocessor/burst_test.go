@@ -0,0 +1,68 @@
+package logprocessor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// burstInput returns an update of row raising entropy by delta at second s
+func burstInput(row string, delta float64, s int) AnomalyInput {
+	input := vectorInput(delta, 0)
+	input.RowIdentifier = row
+	input.Timestamp = time.Date(2024, 1, 1, 0, 0, s, 0, time.UTC)
+	return input
+}
+
+func TestBurstDetector(t *testing.T) {
+	d, err := NewBurstDetector(time.Minute, 3, 1, testSignals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Updates raising entropy by less than the minimum never count
+	for i := 0; i < 10; i++ {
+		feed(t, d, burstInput(fmt.Sprint("quiet-", i), 0.5, i))
+	}
+	// Three sweeping updates, the second's columns reported separately
+	feed(t, d, burstInput("1", 2, 10), burstInput("2", 2, 11), burstInput("2", 2, 11), burstInput("3", 2, 12))
+
+	detections := d.Detect(burstInput("4", 2.5, 13))
+	if len(detections) != 1 {
+		t.Fatalf("got %d detections, want 1: %+v", len(detections), detections)
+	}
+	got := detections[0]
+	if got.Detector != "burst" || got.Signal != "Entropy" || got.Value != 2.5 || got.Score != 4 {
+		t.Errorf("detection %+v, want a score of 4 updates", got)
+	}
+
+	// Once the window has passed the sweep starts over
+	feed(t, d, burstInput("5", 2, 200))
+}
+
+func TestBurstDetectorSessions(t *testing.T) {
+	d, err := NewBurstDetector(time.Minute, 2, 1, testSignals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flagged inputs score their table's count until their session is in a
+	// burst of its own
+	want := []float64{0, 0, 3, 4, 3}
+	for i, session := range []string{"app", "app", "attacker", "attacker", "attacker"} {
+		input := burstInput(fmt.Sprint(i), 2, i)
+		input.Session = session
+		var score float64
+		if detections := d.Detect(input); len(detections) > 0 {
+			score = detections[0].Score
+		}
+		if score != want[i] {
+			t.Errorf("update %d of %s scored %v, want %v", i, session, score, want[i])
+		}
+	}
+}
+
+func TestBurstDetectorRequiresEntropy(t *testing.T) {
+	if _, err := NewBurstDetector(0, 0, 0, []string{"Levenshtein"}); err == nil {
+		t.Error("created a burst detector without the Entropy signal")
+	}
+}
@@ -0,0 +1,83 @@
+package logprocessor
+
+import "strings"
+
+// DDL operations, as normalised by DDLKind
+const (
+	DDLTruncate   = "TRUNCATE"
+	DDLDropTable  = "DROP TABLE"
+	DDLAlterTable = "ALTER TABLE"
+)
+
+// DDLDetectorName identifies DDL events in detections
+const DDLDetectorName = "ddl"
+
+// DDLAllColumns is the column of DDL inputs affecting the whole table
+const DDLAllColumns = "*"
+
+// DDLKind returns the DDL operation a log's operation names, or "" for data
+// changes. It accepts the spellings of the supported logs: TRUNCATE or
+// TRUNCATE TABLE, DROP TABLE, ALTER TABLE, and AWS DMS control operations
+// such as drop-table and column-type-change. Dropping or changing a column
+// is a table alteration.
+func DDLKind(operation string) string {
+	op := strings.ToUpper(strings.NewReplacer("-", " ", "_", " ").Replace(operation))
+	words := strings.Fields(op)
+	if len(words) == 0 {
+		return ""
+	}
+	switch {
+	case words[0] == "TRUNCATE":
+		return DDLTruncate
+	case words[0] == "DROP" && (len(words) == 1 || words[1] == "TABLE"):
+		return DDLDropTable
+	case words[0] == "ALTER" || strings.Contains(op, "COLUMN"):
+		return DDLAlterTable
+	}
+	return ""
+}
+
+// NewDDLInput creates the anomaly input for a DDL log: one per statement,
+// naming the altered columns if the log lists any and DDLAllColumns
+// otherwise. DDL events carry no values, so their signal vector of width
+// zeros is only a placeholder.
+func NewDDLInput(logData LogData, width int) AnomalyInput {
+	column := strings.Join(logData.Columns, ",")
+	if column == "" {
+		column = DDLAllColumns
+	}
+	return AnomalyInput{
+		Operation:     DDLKind(logData.Operation),
+		Table:         logData.Table,
+		RowIdentifier: logData.RowIdentifier,
+		Session:       logData.Session,
+		Column:        column,
+		Timestamp:     logData.Timestamp,
+		SignalVector:  make([]float64, width),
+	}
+}
+
+// FlagDDL flags a DDL input. Ransomware and wipers mix destructive schema
+// changes with encryption, so every DDL event is reported: truncating or
+// dropping a table destroys its rows and is critical, other alterations
+// warn (see SeverityClassifier). The detection names no signal.
+func FlagDDL(input *AnomalyInput) {
+	input.Anomalous = true
+	input.Detections = append(input.Detections, Detection{
+		Detector: DDLDetectorName,
+		Value:    1,
+		Score:    1,
+	})
+}
+
+// ddlSeverity returns the lowest severity of a DDL operation, or "" for
+// data changes
+func ddlSeverity(operation string) Severity {
+	switch DDLKind(operation) {
+	case DDLTruncate, DDLDropTable:
+		return SeverityCritical
+	case DDLAlterTable:
+		return SeverityWarn
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package logprocessor
+
+import (
+	"math"
+	"sort"
+)
+
+// Detection reports one signal of an anomaly input that a detector found anomalous
+type Detection struct {
+	Detector string  `json:"detector"`
+	Signal   string  `json:"signal"`
+	Value    float64 `json:"value"`
+	Score    float64 `json:"score"` // Detector-specific, e.g. the z-score
+	// Values in the baseline the score was computed against, and the
+	// half-width of the score's 95% confidence interval. Scores against small
+	// baselines are less certain; detectors without a learned baseline leave
+	// both unset.
+	Samples     int     `json:"samples,omitempty"`
+	Uncertainty float64 `json:"uncertainty,omitempty"`
+	// Set by detectors scoring several signals together, splitting the flag
+	// between them; otherwise Signal accounts for all of it
+	Contributions []Contribution `json:"contributions,omitempty"`
+}
+
+// Contribution is the share of an anomaly flag attributed to one signal
+type Contribution struct {
+	Signal  string  `json:"signal"`
+	Percent float64 `json:"percent"`
+}
+
+// Detector learns the normal behaviour of signals and flags deviations from it.
+// Detect scores input against what has been learned so far and then learns
+// from it. Detectors keep separate state for each table and column. See
+// RegisterDetector for making one selectable by name.
+type Detector interface {
+	Name() string
+	Detect(input AnomalyInput) []Detection
+}
+
+// ApplyDetectors runs every detector over input, recording their detections
+// and flagging input as anomalous if any detector fired
+func ApplyDetectors(detectors []Detector, input *AnomalyInput) {
+	for _, d := range detectors {
+		input.Detections = append(input.Detections, d.Detect(*input)...)
+	}
+	if len(input.Detections) > 0 {
+		input.Anomalous = true
+		input.Contributions = SignalContributions(input.Detections)
+	}
+}
+
+// SignalContributions attributes detections to signals as percentages,
+// largest first. Each detection counts equally, since scores of different
+// detectors are not comparable, and is split by its own contributions.
+// Detections scoring the input as a whole name no signal and are left out.
+func SignalContributions(detections []Detection) []Contribution {
+	shares := make(map[string]float64)
+	attributed := 0
+	for _, d := range detections {
+		if len(d.Contributions) == 0 {
+			if d.Signal == "" {
+				continue
+			}
+			shares[d.Signal] += 100
+			attributed++
+			continue
+		}
+		for _, c := range d.Contributions {
+			shares[c.Signal] += c.Percent
+		}
+		attributed++
+	}
+	if attributed == 0 {
+		return nil
+	}
+
+	contributions := make([]Contribution, 0, len(shares))
+	for signal, share := range shares {
+		contributions = append(contributions, Contribution{Signal: signal, Percent: share / float64(attributed)})
+	}
+	sort.Slice(contributions, func(i, j int) bool {
+		if contributions[i].Percent != contributions[j].Percent {
+			return contributions[i].Percent > contributions[j].Percent
+		}
+		return contributions[i].Signal < contributions[j].Signal
+	})
+	return contributions
+}
+
+// scoreUncertainty returns the half-width of the 95% confidence interval of
+// a standardised score against a baseline of n values. With the baseline's
+// centre and spread estimated from n values, the score's standard error is
+// about sqrt(1/n + score^2/2n).
+func scoreUncertainty(score float64, n int) float64 {
+	if n <= 0 {
+		return 0
+	}
+	return 1.96 * math.Sqrt((1+score*score/2)/float64(n))
+}
+
+// TrainDetectors lets every detector learn from input without flagging it
+func TrainDetectors(detectors []Detector, input AnomalyInput) {
+	for _, d := range detectors {
+		d.Detect(input)
+	}
+}
+
+// seriesKey identifies the values of one signal for one table and column
+type seriesKey struct {
+	table  string
+	column string
+	signal int
+}
@@ -0,0 +1,51 @@
+package logprocessor
+
+import (
+	"math"
+	"testing"
+)
+
+// testSignals is the vector layout of the detector tests
+var testSignals = []string{"Entropy", "Levenshtein"}
+
+// vectorInput returns an input of users.email carrying vector
+func vectorInput(vector ...float64) AnomalyInput {
+	return AnomalyInput{Operation: "UPDATE", Table: "users", Column: "email", SignalVector: vector}
+}
+
+// feed passes every input to d, failing if any is flagged
+func feed(t *testing.T, d Detector, inputs ...AnomalyInput) {
+	t.Helper()
+	for i, input := range inputs {
+		if detections := d.Detect(input); len(detections) > 0 {
+			t.Fatalf("baseline input %d flagged: %+v", i, detections)
+		}
+	}
+}
+
+// near reports whether got is within tolerance of want
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestApplyDetectorsFlagsAndAttributes(t *testing.T) {
+	zscore := NewZScoreDetector(4, 2, testSignals)
+	detectors := []Detector{zscore}
+	for _, v := range []float64{1, 2, 1, 2} {
+		input := vectorInput(v, 5)
+		ApplyDetectors(detectors, &input)
+		if input.Anomalous {
+			t.Fatalf("baseline value %v flagged", v)
+		}
+	}
+
+	input := vectorInput(9, 5)
+	ApplyDetectors(detectors, &input)
+	if !input.Anomalous || len(input.Detections) != 1 {
+		t.Fatalf("outlier not flagged: %+v", input)
+	}
+	want := []Contribution{{Signal: "Entropy", Percent: 100}}
+	if len(input.Contributions) != 1 || input.Contributions[0] != want[0] {
+		t.Errorf("contributions %+v, want %+v", input.Contributions, want)
+	}
+}
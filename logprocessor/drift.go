@@ -0,0 +1,252 @@
+package logprocessor
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+)
+
+// DriftRecordType marks a drift report record
+const DriftRecordType = "drift"
+
+// Defaults for the drift monitor
+const (
+	DefaultDriftWindow    = 500
+	DefaultDriftMinimum   = 100
+	DefaultDriftThreshold = 0.25
+)
+
+// driftBins is the number of reference quantile bins PSI is computed over
+const driftBins = 10
+
+// DriftReport compares the signals of a table and column's latest inputs
+// against their reference distributions
+type DriftReport struct {
+	RecordType string        `json:"record_type"` // Always DriftRecordType
+	Table      string        `json:"table"`
+	Column     string        `json:"column"`
+	Timestamp  time.Time     `json:"timestamp"` // Of the last input compared
+	Inputs     int           `json:"inputs"`
+	PSI        float64       `json:"psi"` // Largest PSI of the signals
+	Signals    []SignalDrift `json:"signals"`
+	Drifted    bool          `json:"drifted,omitempty"`
+}
+
+// SignalDrift is the drift of one signal: its population stability index
+// over reference decile bins and its Kolmogorov-Smirnov statistic, the
+// largest gap between the reference and current cumulative distributions
+type SignalDrift struct {
+	Signal    string  `json:"signal"`
+	PSI       float64 `json:"psi"`
+	KS        float64 `json:"ks"`
+	Reference int     `json:"reference"` // Values in the reference distribution
+}
+
+// DriftMonitor reports when the signal distributions of a table and column
+// move away from the baseline they were learned from, so stale baselines are
+// retrained rather than silently scoring against outdated behaviour. It keeps
+// a reference distribution of every (table, column, signal) series and
+// compares each Window inputs of a table and column against it. A PSI of
+// Threshold or more (0.25 is the usual rule of thumb for a significant
+// shift) marks the report as drifted.
+//
+// The reference is persisted like a detector's baselines, so it can be
+// trained on known-clean traffic and frozen while detecting.
+type DriftMonitor struct {
+	window      int
+	minimum     int
+	threshold   float64
+	signalNames []string
+	reference   map[seriesKey]*tdigest
+	current     map[seriesKey]*driftWindow
+	frozen      bool
+}
+
+// driftWindow holds the signal values of a table and column's latest inputs
+type driftWindow struct {
+	values [][]float64 // Per signal
+	inputs int
+}
+
+// NewDriftMonitor creates a monitor for vectors laid out as signalNames,
+// comparing every window inputs of a table and column once their series'
+// references hold DefaultDriftMinimum values. Non-positive settings select
+// the defaults.
+func NewDriftMonitor(window int, threshold float64, signalNames []string) *DriftMonitor {
+	if window <= 0 {
+		window = DefaultDriftWindow
+	}
+	if threshold <= 0 {
+		threshold = DefaultDriftThreshold
+	}
+	return &DriftMonitor{
+		window:      window,
+		minimum:     DefaultDriftMinimum,
+		threshold:   threshold,
+		signalNames: signalNames,
+		reference:   make(map[seriesKey]*tdigest),
+		current:     make(map[seriesKey]*driftWindow),
+	}
+}
+
+// Name identifies the monitor's baselines
+func (d *DriftMonitor) Name() string {
+	return "drift"
+}
+
+// Detect never flags inputs; drift is reported per table and column by
+// Observe. It exists so the reference is persisted like detector baselines.
+func (d *DriftMonitor) Detect(AnomalyInput) []Detection {
+	return nil
+}
+
+// Observe adds input to its table and column's window, learning it into the
+// reference unless frozen, and returns a report once the window is full and
+// any of its signals has a reference to compare against
+func (d *DriftMonitor) Observe(input AnomalyInput) *DriftReport {
+	key := seriesKey{table: input.Table, column: input.Column}
+	w, ok := d.current[key]
+	if !ok {
+		w = &driftWindow{}
+		d.current[key] = w
+	}
+	for i, value := range input.SignalVector {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		for len(w.values) <= i {
+			w.values = append(w.values, nil)
+		}
+		w.values[i] = append(w.values[i], value)
+		if d.frozen {
+			continue
+		}
+		series := seriesKey{table: input.Table, column: input.Column, signal: i}
+		digest, ok := d.reference[series]
+		if !ok {
+			digest = &tdigest{}
+			d.reference[series] = digest
+		}
+		digest.add(value)
+	}
+	w.inputs++
+	if w.inputs < d.window {
+		return nil
+	}
+	delete(d.current, key)
+
+	report := &DriftReport{
+		RecordType: DriftRecordType,
+		Table:      input.Table,
+		Column:     input.Column,
+		Timestamp:  input.Timestamp,
+		Inputs:     w.inputs,
+	}
+	for i, values := range w.values {
+		digest := d.reference[seriesKey{table: input.Table, column: input.Column, signal: i}]
+		if len(values) == 0 || digest == nil || digest.count < float64(d.minimum) {
+			continue
+		}
+		sort.Float64s(values)
+		signal := SignalDrift{
+			Signal:    signalLabel(d.signalNames, i),
+			PSI:       stabilityIndex(digest, values),
+			KS:        ksStatistic(digest, values),
+			Reference: int(digest.count),
+		}
+		report.Signals = append(report.Signals, signal)
+		report.PSI = math.Max(report.PSI, signal.PSI)
+	}
+	if len(report.Signals) == 0 {
+		return nil
+	}
+	report.Drifted = report.PSI >= d.threshold
+	return report
+}
+
+// stabilityIndex computes the population stability index of the sorted
+// values against the reference, over bins bounded by the reference deciles.
+// Tied deciles are merged, so discrete signals get fewer, wider bins.
+func stabilityIndex(reference *tdigest, values []float64) float64 {
+	var edges []float64
+	for b := 1; b < driftBins; b++ {
+		edge := reference.quantile(float64(b) / driftBins)
+		if len(edges) == 0 || edge > edges[len(edges)-1] {
+			edges = append(edges, edge)
+		}
+	}
+	edges = append(edges, math.Inf(1))
+
+	// Empty bins are floored so the logarithm stays finite
+	const floor = 1e-4
+	var psi, lower float64
+	next := 0
+	for b, edge := range edges {
+		expected := 1 - lower
+		if b < len(edges)-1 {
+			expected = reference.cdf(edge) - lower
+			lower += expected
+		}
+		start := next
+		for next < len(values) && values[next] <= edge {
+			next++
+		}
+		actual := float64(next-start) / float64(len(values))
+		expected, actual = math.Max(expected, floor), math.Max(actual, floor)
+		psi += (actual - expected) * math.Log(actual/expected)
+	}
+	return psi
+}
+
+// ksStatistic computes the largest gap between the reference's cumulative
+// distribution and that of the sorted values, checked wherever either steps
+func ksStatistic(reference *tdigest, values []float64) float64 {
+	reference.compress()
+	points := append([]float64(nil), values...)
+	for _, c := range reference.centroids {
+		points = append(points, c.Mean)
+	}
+	var d float64
+	for _, x := range points {
+		below := sort.Search(len(values), func(i int) bool { return values[i] > x })
+		d = math.Max(d, math.Abs(float64(below)/float64(len(values))-reference.cdf(x)))
+	}
+	return d
+}
+
+// Freeze stops the monitor adding values to its reference, so inputs are
+// compared against the baseline as trained
+func (d *DriftMonitor) Freeze() {
+	d.frozen = true
+}
+
+// Baselines returns the reference digest of every series
+func (d *DriftMonitor) Baselines() (map[BaselineKey][]byte, error) {
+	baselines := make(map[BaselineKey][]byte, len(d.reference))
+	for key, digest := range d.reference {
+		digest.compress()
+		data, err := json.Marshal(quantileBaseline{Centroids: digest.centroids, Count: digest.count, Min: digest.min, Max: digest.max})
+		if err != nil {
+			return nil, err
+		}
+		baselines[baselineKey(key, d.signalNames)] = data
+	}
+	return baselines, nil
+}
+
+// RestoreBaselines replaces reference digests with persisted ones
+func (d *DriftMonitor) RestoreBaselines(baselines map[BaselineKey][]byte) error {
+	for key, data := range baselines {
+		series, ok := seriesKeyOf(key, d.signalNames)
+		if !ok {
+			continue
+		}
+		var baseline quantileBaseline
+		if err := decodeBaseline(key, data, &baseline); err != nil {
+			return err
+		}
+		d.reference[series] = &tdigest{centroids: baseline.Centroids, count: baseline.Count, min: baseline.Min, max: baseline.Max}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package logprocessor
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Defaults for the EWMA detector
+const (
+	DefaultEWMAAlpha = 0.05
+	DefaultEWMABands = 3
+)
+
+// EWMADetector tracks an exponentially-weighted moving average and variance
+// of each (table, column, signal) series and flags values outside Bands
+// deviations of the average. Unlike the rolling z-score, the baseline follows
+// gradual drift without forgetting abruptly. A series is scored once it has
+// seen 1/Alpha values, and series without any variation are not scored.
+type EWMADetector struct {
+	alpha       float64
+	bands       float64
+	warmup      int
+	signalNames []string
+	series      map[seriesKey]*ewmaStats
+	frozen      bool
+}
+
+// ewmaStats is the moving average and variance of one series
+type ewmaStats struct {
+	Count    int     `json:"count"`
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+}
+
+// NewEWMADetector creates a detector for vectors laid out as signalNames.
+// alpha in (0, 1] weights new values; out of range values and non-positive
+// bands select the defaults.
+func NewEWMADetector(alpha, bands float64, signalNames []string) *EWMADetector {
+	if alpha <= 0 || alpha > 1 {
+		alpha = DefaultEWMAAlpha
+	}
+	if bands <= 0 {
+		bands = DefaultEWMABands
+	}
+	return &EWMADetector{
+		alpha:       alpha,
+		bands:       bands,
+		warmup:      int(math.Ceil(1 / alpha)),
+		signalNames: signalNames,
+		series:      make(map[seriesKey]*ewmaStats),
+	}
+}
+
+// Name identifies the detector in detections
+func (d *EWMADetector) Name() string {
+	return "ewma"
+}
+
+// Detect scores each signal of input against its moving average, then updates it
+func (d *EWMADetector) Detect(input AnomalyInput) []Detection {
+	var detections []Detection
+	for i, value := range input.SignalVector {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		key := seriesKey{table: input.Table, column: input.Column, signal: i}
+		stats, ok := d.series[key]
+		if !ok {
+			if !d.frozen {
+				d.series[key] = &ewmaStats{Count: 1, Mean: value}
+			}
+			continue
+		}
+
+		diff := value - stats.Mean
+		if stats.Count >= d.warmup && stats.Variance > 1e-12 {
+			if score := diff / math.Sqrt(stats.Variance); math.Abs(score) > d.bands {
+				detections = append(detections, Detection{
+					Detector:    d.Name(),
+					Signal:      signalLabel(d.signalNames, i),
+					Value:       value,
+					Score:       score,
+					Samples:     d.effectiveSamples(stats.Count),
+					Uncertainty: scoreUncertainty(score, d.effectiveSamples(stats.Count)),
+				})
+			}
+		}
+
+		if d.frozen {
+			continue
+		}
+		// Incremental exponentially-weighted mean and variance
+		stats.Count++
+		stats.Mean += d.alpha * diff
+		stats.Variance = (1 - d.alpha) * (stats.Variance + d.alpha*diff*diff)
+	}
+	return detections
+}
+
+// effectiveSamples returns the number of values a moving average of count
+// values effectively averages over, (2-alpha)/alpha at most
+func (d *EWMADetector) effectiveSamples(count int) int {
+	if effective := int((2 - d.alpha) / d.alpha); count > effective {
+		return effective
+	}
+	return count
+}
+
+// Freeze stops the detector updating its moving averages
+func (d *EWMADetector) Freeze() {
+	d.frozen = true
+}
+
+// Baselines returns the moving average and variance of every series
+func (d *EWMADetector) Baselines() (map[BaselineKey][]byte, error) {
+	baselines := make(map[BaselineKey][]byte, len(d.series))
+	for key, stats := range d.series {
+		data, err := json.Marshal(stats)
+		if err != nil {
+			return nil, err
+		}
+		baselines[baselineKey(key, d.signalNames)] = data
+	}
+	return baselines, nil
+}
+
+// RestoreBaselines replaces series averages with persisted ones
+func (d *EWMADetector) RestoreBaselines(baselines map[BaselineKey][]byte) error {
+	for key, data := range baselines {
+		series, ok := seriesKeyOf(key, d.signalNames)
+		if !ok {
+			continue
+		}
+		stats := &ewmaStats{}
+		if err := decodeBaseline(key, data, stats); err != nil {
+			return err
+		}
+		d.series[series] = stats
+	}
+	return nil
+}
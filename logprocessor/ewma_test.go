@@ -0,0 +1,40 @@
+package logprocessor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEWMADetector(t *testing.T) {
+	// With alpha 0.5 series are scored from their second update: after 1, 3,
+	// 2 and 2.5 the average is 2.25 and the variance 0.3125
+	d := NewEWMADetector(0.5, 3, testSignals)
+	feed(t, d, vectorInput(1, 4), vectorInput(3, 4), vectorInput(2, 4), vectorInput(2.5, 4))
+
+	detections := d.Detect(vectorInput(10, 4))
+	if len(detections) != 1 {
+		t.Fatalf("got %d detections, want 1: %+v", len(detections), detections)
+	}
+	got := detections[0]
+	if got.Detector != "ewma" || got.Signal != "Entropy" || got.Value != 10 {
+		t.Errorf("detection %+v", got)
+	}
+	if want := 7.75 / math.Sqrt(0.3125); !near(got.Score, want, 1e-9) {
+		t.Errorf("score %v, want %v", got.Score, want)
+	}
+	// A moving average with alpha 0.5 effectively averages three values
+	if got.Samples != 3 {
+		t.Errorf("samples %d, want 3", got.Samples)
+	}
+}
+
+func TestEWMADetectorWithinBands(t *testing.T) {
+	d := NewEWMADetector(0.5, 3, testSignals)
+	feed(t, d, vectorInput(1, 4), vectorInput(3, 4), vectorInput(2, 4))
+	// 0.5 from an average of 2 with variance 0.5 is 0.71 deviations
+	feed(t, d, vectorInput(2.5, 4))
+	// Below the average counts as much as above it
+	if detections := d.Detect(vectorInput(-8, 4)); len(detections) != 1 || detections[0].Score >= 0 {
+		t.Errorf("detections %+v, want one with a negative score", detections)
+	}
+}
@@ -0,0 +1,229 @@
+package logprocessor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// FeedbackMargin is how far above a false positive's score a detection must
+// be to be reported again for the same table, column, detector and signal
+const FeedbackMargin = 0.1
+
+// Feedback marks an emitted anomaly as a false positive. It is decoded
+// directly from an emitted JSON record; Pattern may be added to it to stop
+// flagging after values of the column that match a regular expression.
+type Feedback struct {
+	Table      string      `json:"table"`
+	Column     string      `json:"column"`
+	Detections []Detection `json:"detections"`
+	Pattern    string      `json:"pattern,omitempty"`
+}
+
+// FeedbackThreshold is the score detections must exceed to be reported for
+// one table, column, detector and signal
+type FeedbackThreshold struct {
+	Table    string  `json:"table"`
+	Column   string  `json:"column"`
+	Detector string  `json:"detector"`
+	Signal   string  `json:"signal"`
+	MinScore float64 `json:"min_score"`
+}
+
+// FeedbackPattern whitelists after values of a table and column
+type FeedbackPattern struct {
+	Table   string `json:"table"`
+	Column  string `json:"column"`
+	Pattern string `json:"pattern"`
+}
+
+// feedbackFile is the layout of a feedback file
+type feedbackFile struct {
+	Thresholds []FeedbackThreshold `json:"thresholds"`
+	Patterns   []FeedbackPattern   `json:"patterns"`
+}
+
+// thresholdKey identifies a FeedbackThreshold
+type thresholdKey struct {
+	table, column, detector, signal string
+}
+
+// FeedbackFilter applies false-positive feedback to detections: each false
+// positive widens the threshold of its table, column, detector and signal to
+// just above the score it had, and whitelist patterns drop every detection
+// of values they match. Rules are not affected. It is safe for concurrent use.
+type FeedbackFilter struct {
+	mu         sync.Mutex
+	thresholds map[thresholdKey]float64
+	patterns   map[[2]string][]*regexp.Regexp
+	sources    []FeedbackPattern
+}
+
+// NewFeedbackFilter creates a filter without any feedback
+func NewFeedbackFilter() *FeedbackFilter {
+	return &FeedbackFilter{
+		thresholds: make(map[thresholdKey]float64),
+		patterns:   make(map[[2]string][]*regexp.Regexp),
+	}
+}
+
+// LoadFeedbackFile reads the feedback saved at path, which need not exist yet
+func LoadFeedbackFile(path string) (*FeedbackFilter, error) {
+	f := NewFeedbackFilter()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file feedbackFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid feedback file %s: %w", path, err)
+	}
+	for _, t := range file.Thresholds {
+		f.thresholds[thresholdKey{t.Table, t.Column, t.Detector, t.Signal}] = t.MinScore
+	}
+	for _, p := range file.Patterns {
+		if err := f.addPattern(p); err != nil {
+			return nil, fmt.Errorf("invalid feedback file %s: %w", path, err)
+		}
+	}
+	return f, nil
+}
+
+// Add incorporates a false positive
+func (f *FeedbackFilter) Add(fb Feedback) error {
+	if fb.Table == "" || fb.Column == "" {
+		return fmt.Errorf("feedback needs the table and column of the anomaly")
+	}
+	if len(fb.Detections) == 0 && fb.Pattern == "" {
+		return fmt.Errorf("feedback for %s.%s has neither detections nor a pattern", fb.Table, fb.Column)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if fb.Pattern != "" {
+		if err := f.addPattern(FeedbackPattern{Table: fb.Table, Column: fb.Column, Pattern: fb.Pattern}); err != nil {
+			return err
+		}
+	}
+	for _, d := range fb.Detections {
+		key := thresholdKey{fb.Table, fb.Column, d.Detector, d.Signal}
+		f.thresholds[key] = math.Max(f.thresholds[key], math.Abs(d.Score)*(1+FeedbackMargin))
+	}
+	return nil
+}
+
+// addPattern compiles and records a whitelist pattern
+func (f *FeedbackFilter) addPattern(p FeedbackPattern) error {
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return fmt.Errorf("pattern for %s.%s: %w", p.Table, p.Column, err)
+	}
+	key := [2]string{p.Table, p.Column}
+	f.patterns[key] = append(f.patterns[key], re)
+	f.sources = append(f.sources, p)
+	return nil
+}
+
+// Apply removes the detections of input suppressed by feedback, clearing its
+// anomaly flag if nothing else flagged it
+func (f *FeedbackFilter) Apply(input *AnomalyInput) {
+	if len(input.Detections) == 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	kept := input.Detections[:0]
+	if !f.whitelisted(input) {
+		for _, d := range input.Detections {
+			key := thresholdKey{input.Table, input.Column, d.Detector, d.Signal}
+			if math.Abs(d.Score) > f.thresholds[key] {
+				kept = append(kept, d)
+			}
+		}
+	}
+	if len(kept) == len(input.Detections) {
+		return
+	}
+
+	input.Detections = kept
+	input.Contributions = SignalContributions(kept)
+	if len(kept) == 0 {
+		input.Detections = nil
+		input.Anomalous = len(input.MatchedRules) > 0
+	}
+}
+
+// whitelisted reports whether the after value of input matches a pattern
+func (f *FeedbackFilter) whitelisted(input *AnomalyInput) bool {
+	patterns := f.patterns[[2]string{input.Table, input.Column}]
+	if len(patterns) == 0 || input.AfterValue == nil {
+		return false
+	}
+	value := fmt.Sprint(input.AfterValue)
+	for _, re := range patterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Save writes the feedback to path, replacing it atomically
+func (f *FeedbackFilter) Save(path string) error {
+	f.mu.Lock()
+	file := feedbackFile{Thresholds: []FeedbackThreshold{}, Patterns: append([]FeedbackPattern{}, f.sources...)}
+	for key, minScore := range f.thresholds {
+		file.Thresholds = append(file.Thresholds, FeedbackThreshold{
+			Table:    key.table,
+			Column:   key.column,
+			Detector: key.detector,
+			Signal:   key.signal,
+			MinScore: minScore,
+		})
+	}
+	f.mu.Unlock()
+
+	sort.Slice(file.Thresholds, func(i, j int) bool {
+		a, b := file.Thresholds[i], file.Thresholds[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		if a.Detector != b.Detector {
+			return a.Detector < b.Detector
+		}
+		return a.Signal < b.Signal
+	})
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename so a crash never leaves a truncated file
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,62 @@
+package logprocessor
+
+import (
+	"crypto/hmac"
+	crypto_rand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// HashSaltEnvVar names the environment variable used to supply a stable salt.
+// Without it a random salt is generated per process, which keeps hashes private
+// but means keys cannot be compared across restarts.
+const HashSaltEnvVar = "LSP_HASH_SALT"
+
+// ValueHasher derives keys for before/after values that are kept in state
+// (frequency counts, cardinality sets, dedup caches). When enabled, values are
+// replaced by a salted HMAC-SHA256 so raw data never leaves the process.
+type ValueHasher struct {
+	Enabled bool
+	salt    []byte
+}
+
+// NewValueHasher creates a hasher using the given salt. An empty salt falls back
+// to the HashSaltEnvVar environment variable and then to a random salt.
+func NewValueHasher(enabled bool, salt string) (*ValueHasher, error) {
+	if salt == "" {
+		salt = os.Getenv(HashSaltEnvVar)
+	}
+
+	h := &ValueHasher{Enabled: enabled}
+	if salt != "" {
+		h.salt = []byte(salt)
+		return h, nil
+	}
+
+	h.salt = make([]byte, 32)
+	if _, err := io.ReadFull(crypto_rand.Reader, h.salt); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Key returns the state key for a value. When hashing is disabled the value's
+// string form is returned unchanged.
+func (h *ValueHasher) Key(value interface{}) string {
+	s := fmt.Sprintf("%v", value)
+	if h == nil || !h.Enabled {
+		return s
+	}
+
+	mac := hmac.New(sha256.New, h.salt)
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValueKeys returns the state keys for the before and after values of a field.
+func (h *ValueHasher) ValueKeys(logData LogData, fieldName string) (before, after string) {
+	return h.Key(logData.Before[fieldName]), h.Key(logData.After[fieldName])
+}
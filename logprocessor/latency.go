@@ -0,0 +1,54 @@
+package logprocessor
+
+import (
+	"log-signal-processor/metrics"
+	"time"
+)
+
+// Pipeline stages timed by ObserveStage
+const (
+	StageQueue   = "queue"   // Waiting in the ingestion queue
+	StageParse   = "parse"   // Parsing a raw log
+	StageSignals = "signals" // Generating the signal vectors of a log
+	StageDetect  = "detect"  // Rules, detectors, feedback, suppression and severity
+	StageAlert   = "alert"   // Handing flagged inputs to the alerter
+	StageOutput  = "output"  // Writing to the output sinks
+)
+
+// Latency metric names
+const (
+	stageDurationMetric = "pipeline_stage_seconds/"
+	detectionLagMetric  = "detection_lag_seconds"
+	anomalyLagMetric    = "anomaly_detection_lag_seconds"
+	processingLagMetric = "processing_latency_seconds"
+	clockSkewMetric     = "detection_lag_clock_skew"
+)
+
+// pipelineStages lists the stages in pipeline order, for the summary
+var pipelineStages = []string{StageQueue, StageParse, StageSignals, StageDetect, StageAlert, StageOutput}
+
+// ObserveStage records the time one event spent in a pipeline stage
+func ObserveStage(stage string, d time.Duration) {
+	metrics.Default.Histogram(stageDurationMetric+stage, metrics.DurationBuckets).Observe(d.Seconds())
+}
+
+// ObserveEmitted records the end-to-end latency of an input written at
+// emitted: the detection lag from its log timestamp, separately for flagged
+// inputs, and the processing latency from when the log was received, if known.
+// Logs timestamped after emitted, e.g. by a simulated clock running ahead of
+// the wall clock, are counted as clock skew instead of as a negative lag.
+func ObserveEmitted(input AnomalyInput, received, emitted time.Time) {
+	if !input.Timestamp.IsZero() {
+		if lag := emitted.Sub(input.Timestamp).Seconds(); lag < 0 {
+			metrics.Default.Counter(clockSkewMetric).Inc()
+		} else {
+			metrics.Default.Histogram(detectionLagMetric, metrics.LatencyBuckets).Observe(lag)
+			if input.Anomalous {
+				metrics.Default.Histogram(anomalyLagMetric, metrics.LatencyBuckets).Observe(lag)
+			}
+		}
+	}
+	if !received.IsZero() {
+		metrics.Default.Histogram(processingLagMetric, metrics.LatencyBuckets).Observe(emitted.Sub(received).Seconds())
+	}
+}
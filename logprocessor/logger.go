@@ -2,30 +2,45 @@ package logprocessor
 
 import (
 	"fmt"
+	"log-signal-processor/metrics"
 	"log/slog"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/lmittmann/tint"
 )
 
+// logLevel is the level of the logger, raised by SetQuiet
+var logLevel = new(slog.LevelVar)
+
 // Initialize a colored structured logger
 var logger = slog.New(tint.NewHandler(os.Stdout, &tint.Options{
-	Level:      slog.LevelInfo,
+	Level:      logLevel,
 	TimeFormat: "15:04:05",
 	NoColor:    false,
 }))
 
-// Track generator names for better output labeling
-var signalGeneratorNames []string
+// SetQuiet silences the logger, keeping stdout free for machine-readable
+// output
+func SetQuiet() {
+	logLevel.Set(slog.LevelError + 1)
+}
 
-// RegisterSignalGenerator adds a generator name to the registry for pretty printing
-func RegisterSignalGenerator(name string) {
-	signalGeneratorNames = append(signalGeneratorNames, name)
+// PauseLogging silences the logger until the returned function restores its
+// level, e.g. while a progress screen owns the terminal
+func PauseLogging() (resume func()) {
+	level := logLevel.Level()
+	logLevel.Set(slog.LevelError + 1)
+	return func() {
+		logLevel.Set(level)
+	}
 }
 
-// LogAnomalyInput logs the anomaly input in a compact format using slog
-func LogAnomalyInput(input AnomalyInput) {
+// LogAnomalyInput logs the anomaly input in a compact format using slog.
+// signalNames label the signal vector positions.
+func LogAnomalyInput(input AnomalyInput, signalNames []string) {
 	// Format the basic identifier as table:column:timestamp
 	identifier := fmt.Sprintf("%s:%s:%s",
 		input.Table,
@@ -36,10 +51,10 @@ func LogAnomalyInput(input AnomalyInput) {
 	vectorStrs := make([]string, len(input.SignalVector))
 	for i, val := range input.SignalVector {
 		name := "unknown"
-		if i < len(signalGeneratorNames) {
-			name = signalGeneratorNames[i]
+		if i < len(signalNames) {
+			name = signalNames[i]
 		}
-		vectorStrs[i] = fmt.Sprintf("%s=%.4f", name, val)
+		vectorStrs[i] = fmt.Sprintf("%s(%s)=%.4f", name, input.Column, val)
 	}
 
 	// Format the before and after values
@@ -55,10 +70,159 @@ func LogAnomalyInput(input AnomalyInput) {
 		afterStr = afterStr[:maxValueLength] + "..."
 	}
 
-	// Log the operation, values, and vectors
+	// Log the operation, values, and vectors; inputs flagged by rules or
+	// detectors are warnings
+	if input.Anomalous {
+		detections := make([]string, len(input.Detections))
+		for i, d := range input.Detections {
+			detections[i] = fmt.Sprintf("%s:%s=%.2f", d.Detector, d.Signal, d.Score)
+			if d.Uncertainty > 0 {
+				detections[i] += fmt.Sprintf("±%.2f", d.Uncertainty)
+			}
+		}
+		contributions := make([]string, len(input.Contributions))
+		for i, c := range input.Contributions {
+			contributions[i] = fmt.Sprintf("%s=%.0f%%", c.Signal, c.Percent)
+		}
+		logger.Warn(input.Operation,
+			"id", identifier,
+			"severity", input.Severity,
+			"before", beforeStr,
+			"after", afterStr,
+			"signals", strings.Join(vectorStrs, ", "),
+			"rules", strings.Join(input.MatchedRules, ","),
+			"detections", strings.Join(detections, ","),
+			"contributions", strings.Join(contributions, ","))
+		return
+	}
 	logger.Info(input.Operation,
 		"id", identifier,
 		"before", beforeStr,
 		"after", afterStr,
 		"signals", strings.Join(vectorStrs, ", "))
 }
+
+// LogWindowAggregate logs a closed window, as a warning when it is anomalous
+func LogWindowAggregate(window WindowAggregate) {
+	identifier := fmt.Sprintf("%s:%s/%s",
+		window.Table,
+		window.WindowStart.Format("2006-01-02T15:04:05Z07:00"),
+		window.WindowEnd.Sub(window.WindowStart))
+	args := []interface{}{
+		"id", identifier,
+		"events", window.Events,
+		"flagged", window.Flagged,
+		"max_score", fmt.Sprintf("%.2f", window.MaxScore),
+		"columns", strings.Join(window.Columns, ","),
+	}
+	if window.Anomalous {
+		logger.Warn("window", args...)
+		return
+	}
+	logger.Info("window", args...)
+}
+
+// LogTableAggregate logs a closed table aggregate, as a warning when anomalous
+func LogTableAggregate(table TableAggregate) {
+	identifier := fmt.Sprintf("%s:%s/%s",
+		table.Table,
+		table.WindowStart.Format("2006-01-02T15:04:05Z07:00"),
+		table.WindowEnd.Sub(table.WindowStart))
+	args := []interface{}{
+		"id", identifier,
+		"rows", table.Rows,
+		"encrypted_rows", table.EncryptedRows,
+		"encrypted_percent", fmt.Sprintf("%.1f", table.EncryptedPercent),
+		"columns", strings.Join(table.Columns, ","),
+	}
+	if table.Anomalous {
+		logger.Warn("table", args...)
+		return
+	}
+	logger.Info("table", args...)
+}
+
+// LogDriftReport logs a drift report, as a warning when the baseline drifted
+func LogDriftReport(report DriftReport) {
+	args := []interface{}{
+		"id", report.Table + "." + report.Column,
+		"inputs", report.Inputs,
+		"psi", fmt.Sprintf("%.3f", report.PSI),
+	}
+	for _, signal := range report.Signals {
+		if signal.PSI == report.PSI {
+			args = append(args, "signal", signal.Signal, "ks", fmt.Sprintf("%.3f", signal.KS))
+			break
+		}
+	}
+	if report.Drifted {
+		logger.Warn("baseline drift", args...)
+		return
+	}
+	logger.Info("baseline drift", args...)
+}
+
+// LogSignalLayout logs the position of each signal in the vectors of a run
+func LogSignalLayout(signalNames []string) {
+	for i, name := range signalNames {
+		logger.Info("signal vector layout", "position", i, "signal", name)
+	}
+}
+
+// LogTimingSummary logs the total and mean execution time of each signal
+// generator, the time events spent in each pipeline stage and the end-to-end
+// latency of emitted inputs
+func LogTimingSummary() {
+	snapshot := metrics.Default.Snapshot()
+
+	names := make([]string, 0)
+	for name := range snapshot.Histograms {
+		if strings.HasPrefix(name, signalDurationMetric) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		h := snapshot.Histograms[name]
+		logger.Info("signal timing",
+			"generator", strings.TrimPrefix(name, signalDurationMetric),
+			"calls", h.Count,
+			"total", time.Duration(h.Sum*float64(time.Second)),
+			"mean", time.Duration(h.Mean()*float64(time.Second)))
+	}
+
+	for _, stage := range pipelineStages {
+		h, ok := snapshot.Histograms[stageDurationMetric+stage]
+		if !ok || h.Count == 0 {
+			continue
+		}
+		logger.Info("stage timing",
+			"stage", stage,
+			"events", h.Count,
+			"mean", seconds(h.Mean()),
+			"p99", seconds(h.Quantile(0.99)))
+	}
+	for _, name := range []string{processingLagMetric, detectionLagMetric, anomalyLagMetric} {
+		h, ok := snapshot.Histograms[name]
+		if !ok || h.Count == 0 {
+			continue
+		}
+		logger.Info("latency",
+			"metric", name,
+			"events", h.Count,
+			"mean", seconds(h.Mean()),
+			"p50", seconds(h.Quantile(0.5)),
+			"p99", seconds(h.Quantile(0.99)))
+	}
+	if skewed := snapshot.Counters[clockSkewMetric]; skewed > 0 {
+		logger.Warn("clock skew",
+			"metric", clockSkewMetric,
+			"events", skewed)
+	}
+}
+
+// seconds converts a metric value in seconds to a duration
+func seconds(v float64) time.Duration {
+	return time.Duration(v * float64(time.Second))
+}
@@ -1,6 +1,9 @@
 package logprocessor
 
 import (
+	"errors"
+	"log-signal-processor/metrics"
+	"math"
 	"time"
 )
 
@@ -8,20 +11,45 @@ type LogData struct {
 	Operation     string
 	Table         string
 	RowIdentifier string
+	Session       string // Database session or transaction that made the change, when the log records it
+	Transaction   string // Transaction the change belongs to (XID), when the log records it
 	Columns       []string
 	Timestamp     time.Time
 	Before        map[string]interface{}
 	After         map[string]interface{}
 }
 
+// Validate checks that a parsed log names its operation and table, which
+// malformed logs can lack even when they parse
+func (d LogData) Validate() error {
+	if d.Operation == "" {
+		return errors.New("log has no operation")
+	}
+	if d.Table == "" {
+		return errors.New("log has no table")
+	}
+	return nil
+}
+
 type SignalGenerator interface {
 	GenerateSignal(logData LogData) float64
 }
 
 type SignalProcessor struct {
 	generators []SignalGenerator
+	names      []string
+	timings    []*metrics.Histogram
+	expensive  []bool
+
+	// gateThreshold enables two-stage scoring when positive: expensive generators
+	// only run once a cheap signal's magnitude reaches the threshold.
+	gateThreshold float64
+	gatedSkips    *metrics.Counter
 }
 
+// signalDurationMetric prefixes the per-generator execution time histograms
+const signalDurationMetric = "signal_duration_seconds/"
+
 func (sp *SignalProcessor) AddGenerator(gen SignalGenerator) {
 	sp.generators = append(sp.generators, gen)
 
@@ -32,32 +60,101 @@ func (sp *SignalProcessor) AddGenerator(gen SignalGenerator) {
 		name = "Levenshtein(" + g.FieldName + ")"
 	case *EntropyChangeGenerator:
 		name = "Entropy(" + g.FieldName + ")"
+	case *RowChangesGenerator:
+		name = "RowChanges(" + g.FieldName + ")"
+	case *RevertGenerator:
+		name = "Revert(" + g.FieldName + ")"
 	default:
 		name = "Unknown Generator"
 	}
 
-	RegisterSignalGenerator(name)
+	sp.names = append(sp.names, name)
+	sp.timings = append(sp.timings, metrics.Default.Histogram(signalDurationMetric+name, metrics.DurationBuckets))
+	sp.expensive = append(sp.expensive, false)
+}
+
+// AddExpensiveGenerator adds a generator that is skipped by the signal gate
+// while every cheap signal stays below the gate threshold.
+func (sp *SignalProcessor) AddExpensiveGenerator(gen SignalGenerator) {
+	sp.AddGenerator(gen)
+	sp.expensive[len(sp.expensive)-1] = true
+}
+
+// SetGateThreshold enables adaptive gating of expensive generators. A threshold
+// of zero disables gating so every generator runs on every event.
+func (sp *SignalProcessor) SetGateThreshold(threshold float64) {
+	sp.gateThreshold = threshold
+	sp.gatedSkips = metrics.Default.Counter("signal_gated_skips")
 }
 
 func (sp *SignalProcessor) GenerateSignalVector(logData LogData) []float64 {
 	vector := make([]float64, len(sp.generators))
-	for i, gen := range sp.generators {
-		vector[i] = gen.GenerateSignal(logData)
+
+	// First stage: cheap generators always run
+	open := sp.gateThreshold <= 0
+	hasCheap := false
+	for i := range sp.generators {
+		if sp.expensive[i] {
+			continue
+		}
+		hasCheap = true
+		vector[i] = sp.runGenerator(i, logData)
+		if math.Abs(vector[i]) >= sp.gateThreshold {
+			open = true
+		}
+	}
+
+	// Second stage: expensive generators run only when the gate is open.
+	// Skipped positions keep a zero value so the vector layout is unchanged.
+	for i := range sp.generators {
+		if !sp.expensive[i] {
+			continue
+		}
+		if open || !hasCheap {
+			vector[i] = sp.runGenerator(i, logData)
+		} else {
+			sp.gatedSkips.Inc()
+		}
 	}
 	return vector
 }
 
+// runGenerator computes one signal, recording its execution time
+func (sp *SignalProcessor) runGenerator(i int, logData LogData) float64 {
+	start := time.Now()
+	value := sp.generators[i].GenerateSignal(logData)
+	sp.timings[i].Observe(time.Since(start).Seconds())
+	return value
+}
+
+// Names returns the name of each generator, in signal vector order
+func (sp *SignalProcessor) Names() []string {
+	return sp.names
+}
+
 // GetGenerators returns the list of signal generators
 func (sp *SignalProcessor) GetGenerators() []SignalGenerator {
 	return sp.generators
 }
 
 type AnomalyInput struct {
-	Operation    string
-	Table        string
-	Column       string // Changed from Columns []string to a single Column
-	Timestamp    time.Time
-	BeforeValue  interface{} // Value of the column before change
-	AfterValue   interface{} // Value of the column after change
-	SignalVector []float64
+	SchemaVersion int         `json:"schema_version,omitempty"` // Output layout version; unset in the version 1 layout
+	Operation     string      `json:"operation"`
+	Table         string      `json:"table"`
+	RowIdentifier string      `json:"row_identifier,omitempty"` // Primary key or row ID of the changed row, when known
+	Session       string      `json:"session,omitempty"`        // Session or transaction that made the change, when known
+	Column        string      `json:"column"`                   // Changed from Columns []string to a single Column
+	Timestamp     time.Time   `json:"timestamp"`
+	BeforeValue   interface{} `json:"before_value"` // Value of the column before change
+	AfterValue    interface{} `json:"after_value"`  // Value of the column after change
+	SignalVector  []float64   `json:"signal_vector"`
+	Anomalous     bool        `json:"anomaly,omitempty"`       // Set when a rule matched or a detector fired
+	Severity      Severity    `json:"severity,omitempty"`      // Severity of a flagged input
+	MatchedRules  []string    `json:"matched_rules,omitempty"` // Names of the rules that matched
+	Detections    []Detection `json:"detections,omitempty"`    // Signals flagged by detectors
+	// Share of the detections attributed to each signal, largest first
+	Contributions []Contribution `json:"contributions,omitempty"`
+	// Suppression rule that silenced the input, or "duplicate" for a repeat
+	// of an anomaly already reported
+	Suppressed string `json:"suppressed,omitempty"`
 }
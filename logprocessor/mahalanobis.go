@@ -0,0 +1,311 @@
+package logprocessor
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+)
+
+// Defaults for the Mahalanobis detector
+const (
+	DefaultMahalanobisBaseline  = 200
+	DefaultMahalanobisThreshold = 4
+)
+
+// MahalanobisDetector learns the mean and covariance of the whole signal
+// vector of each table and column from its first Baseline inputs, then scores
+// later vectors by their Mahalanobis distance from that baseline. Correlated
+// signals (e.g. entropy and compressibility rising together) count once
+// rather than being flagged independently. Signals that never varied during
+// the baseline are left out of the distance.
+type MahalanobisDetector struct {
+	baseline    int
+	threshold   float64
+	signalNames []string
+	series      map[seriesKey]*covarianceModel
+	frozen      bool
+}
+
+// covarianceModel accumulates a baseline and, once it is complete, holds the
+// inverse covariance of the signals that varied
+type covarianceModel struct {
+	count    int
+	mean     []float64
+	comoment [][]float64 // Sum of products of deviations from the mean
+
+	dims    []int       // Vector positions used in the distance
+	inverse [][]float64 // Inverse covariance over dims, nil while learning
+}
+
+// NewMahalanobisDetector creates a detector for vectors laid out as
+// signalNames. Non-positive baseline and threshold select the defaults.
+func NewMahalanobisDetector(baseline int, threshold float64, signalNames []string) *MahalanobisDetector {
+	if baseline <= 0 {
+		baseline = DefaultMahalanobisBaseline
+	}
+	if threshold <= 0 {
+		threshold = DefaultMahalanobisThreshold
+	}
+	return &MahalanobisDetector{
+		baseline:    baseline,
+		threshold:   threshold,
+		signalNames: signalNames,
+		series:      make(map[seriesKey]*covarianceModel),
+	}
+}
+
+// Name identifies the detector in detections
+func (d *MahalanobisDetector) Name() string {
+	return "mahalanobis"
+}
+
+// Detect adds input to its baseline while it is being learned, and scores it
+// afterwards. A detection names the signal contributing most to the distance,
+// carries the distance as its score and splits it between the signals by
+// their share of the squared distance.
+func (d *MahalanobisDetector) Detect(input AnomalyInput) []Detection {
+	vector := input.SignalVector
+	for _, v := range vector {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+	}
+
+	key := seriesKey{table: input.Table, column: input.Column}
+	model, ok := d.series[key]
+	if !ok {
+		if d.frozen {
+			return nil
+		}
+		model = newCovarianceModel(len(vector))
+		d.series[key] = model
+	}
+	if len(vector) != len(model.mean) {
+		return nil
+	}
+
+	if model.inverse == nil {
+		if !d.frozen {
+			model.add(vector)
+			if model.count >= d.baseline {
+				model.invert()
+			}
+		}
+		return nil
+	}
+
+	// distance^2 = z' S^-1 z, with each dimension's share z_i (S^-1 z)_i
+	z := make([]float64, len(model.dims))
+	for i, dim := range model.dims {
+		z[i] = vector[dim] - model.mean[dim]
+	}
+	shares := make([]float64, len(z))
+	var squared, positive float64
+	top := -1
+	for i := range z {
+		var row float64
+		for j := range z {
+			row += model.inverse[i][j] * z[j]
+		}
+		shares[i] = z[i] * row
+		squared += shares[i]
+		if shares[i] > 0 {
+			positive += shares[i]
+		}
+		if top < 0 || shares[i] > shares[top] {
+			top = i
+		}
+	}
+
+	distance := math.Sqrt(math.Max(squared, 0))
+	if top < 0 || distance <= d.threshold {
+		return nil
+	}
+
+	// Negative shares (a signal offsetting a correlated one) count as none
+	var contributions []Contribution
+	for i, share := range shares {
+		if share > 0 {
+			contributions = append(contributions, Contribution{
+				Signal:  signalLabel(d.signalNames, model.dims[i]),
+				Percent: 100 * share / positive,
+			})
+		}
+	}
+	dim := model.dims[top]
+	return []Detection{{
+		Detector:      d.Name(),
+		Signal:        signalLabel(d.signalNames, dim),
+		Value:         vector[dim],
+		Score:         distance,
+		Samples:       model.count,
+		Uncertainty:   scoreUncertainty(distance, model.count),
+		Contributions: contributions,
+	}}
+}
+
+// Freeze stops the detector learning baselines; tables and columns whose
+// baseline is incomplete are not scored
+func (d *MahalanobisDetector) Freeze() {
+	d.frozen = true
+}
+
+func newCovarianceModel(n int) *covarianceModel {
+	comoment := make([][]float64, n)
+	for i := range comoment {
+		comoment[i] = make([]float64, n)
+	}
+	return &covarianceModel{mean: make([]float64, n), comoment: comoment}
+}
+
+// add updates the mean and co-moments with Welford's online algorithm
+func (m *covarianceModel) add(vector []float64) {
+	m.count++
+	before := make([]float64, len(vector))
+	for i, v := range vector {
+		before[i] = v - m.mean[i]
+		m.mean[i] += before[i] / float64(m.count)
+	}
+	for i := range vector {
+		after := vector[i] - m.mean[i]
+		for j := range vector {
+			m.comoment[j][i] += before[j] * after
+		}
+	}
+}
+
+// invert finishes the baseline, inverting the covariance of the signals
+// that varied. A tiny ridge keeps perfectly correlated signals invertible.
+func (m *covarianceModel) invert() {
+	n := float64(m.count)
+	m.dims = m.dims[:0]
+	for i := range m.mean {
+		if m.comoment[i][i]/n > 1e-12 {
+			m.dims = append(m.dims, i)
+		}
+	}
+
+	covariance := make([][]float64, len(m.dims))
+	for i, a := range m.dims {
+		covariance[i] = make([]float64, len(m.dims))
+		for j, b := range m.dims {
+			covariance[i][j] = m.comoment[a][b] / n
+		}
+		covariance[i][i] += 1e-9 + 1e-6*covariance[i][i]
+	}
+	m.inverse = invertMatrix(covariance)
+	if m.inverse == nil {
+		// Singular even with the ridge; score nothing rather than everything
+		m.dims, m.inverse = nil, [][]float64{}
+	}
+}
+
+// invertMatrix inverts a square matrix by Gauss-Jordan elimination with
+// partial pivoting, returning nil if it is singular
+func invertMatrix(a [][]float64) [][]float64 {
+	n := len(a)
+	aug := make([][]float64, n)
+	for i := range a {
+		aug[i] = make([]float64, 2*n)
+		copy(aug[i], a[i])
+		aug[i][n+i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(aug[row][col]) > math.Abs(aug[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(aug[pivot][col]) < 1e-15 {
+			return nil
+		}
+		aug[col], aug[pivot] = aug[pivot], aug[col]
+
+		scale := aug[col][col]
+		for j := range aug[col] {
+			aug[col][j] /= scale
+		}
+		for row := 0; row < n; row++ {
+			if row == col || aug[row][col] == 0 {
+				continue
+			}
+			factor := aug[row][col]
+			for j := range aug[row] {
+				aug[row][j] -= factor * aug[col][j]
+			}
+		}
+	}
+
+	inverse := make([][]float64, n)
+	for i := range aug {
+		inverse[i] = aug[i][n:]
+	}
+	return inverse
+}
+
+// mahalanobisBaseline is the persisted mean and co-moments of a table and
+// column, with the layout of the vectors they were learned from
+type mahalanobisBaseline struct {
+	Signals  []string    `json:"signals"`
+	Count    int         `json:"count"`
+	Mean     []float64   `json:"mean"`
+	Comoment [][]float64 `json:"comoment"`
+}
+
+// Baselines returns the model of every table and column, keyed with an empty
+// signal since each covers the whole vector
+func (d *MahalanobisDetector) Baselines() (map[BaselineKey][]byte, error) {
+	baselines := make(map[BaselineKey][]byte, len(d.series))
+	for key, model := range d.series {
+		data, err := json.Marshal(mahalanobisBaseline{
+			Signals:  d.signalNames,
+			Count:    model.count,
+			Mean:     model.mean,
+			Comoment: model.comoment,
+		})
+		if err != nil {
+			return nil, err
+		}
+		baselines[BaselineKey{Table: key.table, Column: key.column}] = data
+	}
+	return baselines, nil
+}
+
+// RestoreBaselines replaces models with persisted ones learned from the same
+// vector layout. Complete baselines are scored against immediately.
+func (d *MahalanobisDetector) RestoreBaselines(baselines map[BaselineKey][]byte) error {
+	for key, data := range baselines {
+		if key.Signal != "" {
+			continue
+		}
+		var baseline mahalanobisBaseline
+		if err := decodeBaseline(key, data, &baseline); err != nil {
+			return err
+		}
+		if !slices.Equal(baseline.Signals, d.signalNames) || !baseline.square() {
+			continue
+		}
+		model := &covarianceModel{count: baseline.Count, mean: baseline.Mean, comoment: baseline.Comoment}
+		if model.count >= d.baseline {
+			model.invert()
+		}
+		d.series[seriesKey{table: key.Table, column: key.Column}] = model
+	}
+	return nil
+}
+
+// square reports whether the mean and co-moments match the signal count
+func (b mahalanobisBaseline) square() bool {
+	n := len(b.Signals)
+	if len(b.Mean) != n || len(b.Comoment) != n {
+		return false
+	}
+	for _, row := range b.Comoment {
+		if len(row) != n {
+			return false
+		}
+	}
+	return true
+}
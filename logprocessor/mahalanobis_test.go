@@ -0,0 +1,58 @@
+package logprocessor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMahalanobisDetector(t *testing.T) {
+	// Mean (1, 1) with unit variances and no covariance
+	d := NewMahalanobisDetector(4, 4, testSignals)
+	feed(t, d, vectorInput(0, 0), vectorInput(2, 0), vectorInput(0, 2), vectorInput(2, 2))
+	feed(t, d, vectorInput(2, 2))
+
+	detections := d.Detect(vectorInput(1, 7))
+	if len(detections) != 1 {
+		t.Fatalf("got %d detections, want 1: %+v", len(detections), detections)
+	}
+	got := detections[0]
+	if got.Detector != "mahalanobis" || got.Signal != "Levenshtein" || got.Value != 7 || got.Samples != 4 {
+		t.Errorf("detection %+v", got)
+	}
+	if !near(got.Score, 6, 1e-4) {
+		t.Errorf("score %v, want 6", got.Score)
+	}
+	if len(got.Contributions) != 1 || got.Contributions[0].Signal != "Levenshtein" || !near(got.Contributions[0].Percent, 100, 1e-9) {
+		t.Errorf("contributions %+v", got.Contributions)
+	}
+}
+
+func TestMahalanobisDetectorCorrelatedSignals(t *testing.T) {
+	// Signals rising together: variances 1.25 and covariance 1
+	d := NewMahalanobisDetector(8, 4, testSignals)
+	feed(t, d,
+		vectorInput(0, 0), vectorInput(1, 1), vectorInput(2, 2), vectorInput(3, 3),
+		vectorInput(0, 1), vectorInput(1, 0), vectorInput(2, 3), vectorInput(3, 2))
+
+	// Both signals at their highest, as the baseline expects: distance sqrt(2)
+	feed(t, d, vectorInput(3, 3))
+
+	// Each signal is within its baseline range, but they moved apart:
+	// distance sqrt(18), shared equally
+	detections := d.Detect(vectorInput(0, 3))
+	if len(detections) != 1 {
+		t.Fatalf("got %d detections, want 1: %+v", len(detections), detections)
+	}
+	got := detections[0]
+	if !near(got.Score, math.Sqrt(18), 1e-3) {
+		t.Errorf("score %v, want %v", got.Score, math.Sqrt(18))
+	}
+	if len(got.Contributions) != 2 {
+		t.Fatalf("contributions %+v, want both signals", got.Contributions)
+	}
+	for _, c := range got.Contributions {
+		if !near(c.Percent, 50, 1e-3) {
+			t.Errorf("contribution %+v, want 50%%", c)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package logprocessor
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Defaults for the quantile detector
+const (
+	DefaultQuantileIQR     = 3
+	DefaultQuantileMinimum = 50
+)
+
+// QuantileDetector estimates the distribution of each (table, column, signal)
+// series with a t-digest and flags values beyond quantile fences. Unlike
+// mean and standard deviation, quantiles are not dragged around by the heavy
+// tails common in signal values.
+//
+// With IQRMultiple set, values further than that many interquartile ranges
+// below the first or above the third quartile are flagged. With Percentile
+// set (e.g. 99.5), values above that percentile or below its mirror (0.5)
+// are flagged. The score is the distance from the median in interquartile
+// ranges, or the plain distance while the quartiles coincide.
+type QuantileDetector struct {
+	iqrMultiple float64
+	percentile  float64
+	minimum     int
+	signalNames []string
+	series      map[seriesKey]*tdigest
+	frozen      bool
+}
+
+// NewQuantileDetector creates a detector for vectors laid out as signalNames.
+// A series is scored once it has seen minimum values. percentile must be in
+// (50, 100) to be used; with neither fence set the IQR fence defaults to
+// DefaultQuantileIQR.
+func NewQuantileDetector(iqrMultiple, percentile float64, minimum int, signalNames []string) *QuantileDetector {
+	if percentile <= 50 || percentile >= 100 {
+		percentile = 0
+	}
+	if iqrMultiple <= 0 && percentile == 0 {
+		iqrMultiple = DefaultQuantileIQR
+	}
+	if minimum <= 0 {
+		minimum = DefaultQuantileMinimum
+	}
+	return &QuantileDetector{
+		iqrMultiple: iqrMultiple,
+		percentile:  percentile,
+		minimum:     minimum,
+		signalNames: signalNames,
+		series:      make(map[seriesKey]*tdigest),
+	}
+}
+
+// Name identifies the detector in detections
+func (d *QuantileDetector) Name() string {
+	return "quantile"
+}
+
+// Detect checks each signal of input against its series' fences, then adds it
+func (d *QuantileDetector) Detect(input AnomalyInput) []Detection {
+	var detections []Detection
+	for i, value := range input.SignalVector {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		key := seriesKey{table: input.Table, column: input.Column, signal: i}
+		digest, ok := d.series[key]
+		if !ok {
+			if d.frozen {
+				continue
+			}
+			digest = &tdigest{}
+			d.series[key] = digest
+		}
+
+		if digest.count >= float64(d.minimum) && d.outside(digest, value) {
+			q1, median, q3 := digest.quantile(0.25), digest.quantile(0.5), digest.quantile(0.75)
+			score := value - median
+			if iqr := q3 - q1; iqr > 0 {
+				score /= iqr
+			}
+			detections = append(detections, Detection{
+				Detector:    d.Name(),
+				Signal:      signalLabel(d.signalNames, i),
+				Value:       value,
+				Score:       score,
+				Samples:     int(digest.count),
+				Uncertainty: scoreUncertainty(score, int(digest.count)),
+			})
+		}
+		if !d.frozen {
+			digest.add(value)
+		}
+	}
+	return detections
+}
+
+// Freeze stops the detector adding values to its digests
+func (d *QuantileDetector) Freeze() {
+	d.frozen = true
+}
+
+// outside reports whether value lies beyond any configured fence. An IQR
+// fence is not applied while the interquartile range is zero.
+func (d *QuantileDetector) outside(digest *tdigest, value float64) bool {
+	if d.iqrMultiple > 0 {
+		q1, q3 := digest.quantile(0.25), digest.quantile(0.75)
+		if iqr := q3 - q1; iqr > 0 && (value < q1-d.iqrMultiple*iqr || value > q3+d.iqrMultiple*iqr) {
+			return true
+		}
+	}
+	if d.percentile > 0 {
+		upper := digest.quantile(d.percentile / 100)
+		lower := digest.quantile(1 - d.percentile/100)
+		if value > upper || value < lower {
+			return true
+		}
+	}
+	return false
+}
+
+// quantileBaseline is the persisted digest of a series
+type quantileBaseline struct {
+	Centroids []centroid `json:"centroids"`
+	Count     float64    `json:"count"`
+	Min       float64    `json:"min"`
+	Max       float64    `json:"max"`
+}
+
+// Baselines returns the digest of every series
+func (d *QuantileDetector) Baselines() (map[BaselineKey][]byte, error) {
+	baselines := make(map[BaselineKey][]byte, len(d.series))
+	for key, digest := range d.series {
+		digest.compress()
+		data, err := json.Marshal(quantileBaseline{Centroids: digest.centroids, Count: digest.count, Min: digest.min, Max: digest.max})
+		if err != nil {
+			return nil, err
+		}
+		baselines[baselineKey(key, d.signalNames)] = data
+	}
+	return baselines, nil
+}
+
+// RestoreBaselines replaces series digests with persisted ones
+func (d *QuantileDetector) RestoreBaselines(baselines map[BaselineKey][]byte) error {
+	for key, data := range baselines {
+		series, ok := seriesKeyOf(key, d.signalNames)
+		if !ok {
+			continue
+		}
+		var baseline quantileBaseline
+		if err := decodeBaseline(key, data, &baseline); err != nil {
+			return err
+		}
+		d.series[series] = &tdigest{centroids: baseline.Centroids, count: baseline.Count, min: baseline.Min, max: baseline.Max}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package logprocessor
+
+import "testing"
+
+func TestQuantileDetector(t *testing.T) {
+	// Values 1 to 100: quartiles near 25.5 and 75.5, median near 50.5, so
+	// the fences at 3 interquartile ranges are near -124.5 and 225.5
+	d := NewQuantileDetector(3, 0, 50, testSignals)
+	for v := 1; v <= 100; v++ {
+		feed(t, d, vectorInput(float64(v), 0))
+	}
+	feed(t, d, vectorInput(200, 0), vectorInput(-100, 0))
+
+	detections := d.Detect(vectorInput(1000, 0))
+	if len(detections) != 1 {
+		t.Fatalf("got %d detections, want 1: %+v", len(detections), detections)
+	}
+	got := detections[0]
+	if got.Detector != "quantile" || got.Signal != "Entropy" || got.Value != 1000 {
+		t.Errorf("detection %+v", got)
+	}
+	// (1000 - 50.5) / 50 interquartile ranges from the median
+	if !near(got.Score, 18.99, 0.5) {
+		t.Errorf("score %v, want about 18.99", got.Score)
+	}
+
+	if detections := d.Detect(vectorInput(-1000, 0)); len(detections) != 1 || detections[0].Score >= 0 {
+		t.Errorf("detections %+v, want one with a negative score", detections)
+	}
+}
+
+func TestQuantileDetectorPercentile(t *testing.T) {
+	d := NewQuantileDetector(0, 99, 50, testSignals)
+	for v := 1; v <= 100; v++ {
+		d.Detect(vectorInput(float64(v), 0))
+	}
+	feed(t, d, vectorInput(50, 0))
+	if detections := d.Detect(vectorInput(150, 0)); len(detections) != 1 {
+		t.Errorf("value above the 99th percentile: got %d detections, want 1", len(detections))
+	}
+}
+
+func TestQuantileDetectorMinimum(t *testing.T) {
+	d := NewQuantileDetector(3, 0, 50, testSignals)
+	for v := 1; v < 50; v++ {
+		feed(t, d, vectorInput(float64(v), 0))
+	}
+	// 49 values are not enough to score
+	feed(t, d, vectorInput(1000, 0))
+}
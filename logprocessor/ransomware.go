@@ -0,0 +1,215 @@
+package logprocessor
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Defaults for the ransomware detector
+const (
+	DefaultRansomwareThreshold = 0.8
+	DefaultRansomwareWindow    = time.Minute
+	DefaultRansomwareCount     = 20
+)
+
+// Weights of the ransomware score components. A value that looks fully
+// encrypted scores 0.75 on its own; the rest comes from its table's burst
+// rate, so the default threshold of 0.8 needs a few such updates together.
+const (
+	ransomwareEntropyWeight = 0.35
+	ransomwareLengthWeight  = 0.15
+	ransomwareFormatWeight  = 0.25
+	ransomwareBurstWeight   = 0.25
+)
+
+// ransomwareSuspicious is the value score from which an update counts
+// towards its table's burst rate
+const ransomwareSuspicious = 0.4
+
+// Ransomware score components, as named in detection contributions
+const (
+	RansomwareEntropyJump     = "entropy_jump"
+	RansomwareLengthExpansion = "length_expansion"
+	RansomwareFormatLoss      = "format_loss"
+	RansomwareBurstRate       = "burst_rate"
+)
+
+// RansomwareDetector combines the signs of a value being encrypted in place
+// into one calibrated score between 0 and 1:
+//
+//   - entropy jump: the rise in Shannon entropy, in full from 2 bits (weight 0.35)
+//   - length expansion: the growth in length, in full from double (weight 0.15)
+//   - format loss: a number, date, email, UUID or text value replaced by one
+//     of another format or by encoded ciphertext (weight 0.25)
+//   - burst rate: the updates of the table scoring at least 0.4 on the above
+//     within the window, in full at Count (weight 0.25)
+//
+// Inputs scoring at least Threshold are flagged. The detection's signal is
+// the largest component, and its contributions split the score between the
+// components. The detector scores the before and after values directly, so
+// it does not depend on the selected signals; only updates are scored.
+type RansomwareDetector struct {
+	threshold float64
+	window    time.Duration
+	count     int
+
+	tables    map[string]*burstCounter
+	lastSweep time.Time
+}
+
+// NewRansomwareDetector creates a ransomware detector. Non-positive settings
+// select the defaults.
+func NewRansomwareDetector(threshold float64, window time.Duration, count int) *RansomwareDetector {
+	if threshold <= 0 {
+		threshold = DefaultRansomwareThreshold
+	}
+	if window <= 0 {
+		window = DefaultRansomwareWindow
+	}
+	if count <= 0 {
+		count = DefaultRansomwareCount
+	}
+	return &RansomwareDetector{
+		threshold: threshold,
+		window:    window,
+		count:     count,
+		tables:    make(map[string]*burstCounter),
+	}
+}
+
+// Name identifies the detector in detections
+func (d *RansomwareDetector) Name() string {
+	return "ransomware"
+}
+
+// Detect scores an update, counting it towards its table's burst rate if its
+// value looks encrypted
+func (d *RansomwareDetector) Detect(input AnomalyInput) []Detection {
+	if input.BeforeValue == nil || input.AfterValue == nil {
+		return nil
+	}
+	before, after := fmt.Sprint(input.BeforeValue), fmt.Sprint(input.AfterValue)
+	if before == "" || before == after {
+		return nil
+	}
+
+	components := map[string]float64{
+		RansomwareEntropyJump:     ransomwareEntropyWeight * clamp01((calculateEntropy(after)-calculateEntropy(before))/2),
+		RansomwareLengthExpansion: ransomwareLengthWeight * clamp01(float64(utf8.RuneCountInString(after))/float64(utf8.RuneCountInString(before))-1),
+	}
+	if lostFormat(before, after) {
+		components[RansomwareFormatLoss] = ransomwareFormatWeight
+	}
+	var valueScore float64
+	for _, v := range components {
+		valueScore += v
+	}
+
+	ts := input.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	if ts.Sub(d.lastSweep) >= d.window {
+		d.lastSweep = ts
+		sweepBursts(d.tables, ts, d.window)
+	}
+	if valueScore >= ransomwareSuspicious {
+		n := countBurst(d.tables, input.Table, input.RowIdentifier+"\x00"+ts.String(), ts, d.window)
+		components[RansomwareBurstRate] = ransomwareBurstWeight * clamp01(float64(n)/float64(d.count))
+	}
+
+	score := valueScore + components[RansomwareBurstRate]
+	if score < d.threshold {
+		return nil
+	}
+	detection := Detection{Detector: d.Name(), Value: score, Score: score}
+	var top float64
+	for _, name := range []string{RansomwareEntropyJump, RansomwareLengthExpansion, RansomwareFormatLoss, RansomwareBurstRate} {
+		if components[name] <= 0 {
+			continue
+		}
+		if components[name] > top {
+			top, detection.Signal = components[name], name
+		}
+		detection.Contributions = append(detection.Contributions, Contribution{Signal: name, Percent: 100 * components[name] / score})
+	}
+	return []Detection{detection}
+}
+
+// clamp01 limits v to [0, 1]
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+var (
+	numberFormat = regexp.MustCompile(`^[-+]?[0-9][0-9,]*(\.[0-9]+)?$`)
+	emailFormat  = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[A-Za-z]{2,}$`)
+	uuidFormat   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexFormat    = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{16,}$`)
+	base64Format = regexp.MustCompile(`^[A-Za-z0-9+/_-]{16,}={0,2}$`)
+)
+
+// dateLayouts are the date formats recognised as dates
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02", "01/02/2006", "02.01.2006"}
+
+// valueFormat classifies a value as number, date, email, uuid, text,
+// ciphertext (hex, base64 or binary) or other
+func valueFormat(s string) string {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return "other"
+	case !utf8.ValidString(s) || strings.IndexFunc(s, func(r rune) bool { return !unicode.IsPrint(r) && !unicode.IsSpace(r) }) >= 0:
+		return "ciphertext"
+	case numberFormat.MatchString(s):
+		return "number"
+	case uuidFormat.MatchString(s):
+		return "uuid"
+	case emailFormat.MatchString(s):
+		return "email"
+	}
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return "date"
+		}
+	}
+	if hexFormat.MatchString(s) || (base64Format.MatchString(s) && looksRandom(s)) {
+		return "ciphertext"
+	}
+	if strings.IndexFunc(s, unicode.IsLetter) >= 0 {
+		return "text"
+	}
+	return "other"
+}
+
+// looksRandom reports whether a token mixes upper case, lower case and digits
+// the way encoded ciphertext does, rather than reading as a word or identifier
+func looksRandom(s string) bool {
+	var upper, lower, digit bool
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return upper && lower && digit
+}
+
+// lostFormat reports whether after no longer has the recognisable format of
+// before, or has become ciphertext
+func lostFormat(before, after string) bool {
+	from, to := valueFormat(before), valueFormat(after)
+	if to == "ciphertext" {
+		return from != "ciphertext"
+	}
+	return from != "other" && from != "ciphertext" && from != to
+}
@@ -0,0 +1,62 @@
+package logprocessor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// ransomwareInput returns an update of row from before to after at second s
+func ransomwareInput(row, before, after string, s int) AnomalyInput {
+	input := vectorInput()
+	input.RowIdentifier = row
+	input.BeforeValue, input.AfterValue = before, after
+	input.Timestamp = time.Date(2024, 1, 1, 0, 0, s, 0, time.UTC)
+	return input
+}
+
+func TestRansomwareDetector(t *testing.T) {
+	d := NewRansomwareDetector(0.9, time.Minute, 4)
+
+	// Ordinary edits keep their format and entropy
+	for i := 0; i < 10; i++ {
+		feed(t, d, ransomwareInput(fmt.Sprint("edit-", i), "alice@example.com", fmt.Sprintf("alice%d@example.com", i), i))
+	}
+
+	// Text of entropy 0 replaced by 16 distinct base64 characters, twice as
+	// long: a full entropy jump, length expansion and format loss make 0.75,
+	// and the burst rate adds 0.25 for each quarter of 4 updates
+	const plaintext, ciphertext = "aaaaaaaa", "Xk9Qz2Lm7Rb4Tw1P"
+	feed(t, d, ransomwareInput("1", plaintext, ciphertext, 20), ransomwareInput("2", plaintext, ciphertext, 21))
+
+	detections := d.Detect(ransomwareInput("3", plaintext, ciphertext, 22))
+	if len(detections) != 1 {
+		t.Fatalf("got %d detections, want 1: %+v", len(detections), detections)
+	}
+	got := detections[0]
+	if got.Detector != "ransomware" || got.Signal != RansomwareEntropyJump || !near(got.Score, 0.9375, 1e-9) || got.Value != got.Score {
+		t.Errorf("detection %+v, want a score of 0.9375 led by the entropy jump", got)
+	}
+	want := map[string]float64{
+		RansomwareEntropyJump:     0.35,
+		RansomwareLengthExpansion: 0.15,
+		RansomwareFormatLoss:      0.25,
+		RansomwareBurstRate:       0.1875,
+	}
+	if len(got.Contributions) != len(want) {
+		t.Fatalf("contributions %+v", got.Contributions)
+	}
+	for _, c := range got.Contributions {
+		if !near(c.Percent, 100*want[c.Signal]/0.9375, 1e-9) {
+			t.Errorf("contribution %+v, want %v%%", c, 100*want[c.Signal]/0.9375)
+		}
+	}
+}
+
+func TestRansomwareDetectorScoresUpdatesOnly(t *testing.T) {
+	d := NewRansomwareDetector(0.5, time.Minute, 1)
+	insert := ransomwareInput("1", "", "Xk9Qz2Lm7Rb4Tw1P", 0)
+	insert.BeforeValue = nil
+	unchanged := ransomwareInput("2", "Xk9Qz2Lm7Rb4Tw1P", "Xk9Qz2Lm7Rb4Tw1P", 0)
+	feed(t, d, insert, unchanged)
+}
@@ -0,0 +1,137 @@
+package logprocessor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// AnomalyDetector is the minimal contract for a detector that scores an
+// anomaly input as a whole: Score returns the input's score and whether it
+// is anomalous. Register one with RegisterAnomalyDetector to use it
+// alongside the built-in detectors.
+type AnomalyDetector interface {
+	Score(input AnomalyInput) (float64, bool)
+}
+
+// DetectorFactory creates a detector for signal vectors laid out as signalNames
+type DetectorFactory func(signalNames []string) (Detector, error)
+
+var (
+	detectorFactoriesMu sync.RWMutex
+	detectorFactories   = make(map[string]DetectorFactory)
+)
+
+// RegisterDetector makes a detector available by name to NewDetector. It is
+// meant to be called from init functions, and panics if name is already
+// registered or factory is nil.
+func RegisterDetector(name string, factory DetectorFactory) {
+	detectorFactoriesMu.Lock()
+	defer detectorFactoriesMu.Unlock()
+	if factory == nil {
+		panic("logprocessor: RegisterDetector factory is nil for " + name)
+	}
+	if _, dup := detectorFactories[name]; dup {
+		panic("logprocessor: RegisterDetector called twice for " + name)
+	}
+	detectorFactories[name] = factory
+}
+
+// RegisterAnomalyDetector registers an AnomalyDetector by name, adapting it
+// to a Detector whose detections carry its score and no signal
+func RegisterAnomalyDetector(name string, factory func(signalNames []string) (AnomalyDetector, error)) {
+	RegisterDetector(name, func(signalNames []string) (Detector, error) {
+		d, err := factory(signalNames)
+		if err != nil {
+			return nil, err
+		}
+		return ScoredDetector(name, d), nil
+	})
+}
+
+// NewDetector creates the detector registered as name
+func NewDetector(name string, signalNames []string) (Detector, error) {
+	detectorFactoriesMu.RLock()
+	factory, ok := detectorFactories[name]
+	detectorFactoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown detector %q (registered: %v)", name, DetectorNames())
+	}
+	return factory(signalNames)
+}
+
+// DetectorNames lists the registered detectors in name order
+func DetectorNames() []string {
+	detectorFactoriesMu.RLock()
+	defer detectorFactoriesMu.RUnlock()
+	names := make([]string, 0, len(detectorFactories))
+	for name := range detectorFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScoredDetector adapts an AnomalyDetector to a Detector named name
+func ScoredDetector(name string, d AnomalyDetector) Detector {
+	return scoredDetector{name: name, detector: d}
+}
+
+type scoredDetector struct {
+	name     string
+	detector AnomalyDetector
+}
+
+func (d scoredDetector) Name() string {
+	return d.name
+}
+
+func (d scoredDetector) Detect(input AnomalyInput) []Detection {
+	score, anomalous := d.detector.Score(input)
+	if !anomalous {
+		return nil
+	}
+	return []Detection{{Detector: d.name, Score: score}}
+}
+
+// DetectorChain runs detectors in order, so several compose into one
+type DetectorChain []Detector
+
+// Name joins the names of the chained detectors
+func (c DetectorChain) Name() string {
+	name := ""
+	for i, d := range c {
+		if i > 0 {
+			name += "+"
+		}
+		name += d.Name()
+	}
+	return name
+}
+
+// Detect returns the detections of every chained detector
+func (c DetectorChain) Detect(input AnomalyInput) []Detection {
+	var detections []Detection
+	for _, d := range c {
+		detections = append(detections, d.Detect(input)...)
+	}
+	return detections
+}
+
+// Score returns the largest absolute detection score of the chain, and
+// whether any chained detector fired
+func (c DetectorChain) Score(input AnomalyInput) (float64, bool) {
+	detections := c.Detect(input)
+	var score float64
+	for _, d := range detections {
+		score = math.Max(score, math.Abs(d.Score))
+	}
+	return score, len(detections) > 0
+}
+
+// Score returns the number of rules matching input, and whether any did
+func (rs *RuleSet) Score(input AnomalyInput) (float64, bool) {
+	matched := rs.Match(input)
+	return float64(len(matched)), len(matched) > 0
+}
@@ -0,0 +1,161 @@
+package logprocessor
+
+import (
+	"sync"
+	"time"
+)
+
+// Defaults for row change history
+const (
+	DefaultRowHistoryWindow = time.Hour
+	DefaultRowHistoryValues = 8
+)
+
+// RowHistory correlates the changes made to each row over time, keyed by
+// table and RowIdentifier, so slow, repeated tampering with the same records
+// shows up in the signals of every change. Rows idle for longer than the
+// window are forgotten. Rows and past values are kept as ValueHasher keys only.
+type RowHistory struct {
+	window time.Duration
+	values int
+	hasher *ValueHasher
+
+	mu        sync.Mutex
+	rows      map[[2]string]*rowChanges
+	lastSweep time.Time
+}
+
+// rowChanges is the recent history of one row
+type rowChanges struct {
+	times   []time.Time           // Changes within the window, oldest first
+	columns map[string][]string   // Recent value keys of each column, oldest first
+	last    map[string]rowOutcome // Last change recorded for each column
+}
+
+// rowOutcome is a column's last recorded change and the signals computed for it
+type rowOutcome struct {
+	event    string
+	changes  int
+	reverted bool
+}
+
+// NewRowHistory creates a row history covering window, remembering up to
+// values past values per column. Non-positive settings select the defaults.
+func NewRowHistory(window time.Duration, values int, hasher *ValueHasher) *RowHistory {
+	if window <= 0 {
+		window = DefaultRowHistoryWindow
+	}
+	if values <= 0 {
+		values = DefaultRowHistoryValues
+	}
+	return &RowHistory{
+		window: window,
+		values: values,
+		hasher: hasher,
+		rows:   make(map[[2]string]*rowChanges),
+	}
+}
+
+// Observe records the change of fieldName in logData, once however many
+// generators ask, and returns the number of changes to the row within the
+// window, including this one, and whether the column reverted to a value it
+// held before its previous value. Logs without a row identifier are not tracked.
+func (h *RowHistory) Observe(logData LogData, fieldName string) (changes int, reverted bool) {
+	if logData.RowIdentifier == "" {
+		return 0, false
+	}
+	ts := logData.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sweep(ts)
+
+	key := [2]string{logData.Table, h.hasher.Key(logData.RowIdentifier)}
+	row, ok := h.rows[key]
+	if !ok {
+		row = &rowChanges{
+			columns: make(map[string][]string),
+			last:    make(map[string]rowOutcome),
+		}
+		h.rows[key] = row
+	}
+	before, after := h.hasher.ValueKeys(logData, fieldName)
+	// Keyed by the log's own timestamp, which unlike ts is the same for every generator
+	event := logData.Timestamp.String() + "\x00" + before + "\x00" + after
+	if last, seen := row.last[fieldName]; seen && last.event == event {
+		return last.changes, last.reverted
+	}
+
+	// Columns changed by the same statement share a timestamp and count once
+	if len(row.times) == 0 || !row.times[len(row.times)-1].Equal(ts) {
+		row.times = append(row.times, ts)
+	}
+	cutoff := ts.Add(-h.window)
+	expired := 0
+	for expired < len(row.times) && !row.times[expired].After(cutoff) {
+		expired++
+	}
+	row.times = row.times[expired:]
+
+	past := row.columns[fieldName]
+	if len(past) == 0 {
+		past = append(past, before)
+	}
+	for _, value := range past[:len(past)-1] {
+		if value == after && after != before {
+			reverted = true
+		}
+	}
+	past = append(past, after)
+	if len(past) > h.values {
+		past = past[len(past)-h.values:]
+	}
+	row.columns[fieldName] = past
+
+	outcome := rowOutcome{event: event, changes: len(row.times), reverted: reverted}
+	row.last[fieldName] = outcome
+	return outcome.changes, outcome.reverted
+}
+
+// sweep forgets rows without changes in the last window, at most once per window
+func (h *RowHistory) sweep(ts time.Time) {
+	if ts.Sub(h.lastSweep) < h.window {
+		return
+	}
+	h.lastSweep = ts
+	cutoff := ts.Add(-h.window)
+	for key, row := range h.rows {
+		if len(row.times) == 0 || !row.times[len(row.times)-1].After(cutoff) {
+			delete(h.rows, key)
+		}
+	}
+}
+
+// RowChangesGenerator emits the number of changes to the row within the
+// history window, including the current one
+type RowChangesGenerator struct {
+	FieldName string
+	History   *RowHistory
+}
+
+func (g *RowChangesGenerator) GenerateSignal(logData LogData) float64 {
+	changes, _ := g.History.Observe(logData, g.FieldName)
+	return float64(changes)
+}
+
+// RevertGenerator emits 1 when a column is set back to a value it held before
+// its previous value, e.g. a balance changed and quietly restored, and 0 otherwise
+type RevertGenerator struct {
+	FieldName string
+	History   *RowHistory
+}
+
+func (g *RevertGenerator) GenerateSignal(logData LogData) float64 {
+	if _, reverted := g.History.Observe(logData, g.FieldName); reverted {
+		return 1
+	}
+	return 0
+}
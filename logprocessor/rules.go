@@ -0,0 +1,422 @@
+package logprocessor
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleDefinition is a named condition over the signals of an anomaly input, as
+// written in a rules file
+type RuleDefinition struct {
+	Name string `json:"name" yaml:"name"`
+	When string `json:"when" yaml:"when"`
+}
+
+// RuleFile is the layout of a rules file (YAML or JSON):
+//
+//	rules:
+//	  - name: likely_encryption
+//	    when: Entropy(email) > 2.5 AND Levenshtein(email)/len > 0.9
+type RuleFile struct {
+	Rules []RuleDefinition `json:"rules" yaml:"rules"`
+}
+
+// Rule is a compiled RuleDefinition
+type Rule struct {
+	Name   string
+	Column string // Column the rule is limited to; empty applies to every column
+	expr   ruleExpr
+}
+
+// RuleSet evaluates rules against anomaly inputs, flagging the inputs that
+// match any rule
+type RuleSet struct {
+	rules []Rule
+}
+
+// LoadRuleFile reads and compiles a rules file for vectors laid out as signalNames
+func LoadRuleFile(path string, signalNames []string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file RuleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	rules, err := CompileRules(file.Rules, signalNames)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// CompileRules parses rule conditions. Conditions reference signals by name,
+// either for the input's own column (Entropy) or for a specific column
+// (Entropy(email)), and can use the variables len, before_len and after_len
+// (the longer, before and after value lengths in characters). They combine
+// numbers with + - * /, comparisons (> >= < <= == !=), AND, OR, NOT and
+// parentheses.
+func CompileRules(defs []RuleDefinition, signalNames []string) (*RuleSet, error) {
+	positions := make(map[string]int, len(signalNames))
+	for i, name := range signalNames {
+		positions[strings.ToLower(name)] = i
+	}
+
+	set := &RuleSet{}
+	seen := make(map[string]bool)
+	for _, def := range defs {
+		if def.Name == "" {
+			return nil, fmt.Errorf("rule %q: name is required", def.When)
+		}
+		if seen[def.Name] {
+			return nil, fmt.Errorf("duplicate rule name %q", def.Name)
+		}
+		seen[def.Name] = true
+
+		p := &ruleParser{positions: positions}
+		if err := p.tokenize(def.When); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", def.Name, err)
+		}
+		expr, err := p.parseOr()
+		if err == nil && p.pos < len(p.tokens) {
+			err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", def.Name, err)
+		}
+		set.rules = append(set.rules, Rule{Name: def.Name, Column: p.column, expr: expr})
+	}
+	return set, nil
+}
+
+// Rules returns the compiled rules in file order
+func (rs *RuleSet) Rules() []Rule {
+	return rs.rules
+}
+
+// Match returns the names of the rules input satisfies
+func (rs *RuleSet) Match(input AnomalyInput) []string {
+	env := ruleEnv{
+		input:     input,
+		beforeLen: valueLength(input.BeforeValue),
+		afterLen:  valueLength(input.AfterValue),
+	}
+	var matched []string
+	for _, rule := range rs.rules {
+		if rule.Column != "" && rule.Column != input.Column {
+			continue
+		}
+		if truthy(rule.expr(env)) {
+			matched = append(matched, rule.Name)
+		}
+	}
+	return matched
+}
+
+// Apply sets the anomaly flag and matched rule names of input
+func (rs *RuleSet) Apply(input *AnomalyInput) {
+	input.MatchedRules = rs.Match(*input)
+	input.Anomalous = len(input.MatchedRules) > 0
+}
+
+// valueLength is the length of a column value in characters; nil has length 0
+func valueLength(v interface{}) float64 {
+	if v == nil {
+		return 0
+	}
+	return float64(utf8.RuneCountInString(fmt.Sprintf("%v", v)))
+}
+
+// ruleEnv holds the values a condition is evaluated against
+type ruleEnv struct {
+	input     AnomalyInput
+	beforeLen float64
+	afterLen  float64
+}
+
+// ruleExpr evaluates part of a condition. Booleans are represented as 1 and 0.
+type ruleExpr func(env ruleEnv) float64
+
+func truthy(v float64) bool {
+	return v != 0 && !math.IsNaN(v)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ruleParser is a recursive descent parser for rule conditions
+type ruleParser struct {
+	positions map[string]int
+	tokens    []string
+	pos       int
+	column    string
+}
+
+// tokenize splits a condition into numbers, identifiers, operators and parentheses
+func (p *ruleParser) tokenize(s string) error {
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.' || s[j] == 'e' || s[j] == 'E' ||
+				((s[j] == '+' || s[j] == '-') && j > i && (s[j-1] == 'e' || s[j-1] == 'E'))) {
+				j++
+			}
+			p.tokens = append(p.tokens, s[i:j])
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_' || s[j] == '.') {
+				j++
+			}
+			p.tokens = append(p.tokens, s[i:j])
+			i = j
+		default:
+			if i+1 < len(s) {
+				if two := s[i : i+2]; two == ">=" || two == "<=" || two == "==" || two == "!=" || two == "&&" || two == "||" {
+					p.tokens = append(p.tokens, two)
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("+-*/()<>!", c) {
+				return fmt.Errorf("unexpected character %q", c)
+			}
+			p.tokens = append(p.tokens, string(c))
+			i++
+		}
+	}
+	if len(p.tokens) == 0 {
+		return fmt.Errorf("empty condition")
+	}
+	return nil
+}
+
+func (p *ruleParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// accept consumes the next token if it is one of ops (keywords case-insensitively)
+func (p *ruleParser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	for _, op := range ops {
+		if strings.EqualFold(tok, op) {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *ruleParser) parseOr() (ruleExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("OR", "||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(env ruleEnv) float64 { return boolValue(truthy(l(env)) || truthy(right(env))) }
+	}
+}
+
+func (p *ruleParser) parseAnd() (ruleExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("AND", "&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(env ruleEnv) float64 { return boolValue(truthy(l(env)) && truthy(right(env))) }
+	}
+}
+
+func (p *ruleParser) parseNot() (ruleExpr, error) {
+	if _, ok := p.accept("NOT", "!"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(env ruleEnv) float64 { return boolValue(!truthy(operand(env))) }, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *ruleParser) parseComparison() (ruleExpr, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept(">=", "<=", "==", "!=", ">", "<")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	compare := map[string]func(a, b float64) bool{
+		">":  func(a, b float64) bool { return a > b },
+		">=": func(a, b float64) bool { return a >= b },
+		"<":  func(a, b float64) bool { return a < b },
+		"<=": func(a, b float64) bool { return a <= b },
+		"==": func(a, b float64) bool { return a == b },
+		"!=": func(a, b float64) bool { return a != b },
+	}[op]
+	return func(env ruleEnv) float64 { return boolValue(compare(left(env), right(env))) }, nil
+}
+
+func (p *ruleParser) parseSum() (ruleExpr, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "+" {
+			left = func(env ruleEnv) float64 { return l(env) + right(env) }
+		} else {
+			left = func(env ruleEnv) float64 { return l(env) - right(env) }
+		}
+	}
+}
+
+func (p *ruleParser) parseProduct() (ruleExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*", "/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "*" {
+			left = func(env ruleEnv) float64 { return l(env) * right(env) }
+		} else {
+			left = func(env ruleEnv) float64 { return l(env) / right(env) }
+		}
+	}
+}
+
+func (p *ruleParser) parseUnary() (ruleExpr, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(env ruleEnv) float64 { return -operand(env) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *ruleParser) parsePrimary() (ruleExpr, error) {
+	tok := p.peek()
+	if tok == "" {
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+	p.pos++
+
+	switch {
+	case tok == "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("missing )")
+		}
+		return expr, nil
+	case unicode.IsDigit(rune(tok[0])) || tok[0] == '.':
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok)
+		}
+		return func(ruleEnv) float64 { return v }, nil
+	case unicode.IsLetter(rune(tok[0])) || tok[0] == '_':
+		return p.parseIdentifier(tok)
+	default:
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+}
+
+// parseIdentifier resolves a variable or a signal reference with an optional column
+func (p *ruleParser) parseIdentifier(name string) (ruleExpr, error) {
+	switch strings.ToLower(name) {
+	case "len":
+		return func(env ruleEnv) float64 { return math.Max(env.beforeLen, env.afterLen) }, nil
+	case "before_len":
+		return func(env ruleEnv) float64 { return env.beforeLen }, nil
+	case "after_len":
+		return func(env ruleEnv) float64 { return env.afterLen }, nil
+	}
+
+	position, ok := p.positions[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown signal or variable %q", name)
+	}
+	if _, ok := p.accept("("); ok {
+		column := p.peek()
+		if column == "" || column == ")" {
+			return nil, fmt.Errorf("%s(): missing column", name)
+		}
+		p.pos++
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("%s(%s: missing )", name, column)
+		}
+		if p.column != "" && p.column != column {
+			return nil, fmt.Errorf("a rule can only reference one column (%s and %s)", p.column, column)
+		}
+		p.column = column
+	}
+	return func(env ruleEnv) float64 {
+		if position >= len(env.input.SignalVector) {
+			return math.NaN()
+		}
+		return env.input.SignalVector[position]
+	}, nil
+}
@@ -0,0 +1,89 @@
+package logprocessor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRuleSetMatch(t *testing.T) {
+	rules, err := CompileRules([]RuleDefinition{
+		{Name: "likely_encryption", When: "Entropy(email) > 2.5 AND Levenshtein(email)/len > 0.9"},
+		{Name: "grew", When: "after_len >= 2 * before_len"},
+		{Name: "calm", When: "entropy < 1 AND levenshtein <= 1"},
+	}, testSignals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		column string
+		before interface{}
+		after  interface{}
+		vector []float64
+		want   []string
+	}{
+		{"encrypted email", "email", "bob@example.com", "Xk9Qz2Lm7Rb4Tw1P", []float64{3.5, 16}, []string{"likely_encryption"}},
+		{"entropy at the threshold", "email", "bob@example.com", "Xk9Qz2Lm7Rb4Tw1P", []float64{2.5, 16}, nil},
+		{"edited email", "email", "bob@example.com", "rob@example.com", []float64{0.8, 1}, []string{"calm"}},
+		{"other column", "name", "Bob", "Xk9Qz2Lm7Rb4Tw1P", []float64{3.5, 16}, []string{"grew"}},
+		{"insert", "name", nil, "Bob", []float64{0.5, 0}, []string{"grew", "calm"}},
+		// Signals missing from the vector fail every comparison
+		{"short vector", "email", "a", "b", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := vectorInput(tt.vector...)
+			input.Column, input.BeforeValue, input.AfterValue = tt.column, tt.before, tt.after
+			rules.Apply(&input)
+			if strings.Join(input.MatchedRules, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("matched %v, want %v", input.MatchedRules, tt.want)
+			}
+			if input.Anomalous != (len(tt.want) > 0) {
+				t.Errorf("anomalous %v with rules %v", input.Anomalous, input.MatchedRules)
+			}
+		})
+	}
+}
+
+func TestRuleArithmetic(t *testing.T) {
+	rules, err := CompileRules([]RuleDefinition{
+		{Name: "precedence", When: "1 + 2 * 3 == 7 AND -(1 - 3) == 2"},
+		{Name: "exponent", When: "Entropy > 1e-3"},
+		{Name: "negation", When: "NOT Entropy > 1 && !(Levenshtein < 1)"},
+		{Name: "division_by_zero", When: "Levenshtein / 0 > 1"},
+	}, testSignals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rules.Match(vectorInput(0.5, 3))
+	if strings.Join(got, ",") != "precedence,exponent,negation,division_by_zero" {
+		t.Errorf("matched %v", got)
+	}
+}
+
+func TestCompileRulesErrors(t *testing.T) {
+	tests := []struct {
+		def  RuleDefinition
+		want string
+	}{
+		{RuleDefinition{When: "Entropy > 1"}, "name is required"},
+		{RuleDefinition{Name: "r", When: "Compressibility > 1"}, `unknown signal or variable "Compressibility"`},
+		{RuleDefinition{Name: "r", When: "Entropy(email) > Entropy(name)"}, "only reference one column"},
+		{RuleDefinition{Name: "r", When: "Entropy > 1 1"}, `unexpected "1"`},
+		{RuleDefinition{Name: "r", When: "(Entropy > 1"}, "missing )"},
+		{RuleDefinition{Name: "r", When: "Entropy # 1"}, "unexpected character"},
+		{RuleDefinition{Name: "r", When: " "}, "empty condition"},
+	}
+	for _, tt := range tests {
+		_, err := CompileRules([]RuleDefinition{tt.def}, testSignals)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error %v, want one mentioning %q", tt.def.When, err, tt.want)
+		}
+	}
+
+	_, err := CompileRules([]RuleDefinition{{Name: "r", When: "Entropy > 1"}, {Name: "r", When: "Entropy > 2"}}, testSignals)
+	if err == nil || !strings.Contains(err.Error(), "duplicate rule name") {
+		t.Errorf("duplicate names: error %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package logprocessor
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity ranks flagged anomaly inputs for routing, e.g. logging info,
+// ticketing warn and paging critical
+type Severity string
+
+// Severity levels, lowest first
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarn     Severity = "warn"
+	SeverityCritical Severity = "critical"
+)
+
+var severityLevels = []Severity{SeverityInfo, SeverityWarn, SeverityCritical}
+
+// ParseSeverity validates a severity name
+func ParseSeverity(s string) (Severity, error) {
+	for _, level := range severityLevels {
+		if Severity(s) == level {
+			return level, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity %q (expected info, warn or critical)", s)
+}
+
+// AtLeast reports whether s is as severe as other
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+func (s Severity) rank() int {
+	for i, level := range severityLevels {
+		if s == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// shift moves s by n levels, staying within info and critical
+func (s Severity) shift(n int) Severity {
+	i := s.rank() + n
+	i = int(math.Max(0, math.Min(float64(i), float64(len(severityLevels)-1))))
+	return severityLevels[i]
+}
+
+// Table sensitivities
+const (
+	SensitivityLow    = "low"
+	SensitivityNormal = "normal"
+	SensitivityHigh   = "high"
+)
+
+// TableConfig describes a monitored table. High sensitivity raises the
+// severity of its anomalies by one level and low sensitivity lowers it. Tags
+// (e.g. pii, pci) are carried into alerts for routing.
+type TableConfig struct {
+	Sensitivity string   `json:"sensitivity" yaml:"sensitivity"`
+	Tags        []string `json:"tags" yaml:"tags"`
+}
+
+// TableConfigFile is the layout of a table configuration file (YAML or JSON):
+//
+//	tables:
+//	  payments:
+//	    sensitivity: high
+//	    tags: [pci]
+type TableConfigFile struct {
+	Tables map[string]TableConfig `json:"tables" yaml:"tables"`
+}
+
+// LoadTableConfigFile reads and validates a table configuration file
+func LoadTableConfigFile(path string) (map[string]TableConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file TableConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for table, config := range file.Tables {
+		switch config.Sensitivity {
+		case "", SensitivityLow, SensitivityNormal, SensitivityHigh:
+		default:
+			return nil, fmt.Errorf("%s: table %s: unknown sensitivity %q (expected low, normal or high)", path, table, config.Sensitivity)
+		}
+	}
+	return file.Tables, nil
+}
+
+// Defaults for severity classification
+const (
+	DefaultSeverityWarnScore     = 4
+	DefaultSeverityCriticalScore = 10
+	DefaultSeverityWarnRows      = 5
+	DefaultSeverityCriticalRows  = 50
+	DefaultSeverityWindow        = time.Minute
+)
+
+// SeverityConfig sets the thresholds of a SeverityClassifier
+type SeverityConfig struct {
+	WarnScore     float64       // Detection score making an input warn
+	CriticalScore float64       // Detection score making an input critical
+	WarnRows      int           // Flagged inputs of a table within Window making an input warn
+	CriticalRows  int           // Flagged inputs of a table within Window making an input critical
+	Window        time.Duration // Period flagged inputs are counted over
+	Tables        map[string]TableConfig
+}
+
+// SeverityClassifier assigns a severity to flagged anomaly inputs from the
+// largest absolute detection score, the number of flagged inputs of the same
+// table in the recent window, and the table's sensitivity. Inputs matching a
+// rule are at least warn, and DDL events at least their DDL severity.
+type SeverityClassifier struct {
+	config  SeverityConfig
+	flagged map[string][]time.Time
+}
+
+// NewSeverityClassifier creates a classifier, applying defaults to unset thresholds
+func NewSeverityClassifier(config SeverityConfig) *SeverityClassifier {
+	if config.WarnScore <= 0 {
+		config.WarnScore = DefaultSeverityWarnScore
+	}
+	if config.CriticalScore <= 0 {
+		config.CriticalScore = DefaultSeverityCriticalScore
+	}
+	if config.WarnRows <= 0 {
+		config.WarnRows = DefaultSeverityWarnRows
+	}
+	if config.CriticalRows <= 0 {
+		config.CriticalRows = DefaultSeverityCriticalRows
+	}
+	if config.Window <= 0 {
+		config.Window = DefaultSeverityWindow
+	}
+	return &SeverityClassifier{config: config, flagged: make(map[string][]time.Time)}
+}
+
+// Classify sets the severity of a flagged input; unflagged inputs have none
+func (c *SeverityClassifier) Classify(input *AnomalyInput) {
+	if !input.Anomalous {
+		return
+	}
+	rows := c.count(input.Table, input.Timestamp)
+
+	var score float64
+	for _, d := range input.Detections {
+		score = math.Max(score, math.Abs(d.Score))
+	}
+
+	severity := SeverityInfo
+	switch {
+	case score >= c.config.CriticalScore || rows >= c.config.CriticalRows:
+		severity = SeverityCritical
+	case score >= c.config.WarnScore || rows >= c.config.WarnRows || len(input.MatchedRules) > 0:
+		severity = SeverityWarn
+	}
+	if floor := ddlSeverity(input.Operation); floor != "" && !severity.AtLeast(floor) {
+		severity = floor
+	}
+
+	switch c.config.Tables[input.Table].Sensitivity {
+	case SensitivityHigh:
+		severity = severity.shift(1)
+	case SensitivityLow:
+		severity = severity.shift(-1)
+	}
+	input.Severity = severity
+}
+
+// count records a flagged input of table at ts and returns the number of
+// flagged inputs of the table within the window
+func (c *SeverityClassifier) count(table string, ts time.Time) int {
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	times := append(c.flagged[table], ts)
+	cutoff := ts.Add(-c.config.Window)
+	expired := 0
+	for expired < len(times) && !times[expired].After(cutoff) {
+		expired++
+	}
+	c.flagged[table] = times[expired:]
+	return len(times) - expired
+}
@@ -0,0 +1,177 @@
+package logprocessor
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SuppressedDuplicate is the Suppressed reason of an input repeating an
+// anomaly of the same row reported within the cooldown
+const SuppressedDuplicate = "duplicate"
+
+// SuppressionRule silences anomalies expected from known activity, e.g. a
+// bulk migration rewriting one column. Table and Column accept shell patterns
+// (staging_*); empty fields match anything.
+type SuppressionRule struct {
+	Name      string    `json:"name" yaml:"name"`
+	Table     string    `json:"table,omitempty" yaml:"table"`
+	Column    string    `json:"column,omitempty" yaml:"column"`
+	Operation string    `json:"operation,omitempty" yaml:"operation"`
+	Until     time.Time `json:"until,omitempty" yaml:"until"` // Rule expires at this time; zero never expires
+}
+
+// SuppressionFile is the layout of a suppression file (YAML or JSON):
+//
+//	suppressions:
+//	  - name: staging_bio
+//	    table: staging_users
+//	    column: bio
+//	  - name: orders_migration
+//	    table: orders
+//	    operation: UPDATE
+//	    until: 2024-06-01T00:00:00Z
+type SuppressionFile struct {
+	Suppressions []SuppressionRule `json:"suppressions" yaml:"suppressions"`
+}
+
+// LoadSuppressionFile reads and validates a suppression file
+func LoadSuppressionFile(path string) ([]SuppressionRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file SuppressionFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := validateSuppressions(file.Suppressions); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file.Suppressions, nil
+}
+
+// validateSuppressions checks rule names and patterns
+func validateSuppressions(rules []SuppressionRule) error {
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("suppression for table %q column %q: name is required", rule.Table, rule.Column)
+		}
+		if seen[rule.Name] {
+			return fmt.Errorf("duplicate suppression name %q", rule.Name)
+		}
+		seen[rule.Name] = true
+		if rule.Name == SuppressedDuplicate {
+			return fmt.Errorf("suppression name %q is reserved", rule.Name)
+		}
+		for _, pattern := range []string{rule.Table, rule.Column} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("suppression %q: invalid pattern %q", rule.Name, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Suppressor silences flagged inputs matching a suppression rule, and
+// dedupes repeated anomalies of the same (table, column, row) so a row
+// flagged on every update is reported once per cooldown. Suppressed inputs
+// keep their rules and detections for auditing but are no longer anomalous.
+// Rows are remembered by their ValueHasher key.
+type Suppressor struct {
+	rules    []SuppressionRule
+	cooldown time.Duration
+	hasher   *ValueHasher
+
+	reported  map[[3]string]time.Time // Last reported anomaly by table, column and row
+	lastSweep time.Time
+}
+
+// NewSuppressor creates a suppressor. A zero cooldown disables deduplication.
+// A nil hasher keeps row identifiers as they are.
+func NewSuppressor(rules []SuppressionRule, cooldown time.Duration, hasher *ValueHasher) *Suppressor {
+	return &Suppressor{
+		rules:    rules,
+		cooldown: cooldown,
+		hasher:   hasher,
+		reported: make(map[[3]string]time.Time),
+	}
+}
+
+// Apply suppresses input if it is flagged and matches a rule or repeats an
+// anomaly reported within the cooldown, recording the reason in Suppressed
+func (s *Suppressor) Apply(input *AnomalyInput) {
+	if !input.Anomalous {
+		return
+	}
+	ts := input.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	if rule := s.match(input, ts); rule != "" {
+		suppress(input, rule)
+		return
+	}
+	// Rows are only known when the log identifies them
+	if s.cooldown <= 0 || input.RowIdentifier == "" {
+		return
+	}
+	s.sweep(ts)
+	key := [3]string{input.Table, input.Column, s.hasher.Key(input.RowIdentifier)}
+	if last, ok := s.reported[key]; ok && ts.Sub(last) < s.cooldown {
+		suppress(input, SuppressedDuplicate)
+		return
+	}
+	s.reported[key] = ts
+}
+
+// match returns the name of the first unexpired rule matching input
+func (s *Suppressor) match(input *AnomalyInput, ts time.Time) string {
+	for _, rule := range s.rules {
+		if !rule.Until.IsZero() && !ts.Before(rule.Until) {
+			continue
+		}
+		if rule.Operation != "" && !strings.EqualFold(rule.Operation, input.Operation) {
+			continue
+		}
+		if matchPattern(rule.Table, input.Table) && matchPattern(rule.Column, input.Column) {
+			return rule.Name
+		}
+	}
+	return ""
+}
+
+// sweep forgets rows last reported over a cooldown ago, at most once per cooldown
+func (s *Suppressor) sweep(ts time.Time) {
+	if ts.Sub(s.lastSweep) < s.cooldown {
+		return
+	}
+	s.lastSweep = ts
+	for key, last := range s.reported {
+		if ts.Sub(last) >= s.cooldown {
+			delete(s.reported, key)
+		}
+	}
+}
+
+// matchPattern reports whether value matches a shell pattern; an empty pattern matches anything
+func matchPattern(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, _ := path.Match(pattern, value)
+	return matched
+}
+
+// suppress clears the anomaly flag of input, recording why
+func suppress(input *AnomalyInput, reason string) {
+	input.Anomalous = false
+	input.Severity = ""
+	input.Suppressed = reason
+}
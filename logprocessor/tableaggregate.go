@@ -0,0 +1,175 @@
+package logprocessor
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TableRecordType marks a table aggregate record
+const TableRecordType = "table"
+
+// Defaults for table aggregation
+const (
+	DefaultTableMinPercent       = 10.0
+	DefaultTableMinEncryptedRows = 5
+)
+
+// TableAggregate summarises the rows of one table changed within a time
+// window: how many there were and what share of them had updates that look
+// encrypted, which downstream consumers would otherwise have to rebuild from
+// per-row inputs
+type TableAggregate struct {
+	RecordType       string    `json:"record_type"` // Always TableRecordType
+	Table            string    `json:"table"`
+	WindowStart      time.Time `json:"window_start"`
+	WindowEnd        time.Time `json:"window_end"`
+	Rows             int       `json:"rows"`              // Distinct rows changed in the window
+	EncryptedRows    int       `json:"encrypted_rows"`    // Rows with an update that looks encrypted
+	EncryptedPercent float64   `json:"encrypted_percent"` // EncryptedRows as a percentage of Rows
+	Columns          []string  `json:"columns"`           // Columns with updates that look encrypted
+	Anomalous        bool      `json:"anomaly,omitempty"` // Set when both minimums were reached
+}
+
+// TableAggregateConfig selects the windows rows are aggregated over and when
+// a table aggregate is anomalous
+type TableAggregateConfig struct {
+	Size             time.Duration // Length of each window
+	Slide            time.Duration // Interval between window starts; equal to Size for tumbling windows
+	MinPercent       float64       // Percentage of rows looking encrypted that makes a window anomalous
+	MinEncryptedRows int           // Rows looking encrypted that make a window anomalous
+	Hasher           *ValueHasher  // Derives the keys rows are told apart by; nil keeps row identifiers as they are
+}
+
+// TableAggregator aggregates the rows changed in each table over windows of
+// input timestamps, like WindowAggregator. Rows are told apart by their row
+// identifier or, without one, by the timestamp of their log.
+type TableAggregator struct {
+	config    TableAggregateConfig
+	windows   map[windowKey]*tableWindow
+	watermark time.Time
+}
+
+// tableWindow is the open state of one table aggregate
+type tableWindow struct {
+	aggregate TableAggregate
+	rows      map[string]bool // Whether each row had an update looking encrypted
+	columns   map[string]bool
+}
+
+// NewTableAggregator creates an aggregator, applying defaults to unset or
+// invalid fields of config
+func NewTableAggregator(config TableAggregateConfig) *TableAggregator {
+	if config.Size <= 0 {
+		config.Size = DefaultWindowSize
+	}
+	if config.Slide <= 0 || config.Slide > config.Size {
+		config.Slide = config.Size
+	}
+	if config.MinPercent <= 0 {
+		config.MinPercent = DefaultTableMinPercent
+	}
+	if config.MinEncryptedRows <= 0 {
+		config.MinEncryptedRows = DefaultTableMinEncryptedRows
+	}
+	return &TableAggregator{
+		config:  config,
+		windows: make(map[windowKey]*tableWindow),
+	}
+}
+
+// Add records the row of input in every window containing its timestamp and
+// returns the aggregates that input's timestamp closed, oldest first
+func (a *TableAggregator) Add(input AnomalyInput) []TableAggregate {
+	ts := input.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	row := "\x00" + ts.String()
+	if input.RowIdentifier != "" {
+		row = a.config.Hasher.Key(input.RowIdentifier)
+	}
+	encrypted := LooksEncrypted(input.BeforeValue, input.AfterValue)
+
+	for _, start := range windowStarts(ts, a.config.Size, a.config.Slide, a.watermark) {
+		key := windowKey{table: input.Table, start: start.UnixNano()}
+		w, ok := a.windows[key]
+		if !ok {
+			w = &tableWindow{
+				aggregate: TableAggregate{
+					RecordType:  TableRecordType,
+					Table:       input.Table,
+					WindowStart: start,
+					WindowEnd:   start.Add(a.config.Size),
+				},
+				rows:    make(map[string]bool),
+				columns: make(map[string]bool),
+			}
+			a.windows[key] = w
+		}
+		w.rows[row] = w.rows[row] || encrypted
+		if encrypted {
+			w.columns[input.Column] = true
+		}
+	}
+
+	if ts.After(a.watermark) {
+		a.watermark = ts
+	}
+	return a.emit(func(w *tableWindow) bool { return !w.aggregate.WindowEnd.After(a.watermark) })
+}
+
+// Flush returns every open aggregate, oldest first
+func (a *TableAggregator) Flush() []TableAggregate {
+	return a.emit(func(*tableWindow) bool { return true })
+}
+
+// emit removes and finalises the aggregates selected by done
+func (a *TableAggregator) emit(done func(*tableWindow) bool) []TableAggregate {
+	var closed []TableAggregate
+	for key, w := range a.windows {
+		if !done(w) {
+			continue
+		}
+		t := w.aggregate
+		t.Rows = len(w.rows)
+		for _, encrypted := range w.rows {
+			if encrypted {
+				t.EncryptedRows++
+			}
+		}
+		t.EncryptedPercent = 100 * float64(t.EncryptedRows) / float64(t.Rows)
+		t.Anomalous = t.EncryptedPercent >= a.config.MinPercent && t.EncryptedRows >= a.config.MinEncryptedRows
+		t.Columns = make([]string, 0, len(w.columns))
+		for column := range w.columns {
+			t.Columns = append(t.Columns, column)
+		}
+		sort.Strings(t.Columns)
+		closed = append(closed, t)
+		delete(a.windows, key)
+	}
+	sort.Slice(closed, func(i, j int) bool {
+		if !closed[i].WindowStart.Equal(closed[j].WindowStart) {
+			return closed[i].WindowStart.Before(closed[j].WindowStart)
+		}
+		return closed[i].Table < closed[j].Table
+	})
+	return closed
+}
+
+// LooksEncrypted reports whether an update replaced a value with one that
+// looks encrypted: ciphertext where there was none, or a value whose entropy
+// rose by at least DefaultBurstEntropyDelta bits while losing its format
+func LooksEncrypted(before, after interface{}) bool {
+	if before == nil || after == nil {
+		return false
+	}
+	b, a := fmt.Sprint(before), fmt.Sprint(after)
+	if b == "" || b == a {
+		return false
+	}
+	if valueFormat(a) == "ciphertext" && valueFormat(b) != "ciphertext" {
+		return true
+	}
+	return calculateEntropy(a)-calculateEntropy(b) >= DefaultBurstEntropyDelta && lostFormat(b, a)
+}
@@ -0,0 +1,120 @@
+package logprocessor
+
+import (
+	"math"
+	"sort"
+)
+
+// tdigestCompression bounds the number of centroids a digest keeps
+const tdigestCompression = 100
+
+// centroid is a cluster of values summarised by their mean and count
+type centroid struct {
+	Mean   float64 `json:"mean"`
+	Weight float64 `json:"weight"`
+}
+
+// tdigest estimates quantiles of a stream in bounded memory (a merging
+// t-digest). Centroids near the tails hold few values, so extreme quantiles
+// stay accurate for heavy-tailed distributions.
+type tdigest struct {
+	centroids []centroid
+	buffer    []centroid
+	count     float64
+	min, max  float64
+}
+
+// add records one value
+func (t *tdigest) add(v float64) {
+	if t.count == 0 || v < t.min {
+		t.min = v
+	}
+	if t.count == 0 || v > t.max {
+		t.max = v
+	}
+	t.count++
+	t.buffer = append(t.buffer, centroid{Mean: v, Weight: 1})
+	if len(t.buffer) >= 5*tdigestCompression {
+		t.compress()
+	}
+}
+
+// compress merges buffered values into the centroids, letting each centroid
+// grow only as large as its position between the tails allows
+func (t *tdigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.centroids, t.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].Mean < all[j].Mean })
+	t.buffer = t.buffer[:0]
+
+	merged := make([]centroid, 0, 2*tdigestCompression)
+	current := all[0]
+	var before float64
+	for _, next := range all[1:] {
+		proposed := current.Weight + next.Weight
+		q := (before + proposed/2) / t.count
+		if limit := 4 * t.count * q * (1 - q) / tdigestCompression; proposed <= math.Max(limit, 1) {
+			current.Mean += (next.Mean - current.Mean) * next.Weight / proposed
+			current.Weight = proposed
+			continue
+		}
+		merged = append(merged, current)
+		before += current.Weight
+		current = next
+	}
+	t.centroids = append(merged, current)
+}
+
+// quantile estimates the value below which a fraction q of the values fall
+func (t *tdigest) quantile(q float64) float64 {
+	t.compress()
+	if len(t.centroids) == 0 {
+		return math.NaN()
+	}
+	if len(t.centroids) == 1 {
+		return t.centroids[0].Mean
+	}
+
+	// Interpolate between centroid centres, and towards min/max at the ends
+	target := q * t.count
+	first, last := t.centroids[0], t.centroids[len(t.centroids)-1]
+	if target <= first.Weight/2 {
+		return t.min + (first.Mean-t.min)*target/(first.Weight/2)
+	}
+	if target >= t.count-last.Weight/2 {
+		return last.Mean + (t.max-last.Mean)*(target-(t.count-last.Weight/2))/(last.Weight/2)
+	}
+
+	centre := first.Weight / 2
+	for i := 1; i < len(t.centroids); i++ {
+		prev, c := t.centroids[i-1], t.centroids[i]
+		next := centre + prev.Weight/2 + c.Weight/2
+		if target <= next {
+			return prev.Mean + (c.Mean-prev.Mean)*(target-centre)/(next-centre)
+		}
+		centre = next
+	}
+	return t.max
+}
+
+// cdf estimates the fraction of values at or below x, counting each centroid
+// wholly on one side of it
+func (t *tdigest) cdf(x float64) float64 {
+	t.compress()
+	if t.count == 0 || x < t.min {
+		return 0
+	}
+	if x >= t.max {
+		return 1
+	}
+	var below float64
+	for _, c := range t.centroids {
+		if c.Mean > x {
+			break
+		}
+		below += c.Weight
+	}
+	return below / t.count
+}
@@ -0,0 +1,161 @@
+package logprocessor
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// WindowRecordType marks a window aggregate record
+const WindowRecordType = "window"
+
+// Defaults for window aggregation
+const (
+	DefaultWindowSize       = time.Minute
+	DefaultWindowMinFlagged = 5
+)
+
+// WindowAggregate summarises the anomaly inputs of one table over a time
+// window. A single flagged row is often noise; many flagged rows in a window
+// point to sustained activity such as a bulk rewrite.
+type WindowAggregate struct {
+	RecordType   string    `json:"record_type"` // Always WindowRecordType
+	Table        string    `json:"table"`
+	WindowStart  time.Time `json:"window_start"`
+	WindowEnd    time.Time `json:"window_end"`
+	Events       int       `json:"events"`  // Anomaly inputs in the window
+	Flagged      int       `json:"flagged"` // Inputs flagged by rules or detectors
+	FlaggedRatio float64   `json:"flagged_ratio"`
+	MaxScore     float64   `json:"max_score"`         // Largest absolute detection score
+	Columns      []string  `json:"columns"`           // Columns with flagged inputs
+	Anomalous    bool      `json:"anomaly,omitempty"` // Set when at least MinFlagged inputs were flagged
+}
+
+// WindowConfig selects the windows inputs are aggregated over
+type WindowConfig struct {
+	Size       time.Duration // Length of each window
+	Slide      time.Duration // Interval between window starts; equal to Size for tumbling windows
+	MinFlagged int           // Flagged inputs that make a window anomalous
+}
+
+// WindowAggregator aggregates anomaly inputs per table over sliding windows
+// of their timestamps. A window is emitted once an input at or after its end
+// has been seen; inputs arriving for windows already emitted are dropped.
+type WindowAggregator struct {
+	config    WindowConfig
+	windows   map[windowKey]*WindowAggregate
+	columns   map[windowKey]map[string]bool
+	watermark time.Time
+}
+
+// windowKey identifies the window of one table starting at a given time
+type windowKey struct {
+	table string
+	start int64
+}
+
+// NewWindowAggregator creates an aggregator, applying defaults to unset or
+// invalid fields of config
+func NewWindowAggregator(config WindowConfig) *WindowAggregator {
+	if config.Size <= 0 {
+		config.Size = DefaultWindowSize
+	}
+	if config.Slide <= 0 || config.Slide > config.Size {
+		config.Slide = config.Size
+	}
+	if config.MinFlagged <= 0 {
+		config.MinFlagged = DefaultWindowMinFlagged
+	}
+	return &WindowAggregator{
+		config:  config,
+		windows: make(map[windowKey]*WindowAggregate),
+		columns: make(map[windowKey]map[string]bool),
+	}
+}
+
+// Add counts input in every window containing its timestamp and returns the
+// windows that input's timestamp closed, oldest first
+func (a *WindowAggregator) Add(input AnomalyInput) []WindowAggregate {
+	ts := input.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	score := 0.0
+	for _, d := range input.Detections {
+		score = math.Max(score, math.Abs(d.Score))
+	}
+
+	for _, start := range windowStarts(ts, a.config.Size, a.config.Slide, a.watermark) {
+		key := windowKey{table: input.Table, start: start.UnixNano()}
+		w, ok := a.windows[key]
+		if !ok {
+			w = &WindowAggregate{
+				RecordType:  WindowRecordType,
+				Table:       input.Table,
+				WindowStart: start,
+				WindowEnd:   start.Add(a.config.Size),
+			}
+			a.windows[key] = w
+			a.columns[key] = make(map[string]bool)
+		}
+		w.Events++
+		if input.Anomalous {
+			w.Flagged++
+			a.columns[key][input.Column] = true
+		}
+		w.MaxScore = math.Max(w.MaxScore, score)
+	}
+
+	if ts.After(a.watermark) {
+		a.watermark = ts
+	}
+	return a.emit(func(w *WindowAggregate) bool { return !w.WindowEnd.After(a.watermark) })
+}
+
+// windowStarts returns the starts of the windows of size, starting every
+// slide, that contain ts and were not yet emitted at watermark
+func windowStarts(ts time.Time, size, slide time.Duration, watermark time.Time) []time.Time {
+	var starts []time.Time
+	// Windows start at multiples of the slide; the latest one containing ts
+	// starts at or before it
+	for start := ts.Truncate(slide); ts.Before(start.Add(size)); start = start.Add(-slide) {
+		if !start.Add(size).After(watermark) {
+			break // Already emitted
+		}
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+// Flush returns every open window, oldest first
+func (a *WindowAggregator) Flush() []WindowAggregate {
+	return a.emit(func(*WindowAggregate) bool { return true })
+}
+
+// emit removes and finalises the windows selected by done
+func (a *WindowAggregator) emit(done func(*WindowAggregate) bool) []WindowAggregate {
+	var closed []WindowAggregate
+	for key, w := range a.windows {
+		if !done(w) {
+			continue
+		}
+		w.FlaggedRatio = float64(w.Flagged) / float64(w.Events)
+		w.Anomalous = w.Flagged >= a.config.MinFlagged
+		w.Columns = make([]string, 0, len(a.columns[key]))
+		for column := range a.columns[key] {
+			w.Columns = append(w.Columns, column)
+		}
+		sort.Strings(w.Columns)
+		closed = append(closed, *w)
+		delete(a.windows, key)
+		delete(a.columns, key)
+	}
+	sort.Slice(closed, func(i, j int) bool {
+		if !closed[i].WindowStart.Equal(closed[j].WindowStart) {
+			return closed[i].WindowStart.Before(closed[j].WindowStart)
+		}
+		return closed[i].Table < closed[j].Table
+	})
+	return closed
+}
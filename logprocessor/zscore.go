@@ -0,0 +1,178 @@
+package logprocessor
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Defaults for the rolling z-score detector
+const (
+	DefaultZScoreWindow    = 100
+	DefaultZScoreThreshold = 3
+)
+
+// ZScoreDetector keeps the mean and standard deviation of the last Window
+// values of each (table, column, signal) series and flags values whose
+// z-score exceeds Threshold. A series is only scored once its window is at
+// least half full, and series without any variation are not scored.
+type ZScoreDetector struct {
+	window      int
+	threshold   float64
+	signalNames []string
+	series      map[seriesKey]*rollingStats
+	frozen      bool
+}
+
+// NewZScoreDetector creates a detector for vectors laid out as signalNames.
+// Non-positive window and threshold select the defaults.
+func NewZScoreDetector(window int, threshold float64, signalNames []string) *ZScoreDetector {
+	if window <= 0 {
+		window = DefaultZScoreWindow
+	}
+	if threshold <= 0 {
+		threshold = DefaultZScoreThreshold
+	}
+	return &ZScoreDetector{
+		window:      window,
+		threshold:   threshold,
+		signalNames: signalNames,
+		series:      make(map[seriesKey]*rollingStats),
+	}
+}
+
+// Name identifies the detector in detections
+func (d *ZScoreDetector) Name() string {
+	return "zscore"
+}
+
+// Detect scores each signal of input against its window, then adds it
+func (d *ZScoreDetector) Detect(input AnomalyInput) []Detection {
+	var detections []Detection
+	for i, value := range input.SignalVector {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		key := seriesKey{table: input.Table, column: input.Column, signal: i}
+		stats, ok := d.series[key]
+		if !ok {
+			if d.frozen {
+				continue
+			}
+			stats = &rollingStats{values: make([]float64, 0, d.window)}
+			d.series[key] = stats
+		}
+
+		if len(stats.values) >= (d.window+1)/2 {
+			if std := stats.stddev(); std > 0 {
+				if z := (value - stats.mean()) / std; math.Abs(z) > d.threshold {
+					detections = append(detections, Detection{
+						Detector:    d.Name(),
+						Signal:      signalLabel(d.signalNames, i),
+						Value:       value,
+						Score:       z,
+						Samples:     len(stats.values),
+						Uncertainty: scoreUncertainty(z, len(stats.values)),
+					})
+				}
+			}
+		}
+		if !d.frozen {
+			stats.add(value, d.window)
+		}
+	}
+	return detections
+}
+
+// Freeze stops the detector adding values to its windows
+func (d *ZScoreDetector) Freeze() {
+	d.frozen = true
+}
+
+// rollingStats holds a window of values with their running sum and sum of squares
+type rollingStats struct {
+	values []float64
+	next   int
+	sum    float64
+	sumSq  float64
+}
+
+// add appends v, evicting the oldest value once the window is full
+func (s *rollingStats) add(v float64, window int) {
+	if len(s.values) < window {
+		s.values = append(s.values, v)
+	} else {
+		old := s.values[s.next]
+		s.sum -= old
+		s.sumSq -= old * old
+		s.values[s.next] = v
+		s.next = (s.next + 1) % window
+	}
+	s.sum += v
+	s.sumSq += v * v
+}
+
+func (s *rollingStats) mean() float64 {
+	return s.sum / float64(len(s.values))
+}
+
+// stddev is the population standard deviation of the window
+func (s *rollingStats) stddev() float64 {
+	n := float64(len(s.values))
+	variance := s.sumSq/n - (s.sum/n)*(s.sum/n)
+	if variance <= 1e-12 {
+		return 0
+	}
+	return math.Sqrt(variance)
+}
+
+// signalLabel names signal vector position i
+func signalLabel(names []string, i int) string {
+	if i < len(names) {
+		return names[i]
+	}
+	return "unknown"
+}
+
+// zscoreBaseline is the persisted window of a series, oldest value first
+type zscoreBaseline struct {
+	Values []float64 `json:"values"`
+}
+
+// Baselines returns the window of every series
+func (d *ZScoreDetector) Baselines() (map[BaselineKey][]byte, error) {
+	baselines := make(map[BaselineKey][]byte, len(d.series))
+	for key, stats := range d.series {
+		values := append(append([]float64{}, stats.values[stats.next:]...), stats.values[:stats.next]...)
+		data, err := json.Marshal(zscoreBaseline{Values: values})
+		if err != nil {
+			return nil, err
+		}
+		baselines[baselineKey(key, d.signalNames)] = data
+	}
+	return baselines, nil
+}
+
+// RestoreBaselines replaces series windows with persisted ones, keeping the
+// newest values if the window is now smaller
+func (d *ZScoreDetector) RestoreBaselines(baselines map[BaselineKey][]byte) error {
+	for key, data := range baselines {
+		series, ok := seriesKeyOf(key, d.signalNames)
+		if !ok {
+			continue
+		}
+		var baseline zscoreBaseline
+		if err := decodeBaseline(key, data, &baseline); err != nil {
+			return err
+		}
+		values := baseline.Values
+		if len(values) > d.window {
+			values = values[len(values)-d.window:]
+		}
+		stats := &rollingStats{values: make([]float64, 0, d.window)}
+		for _, v := range values {
+			stats.add(v, d.window)
+		}
+		d.series[series] = stats
+	}
+	return nil
+}
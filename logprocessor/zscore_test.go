@@ -0,0 +1,48 @@
+package logprocessor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestZScoreDetector(t *testing.T) {
+	// Mean 1 and population standard deviation sqrt(0.02) for Entropy;
+	// Levenshtein never varies, so it is never scored
+	trained := func() *ZScoreDetector {
+		d := NewZScoreDetector(10, 3, testSignals)
+		for round := 0; round < 2; round++ {
+			for _, v := range []float64{1, 1.2, 0.8, 1.1, 0.9} {
+				feed(t, d, vectorInput(v, 7))
+			}
+		}
+		return d
+	}
+
+	// 0.3 is 2.1 deviations from the mean
+	feed(t, trained(), vectorInput(1.3, 7))
+
+	d := trained()
+	detections := d.Detect(vectorInput(5, 70))
+	if len(detections) != 1 {
+		t.Fatalf("got %d detections, want 1: %+v", len(detections), detections)
+	}
+	got := detections[0]
+	if got.Detector != "zscore" || got.Signal != "Entropy" || got.Value != 5 || got.Samples != 10 {
+		t.Errorf("detection %+v", got)
+	}
+	if want := 4 / math.Sqrt(0.02); !near(got.Score, want, 1e-9) {
+		t.Errorf("score %v, want %v", got.Score, want)
+	}
+
+	// Other columns have series of their own
+	other := vectorInput(5, 70)
+	other.Column = "name"
+	feed(t, d, other)
+}
+
+func TestZScoreDetectorWaitsForHalfAWindow(t *testing.T) {
+	d := NewZScoreDetector(10, 3, testSignals)
+	feed(t, d, vectorInput(1, 0), vectorInput(2, 0), vectorInput(1, 0), vectorInput(2, 0))
+	// Four values of a window of ten are not enough to score
+	feed(t, d, vectorInput(100, 0))
+}
@@ -0,0 +1,385 @@
+package logsimulator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Actor behaviors
+const (
+	BehaviorNormal  = "normal"  // Application updates, edited as the run's EditConfig sets
+	BehaviorTamper  = "tamper"  // Plaintext replacement of one column, as an insider changing records
+	BehaviorEncrypt = "encrypt" // Updates encrypting the after-values, as ransomware
+	BehaviorNull    = "null"    // Updates setting one column to null, as a wiper
+	BehaviorDelete  = "delete"  // Row deletes, as a wiper
+	BehaviorDDL     = "ddl"     // TRUNCATE, DROP TABLE and ALTER COLUMN events
+)
+
+// Behaviors lists the actor behaviors
+var Behaviors = []string{BehaviorNormal, BehaviorTamper, BehaviorEncrypt, BehaviorNull, BehaviorDelete, BehaviorDDL}
+
+// DefaultActorRows is the number of rows per table actors pick from
+const DefaultActorRows = 1000
+
+// ActorScenario describes several actors changing the simulated tables at
+// the same time, as written in an actors file (YAML or JSON):
+//
+//	duration: 2h
+//	actors:
+//	  - name: app
+//	    behavior: normal
+//	    rate: 5
+//	  - name: insider
+//	    behavior: tamper
+//	    rate: 0.01
+//	    tables: [payments]
+//	    columns: [amount]
+//	  - name: ransomware
+//	    behavior: encrypt
+//	    rate: 20
+//	    start: 90m
+//	    cipher: AES-256-CBC
+//	    schedule: "0s:10,15m:100"
+//
+// Each actor's changes arrive at random at its rate, and the changes of all
+// actors are interleaved in simulated time into one log stream.
+type ActorScenario struct {
+	Start    time.Time     `json:"start,omitempty" yaml:"start,omitempty"` // Simulated start time; zero means now
+	Duration time.Duration `json:"duration" yaml:"duration"`               // Simulated length of the run
+	Rows     int           `json:"rows,omitempty" yaml:"rows,omitempty"`   // Rows per table actors pick from, unless the table sets its own (default DefaultActorRows)
+	Actors   []Actor       `json:"actors" yaml:"actors"`
+}
+
+// Actor is one source of changes in an ActorScenario. The logs of its attack
+// carry its name under AttackLabel: every change of tamper, null, delete and
+// ddl actors, and the encrypted updates of encrypt actors.
+type Actor struct {
+	Name        string        `json:"name" yaml:"name"`
+	Behavior    string        `json:"behavior" yaml:"behavior"`                             // One of Behaviors
+	Rate        float64       `json:"rate" yaml:"rate"`                                     // Average changes per simulated second
+	Tables      []string      `json:"tables,omitempty" yaml:"tables,omitempty"`             // Tables changed; empty means every table
+	Columns     []string      `json:"columns,omitempty" yaml:"columns,omitempty"`           // Columns changed; empty means every column
+	Start       time.Duration `json:"start,omitempty" yaml:"start,omitempty"`               // Offset from the start of the run when the actor begins
+	End         time.Duration `json:"end,omitempty" yaml:"end,omitempty"`                   // Offset when the actor stops; zero means the end of the run
+	Cipher      string        `json:"cipher,omitempty" yaml:"cipher,omitempty"`             // encrypt: AES-<bits>-<mode>, ChaCha20, XChaCha20 or an obfuscation (default AES-256-CBC)
+	Compress    bool          `json:"compress,omitempty" yaml:"compress,omitempty"`         // encrypt: gzip values before encrypting them
+	KeyRotation int           `json:"key_rotation,omitempty" yaml:"key_rotation,omitempty"` // encrypt: values after which the key is replaced by a new one
+	KeyReuse    string        `json:"key_reuse,omitempty" yaml:"key_reuse,omitempty"`       // encrypt: run (default) keeps the actor's key, value draws one per value
+	Encoding    string        `json:"encoding,omitempty" yaml:"encoding,omitempty"`         // encrypt: base64 (default), hex or raw ciphertext
+	Unprefixed  bool          `json:"unprefixed,omitempty" yaml:"unprefixed,omitempty"`     // encrypt: omit the cipher label of ciphertexts
+	Percentage  *int          `json:"percentage,omitempty" yaml:"percentage,omitempty"`     // encrypt: share of updates encrypted (default 100)
+	Schedule    string        `json:"schedule,omitempty" yaml:"schedule,omitempty"`         // encrypt: percentage by offset from the actor's start, replacing percentage
+}
+
+// LoadActorScenario reads and validates an actors file
+func LoadActorScenario(path string) (*ActorScenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var scenario ActorScenario
+	if err := yaml.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &scenario, nil
+}
+
+// Validate checks that the scenario has a duration and actors, and that
+// every actor has a unique name, a known behavior, a positive rate, an
+// active period within the run and valid encryption settings
+func (s *ActorScenario) Validate() error {
+	if s.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if s.Rows < 0 {
+		return fmt.Errorf("rows must not be negative")
+	}
+	if len(s.Actors) == 0 {
+		return fmt.Errorf("scenario has no actors")
+	}
+	names := make(map[string]bool)
+	for _, actor := range s.Actors {
+		if actor.Name == "" {
+			return fmt.Errorf("actor name is required")
+		}
+		if names[actor.Name] {
+			return fmt.Errorf("duplicate actor %q", actor.Name)
+		}
+		names[actor.Name] = true
+		if err := actor.validate(s.Duration); err != nil {
+			return fmt.Errorf("actor %q: %w", actor.Name, err)
+		}
+	}
+	return nil
+}
+
+func (a Actor) validate(duration time.Duration) error {
+	known := false
+	for _, behavior := range Behaviors {
+		known = known || a.Behavior == behavior
+	}
+	if !known {
+		return fmt.Errorf("unknown behavior %q (expected one of %s)", a.Behavior, strings.Join(Behaviors, ", "))
+	}
+	if a.Rate <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+	if a.Start < 0 || a.Start >= duration || (a.End != 0 && a.End <= a.Start) {
+		return fmt.Errorf("start and end must be within the duration, start first")
+	}
+	if a.Behavior != BehaviorEncrypt {
+		if a.Cipher != "" || a.Percentage != nil || a.Schedule != "" {
+			return fmt.Errorf("cipher, percentage and schedule only apply to encrypt actors")
+		}
+		return nil
+	}
+	if _, err := ParseCipher(a.Cipher); err != nil {
+		return err
+	}
+	if a.Percentage != nil && (*a.Percentage < 0 || *a.Percentage > 100) {
+		return fmt.Errorf("percentage must be between 0 and 100")
+	}
+	if a.KeyRotation < 0 {
+		return fmt.Errorf("key_rotation must not be negative")
+	}
+	if a.KeyReuse != "" && a.KeyReuse != KeyPerRun && a.KeyReuse != KeyPerValue {
+		return fmt.Errorf("unknown key_reuse %q (expected one of %s)", a.KeyReuse, strings.Join(KeyReuseModes, ", "))
+	}
+	if a.Encoding != "" && a.Encoding != EncodingBase64 && a.Encoding != EncodingHex && a.Encoding != EncodingRaw {
+		return fmt.Errorf("unknown encoding %q (expected one of %s)", a.Encoding, strings.Join(Encodings, ", "))
+	}
+	if a.Schedule != "" {
+		if _, err := ParseEncryptionSchedule(a.Schedule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseCipher parses a cipher name, AES-<bits>-<mode> (e.g. AES-128-GCM),
+// ChaCha20, XChaCha20 or an obfuscation (Base64, Hex or ROT13), into an
+// encryption config; empty selects AES-256-CBC
+func ParseCipher(name string) (EncryptionConfig, error) {
+	if name == "" {
+		name = "AES-256-CBC"
+	}
+	for _, other := range []EncryptionType{EncryptionTypeChaCha20, EncryptionType3DES, EncryptionTypeBlowfish, EncryptionTypeRC4, EncryptionTypeXOR, EncryptionTypeBase64, EncryptionTypeHex, EncryptionTypeROT13} {
+		if strings.EqualFold(name, string(other)) {
+			return EncryptionConfig{Type: other}, nil
+		}
+	}
+	if strings.EqualFold(name, ChaChaVariantX) {
+		return EncryptionConfig{Type: EncryptionTypeChaCha20, Variant: ChaChaVariantX}, nil
+	}
+	parts := strings.Split(strings.ToUpper(name), "-")
+	if len(parts) != 3 || parts[0] != "AES" {
+		return EncryptionConfig{}, fmt.Errorf("unknown cipher %q (expected AES-<bits>-<mode>, ChaCha20, XChaCha20, 3DES, Blowfish, RC4, XOR, Base64, Hex or ROT13)", name)
+	}
+	bits, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return EncryptionConfig{}, fmt.Errorf("unknown cipher %q: key size %q is not a number", name, parts[1])
+	}
+	config := EncryptionConfig{Type: EncryptionTypeAES, AESMode: parts[2], KeySize: bits / 8}
+	if _, err := GetEncryptor(config); err != nil {
+		return EncryptionConfig{}, fmt.Errorf("unknown cipher %q: %w", name, err)
+	}
+	return config, nil
+}
+
+// actorState is an actor's targets and the offset of its next change
+type actorState struct {
+	actor      Actor
+	tables     []int           // Indexes of the targeted schema tables
+	fields     [][]FieldConfig // Targeted fields of each targeted table
+	end        time.Duration
+	next       time.Duration
+	encConfig  EncryptionConfig
+	schedule   EncryptionSchedule
+	percentage int
+}
+
+// advance draws the offset of the actor's next change after at
+func (a *actorState) advance(at time.Duration) {
+	a.next = at + time.Duration(rand.ExpFloat64()/a.actor.Rate*float64(time.Second))
+}
+
+// StreamActorLogs generates the interleaved logs of the actors of scenario on
+// the tables of schema. editConfig applies to normal actors.
+func StreamActorLogs(ctx context.Context, dbType string, schema *Schema, scenario *ActorScenario, editConfig EditConfig) (<-chan interface{}, error) {
+	if len(schema.Tables) == 0 {
+		return nil, fmt.Errorf("schema has no tables")
+	}
+	rows := scenario.Rows
+	if rows == 0 {
+		rows = DefaultActorRows
+	}
+	start := scenario.Start
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	fields, err := schema.tableFields(rows)
+	if err != nil {
+		return nil, err
+	}
+	actors := make([]*actorState, len(scenario.Actors))
+	for i, actor := range scenario.Actors {
+		state, err := newActorState(actor, schema, fields, scenario.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("actor %q: %w", actor.Name, err)
+		}
+		state.advance(actor.Start)
+		actors[i] = state
+	}
+
+	logs := make(chan interface{}, 64)
+	go func() {
+		defer close(logs)
+		population := make(population)
+		for {
+			// The actor whose next change comes first acts
+			var a *actorState
+			for _, candidate := range actors {
+				if candidate.next < candidate.end && (a == nil || candidate.next < a.next) {
+					a = candidate
+				}
+			}
+			if a == nil {
+				return
+			}
+			at := a.next
+			a.advance(at)
+
+			i := rand.Intn(len(a.tables))
+			table, fields := schema.Tables[a.tables[i]], a.fields[i]
+			size := table.Rows
+			if size == 0 {
+				size = rows
+			}
+			rowID, current := population.pick(table.Name, size)
+			log, attack, hit := a.generate(dbType, table.Name, rowID, current, fields, at, editConfig)
+			if a.actor.Behavior == BehaviorDelete {
+				population.remove(table.Name, rowID)
+			}
+			if log == nil {
+				continue
+			}
+			setTimestamp(log, start.Add(at))
+			if attack {
+				setLabel(log, a.actor.Name, hit)
+			}
+			select {
+			case logs <- log:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return logs, nil
+}
+
+// newActorState resolves the tables and columns actor targets in schema,
+// whose tables have the field configurations tableFields
+func newActorState(actor Actor, schema *Schema, tableFields [][]FieldConfig, duration time.Duration) (*actorState, error) {
+	state := &actorState{actor: actor, end: duration, percentage: 100}
+	if actor.End != 0 && actor.End < duration {
+		state.end = actor.End
+	}
+	if actor.Behavior == BehaviorEncrypt {
+		state.encConfig, _ = ParseCipher(actor.Cipher)
+		state.encConfig.Compress = actor.Compress
+		state.encConfig.KeyRotation = actor.KeyRotation
+		state.encConfig.KeyReuse = actor.KeyReuse
+		state.encConfig.Encoding = actor.Encoding
+		state.encConfig.Unprefixed = actor.Unprefixed
+		// Every actor encrypts under keys of its own
+		state.encConfig.Keys = NewKeyring()
+		if actor.Percentage != nil {
+			state.percentage = *actor.Percentage
+		}
+		if actor.Schedule != "" {
+			state.schedule, _ = ParseEncryptionSchedule(actor.Schedule)
+		}
+	}
+
+	targeted := make(map[string]bool, len(actor.Tables))
+	for _, table := range actor.Tables {
+		targeted[table] = true
+	}
+	selected := make(map[string]bool, len(actor.Columns))
+	for _, column := range actor.Columns {
+		selected[column] = true
+	}
+	for i, table := range schema.Tables {
+		if len(targeted) > 0 && !targeted[table.Name] {
+			continue
+		}
+		delete(targeted, table.Name)
+		var fields []FieldConfig
+		for _, field := range tableFields[i] {
+			if len(selected) == 0 || selected[field.Name] {
+				fields = append(fields, field)
+			}
+		}
+		if len(fields) > 0 {
+			state.tables = append(state.tables, i)
+			state.fields = append(state.fields, fields)
+		}
+	}
+	if len(targeted) > 0 {
+		for _, table := range actor.Tables {
+			if targeted[table] {
+				return nil, fmt.Errorf("unknown table %q", table)
+			}
+		}
+	}
+	if len(state.tables) == 0 {
+		return nil, fmt.Errorf("no simulated table has the columns %s", strings.Join(actor.Columns, ", "))
+	}
+	return state, nil
+}
+
+// generate creates the actor's change of rowID, whose values are current,
+// at offset at, and reports whether it is labelled as an attack and which
+// fields the attack hit (empty for all the log changes)
+func (a *actorState) generate(dbType string, table string, rowID string, current map[string]interface{}, fields []FieldConfig, at time.Duration, editConfig EditConfig) (interface{}, bool, []string) {
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.Name
+	}
+	switch a.actor.Behavior {
+	case BehaviorNormal:
+		log, _ := generateLog(dbType, table, rowID, current, columns, fields, EncryptionConfig{Type: EncryptionTypeNone}, DDLConfig{}, editConfig)
+		return log, false, nil
+	case BehaviorTamper:
+		field := fields[rand.Intn(len(fields))]
+		log, _ := generateLog(dbType, table, rowID, current, []string{field.Name}, []FieldConfig{field}, EncryptionConfig{Type: EncryptionTypeNone}, DDLConfig{}, EditConfig{Mode: EditModeReplace})
+		return log, true, []string{field.Name}
+	case BehaviorEncrypt:
+		encConfig := a.encConfig
+		encConfig.Percentage = a.percentage
+		if len(a.schedule) > 0 {
+			encConfig.Percentage = a.schedule.Percentage(at - a.actor.Start)
+		}
+		log, encrypted := generateLog(dbType, table, rowID, current, columns, fields, encConfig, DDLConfig{}, editConfig)
+		return log, len(encrypted) > 0, encrypted
+	case BehaviorNull:
+		return generateNullLog(dbType, table, rowID, current, fields[rand.Intn(len(fields))]), true, nil
+	case BehaviorDelete:
+		return generateDeleteLog(dbType, table, rowID, current, columns, fields), true, nil
+	case BehaviorDDL:
+		log, _ := maybeDDL(dbType, table, columns, DDLConfig{Percentage: 100})
+		return log, true, nil
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,121 @@
+package logsimulator
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Defaults for the simulated clock
+const (
+	DefaultClockRate    = 1.0 // Events per second
+	DefaultBurstSize    = 100
+	DefaultBurstSpacing = 10 * time.Millisecond
+)
+
+// ClockConfig defines the simulated time of generated logs. Events arrive as
+// a Poisson process at Rate per second, following the business-hour activity
+// of diurnalActivity when Diurnal is set, and bursts of BurstSize events
+// BurstSpacing apart start at random BurstsPerHour times an hour.
+type ClockConfig struct {
+	Start         time.Time     // Time of the first event; zero means now
+	Rate          float64       // Average events per second, at peak hours when Diurnal (default DefaultClockRate)
+	Diurnal       bool          // Vary the rate with the hour of day and the weekday
+	BurstsPerHour float64       // Average bursts started per hour; zero disables bursts
+	BurstSize     int           // Events per burst (default DefaultBurstSize)
+	BurstSpacing  time.Duration // Time between the events of a burst (default DefaultBurstSpacing)
+}
+
+// Clock generates the timestamps of successive simulated events
+type Clock struct {
+	config    ClockConfig
+	now       time.Time
+	started   bool
+	nextBurst time.Time
+	inBurst   int // Events of the current burst still to come
+}
+
+// NewClock creates a clock, applying defaults to unset fields
+func NewClock(config ClockConfig) *Clock {
+	if config.Start.IsZero() {
+		config.Start = time.Now()
+	}
+	if config.Rate <= 0 {
+		config.Rate = DefaultClockRate
+	}
+	if config.BurstSize <= 0 {
+		config.BurstSize = DefaultBurstSize
+	}
+	if config.BurstSpacing <= 0 {
+		config.BurstSpacing = DefaultBurstSpacing
+	}
+	c := &Clock{config: config, now: config.Start}
+	c.nextBurst = c.drawBurst(config.Start)
+	return c
+}
+
+// Next returns the timestamp of the next event
+func (c *Clock) Next() time.Time {
+	if !c.started {
+		c.started = true
+		return c.now
+	}
+	if c.inBurst > 0 {
+		c.inBurst--
+		c.now = c.now.Add(c.config.BurstSpacing)
+		return c.now
+	}
+
+	arrival := c.arrival(c.now)
+	if !c.nextBurst.IsZero() && c.nextBurst.Before(arrival) {
+		c.now = c.nextBurst
+		c.inBurst = c.config.BurstSize - 1
+		c.nextBurst = c.drawBurst(c.now)
+		return c.now
+	}
+	c.now = arrival
+	return c.now
+}
+
+// arrival draws the next Poisson arrival after t. Diurnal rates are drawn by
+// thinning: candidates at the peak rate are kept with the hour's activity.
+func (c *Clock) arrival(t time.Time) time.Time {
+	for {
+		t = t.Add(exponential(c.config.Rate))
+		if !c.config.Diurnal || rand.Float64() < diurnalActivity(t) {
+			return t
+		}
+	}
+}
+
+// drawBurst draws the start of the next burst after t, or zero without bursts
+func (c *Clock) drawBurst(t time.Time) time.Time {
+	if c.config.BurstsPerHour <= 0 {
+		return time.Time{}
+	}
+	return t.Add(exponential(c.config.BurstsPerHour / 3600))
+}
+
+// exponential draws a Poisson inter-arrival time at rate per second
+func exponential(rate float64) time.Duration {
+	return time.Duration(rand.ExpFloat64() / rate * float64(time.Second))
+}
+
+// diurnalActivity is the share of the peak rate at t: full during business
+// hours on weekdays, half at their edges, a tenth at night and a fifth on
+// weekend days
+func diurnalActivity(t time.Time) float64 {
+	hour := t.Hour()
+	night := hour < 7 || hour >= 20
+	switch {
+	case t.Weekday() == time.Saturday || t.Weekday() == time.Sunday:
+		if night {
+			return 0.1
+		}
+		return 0.2
+	case hour >= 9 && hour < 17:
+		return 1
+	case night:
+		return 0.1
+	}
+	return 0.5
+}
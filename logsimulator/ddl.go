@@ -0,0 +1,87 @@
+package logsimulator
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Simulated DDL statements
+const (
+	DDLTruncate    = "TRUNCATE"
+	DDLDropTable   = "DROP TABLE"
+	DDLAlterColumn = "ALTER COLUMN"
+)
+
+// DDLOperations lists the DDL statements the simulator can generate
+var DDLOperations = []string{DDLTruncate, DDLDropTable, DDLAlterColumn}
+
+// DDLConfig defines how often simulated changes are destructive schema
+// changes, as ransomware and wipers mix them with encryption
+type DDLConfig struct {
+	Percentage int      // Chance of each log being a DDL event instead of an update
+	Operations []string // Statements to choose from; empty selects all of DDLOperations
+}
+
+// GenerateOracleDDLLog creates a mock Oracle audit entry for a DDL statement.
+// Actions use Oracle's names (TRUNCATE TABLE, ALTER TABLE); the altered
+// column, if any, is listed in changed_columns.
+func GenerateOracleDDLLog(table string, action string, columns []string, statement string) map[string]interface{} {
+	return map[string]interface{}{
+		"action":          action,
+		"table_name":      table,
+		"changed_columns": columns,
+		"timestamp":       time.Now(),
+		"sql_text":        statement,
+	}
+}
+
+// GeneratePostgresDDLLog creates a mock PostgreSQL entry for a DDL statement
+func GeneratePostgresDDLLog(table string, operation string, columns []string, statement string) map[string]interface{} {
+	return map[string]interface{}{
+		"operation":       operation,
+		"table":           table,
+		"changed_columns": columns,
+		"timestamp":       time.Now(),
+		"statement":       statement,
+	}
+}
+
+// maybeDDL generates a DDL log in place of an update with the configured
+// chance, reporting false when an update should be generated
+func maybeDDL(dbType string, table string, columns []string, config DDLConfig) (interface{}, bool) {
+	if config.Percentage <= 0 || (config.Percentage < 100 && rand.Intn(100) >= config.Percentage) {
+		return nil, false
+	}
+	operations := config.Operations
+	if len(operations) == 0 {
+		operations = DDLOperations
+	}
+
+	var statement, action string
+	var altered []string
+	switch operation := operations[rand.Intn(len(operations))]; operation {
+	case DDLTruncate:
+		statement, action = fmt.Sprintf("TRUNCATE TABLE %s", table), "TRUNCATE TABLE"
+	case DDLDropTable:
+		statement, action = fmt.Sprintf("DROP TABLE %s", table), "DROP TABLE"
+	case DDLAlterColumn:
+		if len(columns) == 0 {
+			return nil, false
+		}
+		column := columns[rand.Intn(len(columns))]
+		altered = []string{column}
+		statement, action = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE bytea", table, column), "ALTER TABLE"
+	default:
+		return nil, false
+	}
+
+	if dbType == "oracle" {
+		return GenerateOracleDDLLog(table, action, altered, statement), true
+	}
+	// PostgreSQL reports TRUNCATE without TABLE
+	if action == "TRUNCATE TABLE" {
+		action = DDLTruncate
+	}
+	return GeneratePostgresDDLLog(table, action, altered, statement), true
+}
@@ -0,0 +1,106 @@
+package logsimulator
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// DeliveryConfig defines how a stream's logs are delivered, as CDC
+// pipelines with at-least-once delivery reorder and repeat events
+type DeliveryConfig struct {
+	Jitter              time.Duration // Largest delay of a log's delivery; logs less than this apart can arrive out of order
+	DuplicatePercentage int           // Chance of each log being delivered twice
+}
+
+// delivery is a log waiting to be delivered at a simulated time
+type delivery struct {
+	at  time.Time
+	log interface{}
+}
+
+// Deliver passes on the logs of stream as config sets: each log is delayed by
+// a random time up to Jitter after its timestamp and delivered in order of
+// the delayed times, so logs close together can swap places, and duplicated
+// logs are delivered again after a delay of their own. The logs keep their
+// timestamps. Logs without a timestamp are delivered as they come.
+func Deliver(ctx context.Context, stream <-chan interface{}, config DeliveryConfig) <-chan interface{} {
+	if config.Jitter <= 0 && config.DuplicatePercentage <= 0 {
+		return stream
+	}
+
+	logs := make(chan interface{}, 64)
+	go func() {
+		defer close(logs)
+		var pending []delivery // Sorted by delivery time
+		add := func(log interface{}, ts time.Time) {
+			at := ts
+			if config.Jitter > 0 {
+				at = ts.Add(time.Duration(rand.Int63n(int64(config.Jitter))))
+			}
+			i := sort.Search(len(pending), func(i int) bool { return pending[i].at.After(at) })
+			pending = append(pending, delivery{})
+			copy(pending[i+1:], pending[i:])
+			pending[i] = delivery{at: at, log: log}
+		}
+		// flush delivers the pending logs due before until
+		flush := func(until time.Time, all bool) bool {
+			for len(pending) > 0 && (all || pending[0].at.Before(until)) {
+				select {
+				case logs <- pending[0].log:
+				case <-ctx.Done():
+					return false
+				}
+				pending = pending[1:]
+			}
+			return true
+		}
+
+		for log := range stream {
+			ts, ok := timestamp(log)
+			if !ok {
+				select {
+				case logs <- log:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			// Later logs are delivered no earlier than their timestamp, so
+			// logs due before this one's can go
+			if !flush(ts, false) {
+				return
+			}
+			add(log, ts)
+			if config.DuplicatePercentage >= 100 || (config.DuplicatePercentage > 0 && rand.Intn(100) < config.DuplicatePercentage) {
+				add(duplicate(log), ts)
+			}
+		}
+		flush(time.Time{}, true)
+	}()
+	return logs
+}
+
+// timestamp returns the timestamp of a simulated log
+func timestamp(log interface{}) (time.Time, bool) {
+	if m, ok := log.(map[string]interface{}); ok {
+		ts, ok := m["timestamp"].(time.Time)
+		return ts, ok
+	}
+	return time.Time{}, false
+}
+
+// duplicate returns a copy of a log, so later changes to either leave the
+// other as delivered
+func duplicate(log interface{}) interface{} {
+	m, ok := log.(map[string]interface{})
+	if !ok {
+		return log
+	}
+	copied := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
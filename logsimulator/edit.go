@@ -0,0 +1,243 @@
+package logsimulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EditMode selects how simulated after-values relate to the before-values
+type EditMode string
+
+// Edit modes
+const (
+	EditModeReplace EditMode = "replace" // A fresh fake value, so every update is a total replacement
+	EditModeBenign  EditMode = "benign"  // A small realistic mutation of the before-value
+)
+
+// EditConfig defines how unencrypted after-values are generated. Benign
+// edits make the signals of normal traffic look like real updates, whose
+// before and after values are mostly alike, instead of unrelated values.
+// Real updates also change only a column or two: fields left unchanged keep
+// their before-value and are not listed as changed.
+type EditConfig struct {
+	Mode               EditMode           // Empty means EditModeReplace
+	ChangeProbability  float64            // Chance of each field changing in an update; zero means always
+	FieldProbabilities map[string]float64 // Chances of fields by column or table.column name, replacing ChangeProbability
+	Correlations       []FieldCorrelation // Fields changing together, applied in order after the chances are drawn
+}
+
+// FieldCorrelation makes fields change together, so multi-column and
+// row-level signals see realistic co-occurrence. When any of Fields changes
+// in an update, each unchanged field of Then changes too with Probability;
+// without Then, Fields form a group whose members follow each other.
+//
+//	correlations:
+//	  - fields: [address, phone]    # move house: both change
+//	  - fields: [email]
+//	    then: [bio]                 # a new email sometimes comes with a new bio
+//	    probability: 0.3
+type FieldCorrelation struct {
+	Table       string   `json:"table,omitempty" yaml:"table,omitempty"` // Table the correlation applies to; empty means every table
+	Fields      []string `json:"fields" yaml:"fields"`
+	Then        []string `json:"then,omitempty" yaml:"then,omitempty"`
+	Probability float64  `json:"probability,omitempty" yaml:"probability,omitempty"` // Chance of each field following; zero means always
+}
+
+// DefaultCorrelations relate the default fields: addresses and phone
+// numbers change together, and a new email sometimes comes with a new bio
+var DefaultCorrelations = []FieldCorrelation{
+	{Fields: []string{"address", "phone"}},
+	{Fields: []string{"email"}, Then: []string{"bio"}, Probability: 0.3},
+}
+
+// Validate checks that the correlation names fields and has a probability
+// between 0 and 1
+func (c FieldCorrelation) Validate() error {
+	if len(c.Fields) == 0 {
+		return fmt.Errorf("correlation has no fields")
+	}
+	if len(c.Then) == 0 && len(c.Fields) < 2 {
+		return fmt.Errorf("correlation of %s needs another field or then", strings.Join(c.Fields, ", "))
+	}
+	if c.Probability < 0 || c.Probability > 1 {
+		return fmt.Errorf("correlation of %s: probability must be between 0 and 1", strings.Join(c.Fields, ", "))
+	}
+	return nil
+}
+
+// follow returns the unchanged fields following the changed fields of an
+// update of table
+func (c FieldCorrelation) follow(table string, changed map[string]bool) []string {
+	if c.Table != "" && c.Table != table {
+		return nil
+	}
+	triggered := false
+	for _, name := range c.Fields {
+		triggered = triggered || changed[name]
+	}
+	if !triggered {
+		return nil
+	}
+	targets := c.Then
+	if len(targets) == 0 {
+		targets = c.Fields
+	}
+	var following []string
+	for _, name := range targets {
+		if !changed[name] && (c.Probability <= 0 || rand.Float64() < c.Probability) {
+			following = append(following, name)
+		}
+	}
+	return following
+}
+
+// changes draws whether column of table changes in an update
+func (c EditConfig) changes(table string, column string) bool {
+	p, ok := c.FieldProbabilities[table+"."+column]
+	if !ok {
+		p, ok = c.FieldProbabilities[column]
+	}
+	if !ok {
+		p = c.ChangeProbability
+		if p <= 0 {
+			return true
+		}
+	}
+	return rand.Float64() < p
+}
+
+// generateAfter generates the after-value of field for before
+func generateAfter(field FieldConfig, before string, config EditConfig) string {
+	if config.Mode == EditModeBenign && field.Reference == "" {
+		return BenignEdit(before)
+	}
+	return field.Generator()
+}
+
+// BenignEdit applies a small realistic mutation to value, as a user
+// correcting a record would: an email moves to another domain, a date
+// shifts by a few days, a JSON document changes one value, and other
+// values get a typo fixed, a letter case changed or a digit adjusted.
+func BenignEdit(value string) string {
+	if value == "" {
+		return value
+	}
+	if edited, ok := editJSON(value); ok {
+		return edited
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		days := rand.Intn(30) + 1
+		if rand.Intn(2) == 0 {
+			days = -days
+		}
+		return t.AddDate(0, 0, days).Format(time.RFC3339)
+	}
+	if at := strings.LastIndex(value, "@"); at > 0 && at < len(value)-1 && rand.Intn(2) == 0 {
+		return value[:at+1] + emailDomains[rand.Intn(len(emailDomains))]
+	}
+
+	edits := []func(string) (string, bool){swapLetters, changeCase, changeDigit}
+	rand.Shuffle(len(edits), func(i, j int) { edits[i], edits[j] = edits[j], edits[i] })
+	for _, edit := range edits {
+		if edited, ok := edit(value); ok {
+			return edited
+		}
+	}
+	return value
+}
+
+var emailDomains = []string{"gmail.com", "outlook.com", "yahoo.com", "icloud.com", "proton.me", "example.org"}
+
+// swapLetters swaps two adjacent letters, as fixing a transposition typo does
+func swapLetters(value string) (string, bool) {
+	runes := []rune(value)
+	var candidates []int
+	for i := 0; i+1 < len(runes); i++ {
+		if unicode.IsLetter(runes[i]) && unicode.IsLetter(runes[i+1]) && runes[i] != runes[i+1] {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return value, false
+	}
+	i := candidates[rand.Intn(len(candidates))]
+	runes[i], runes[i+1] = runes[i+1], runes[i]
+	return string(runes), true
+}
+
+// changeCase flips the case of the first letter of one word
+func changeCase(value string) (string, bool) {
+	runes := []rune(value)
+	var starts []int
+	for i, r := range runes {
+		if unicode.IsLetter(r) && (i == 0 || !unicode.IsLetter(runes[i-1])) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) == 0 {
+		return value, false
+	}
+	i := starts[rand.Intn(len(starts))]
+	if unicode.IsUpper(runes[i]) {
+		runes[i] = unicode.ToLower(runes[i])
+	} else {
+		runes[i] = unicode.ToUpper(runes[i])
+	}
+	return string(runes), true
+}
+
+// changeDigit replaces one digit with another
+func changeDigit(value string) (string, bool) {
+	runes := []rune(value)
+	var digits []int
+	for i, r := range runes {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, i)
+		}
+	}
+	if len(digits) == 0 {
+		return value, false
+	}
+	i := digits[rand.Intn(len(digits))]
+	runes[i] = '0' + (runes[i]-'0'+rune(rand.Intn(9))+1)%10
+	return string(runes), true
+}
+
+// editJSON edits one string or number of a JSON object, keeping it valid
+func editJSON(value string) (string, bool) {
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		return value, false
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &doc); err != nil || len(doc) == 0 {
+		return value, false
+	}
+	var keys []string
+	for key, v := range doc {
+		switch v.(type) {
+		case string, float64:
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return value, true
+	}
+	sort.Strings(keys)
+	key := keys[rand.Intn(len(keys))]
+	switch v := doc[key].(type) {
+	case string:
+		doc[key] = BenignEdit(v)
+	case float64:
+		doc[key] = v + float64(rand.Intn(10)+1)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return value, false
+	}
+	return string(data), true
+}
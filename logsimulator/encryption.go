@@ -2,14 +2,20 @@ package logsimulator
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/des"
 	crypto_rand "crypto/rand"
+	"crypto/rc4"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"math/rand"
+	"strings"
 
+	"golang.org/x/crypto/blowfish"
 	"golang.org/x/crypto/chacha20poly1305"
 )
 
@@ -20,20 +26,74 @@ const (
 	EncryptionTypeNone     EncryptionType = "None"
 	EncryptionTypeAES      EncryptionType = "AES"
 	EncryptionTypeChaCha20 EncryptionType = "ChaCha20"
+
+	// EncryptionTypeXOR is a weak cipher XORing values with a short
+	// repeating key, leaving the structure of the plaintext measurable
+	EncryptionTypeXOR EncryptionType = "XOR"
+
+	// Legacy ciphers still used by older ransomware families and embedded
+	// systems, with 8-byte blocks or no blocks at all
+	EncryptionType3DES     EncryptionType = "3DES"
+	EncryptionTypeBlowfish EncryptionType = "Blowfish"
+	EncryptionTypeRC4      EncryptionType = "RC4"
+
+	// Obfuscations reversibly scramble values without a key, evading
+	// detectors that only look for high entropy
+	EncryptionTypeBase64 EncryptionType = "Base64"
+	EncryptionTypeHex    EncryptionType = "Hex"
+	EncryptionTypeROT13  EncryptionType = "ROT13"
+)
+
+// ChaCha20 variants of EncryptionConfig
+const (
+	ChaChaVariantIETF = "ChaCha20"  // 96-bit nonces, as in RFC 8439
+	ChaChaVariantX    = "XChaCha20" // 192-bit nonces, safe to draw at random
 )
 
+// Ciphertext encodings of EncryptionConfig
+const (
+	EncodingBase64 = "base64"
+	EncodingHex    = "hex"
+	EncodingRaw    = "raw" // One character per byte, U+0000 to U+00FF, so binary survives JSON
+)
+
+// Encodings lists the accepted ciphertext encodings
+var Encodings = []string{EncodingBase64, EncodingHex, EncodingRaw}
+
 // EncryptionConfig defines the configuration for encryption simulation
 type EncryptionConfig struct {
-	Type       EncryptionType
-	Percentage int
-	AESMode    string // New field for AES mode (CBC, CTR, GCM)
-	KeySize    int    // Key size in bytes (16, 24, 32 for AES)
+	Type        EncryptionType
+	Percentage  int
+	AESMode     string   // New field for AES mode (CBC, CTR, GCM, ECB)
+	KeySize     int      // Key size in bytes (16, 24, 32 for AES)
+	Variant     string   // ChaCha20 variant: ChaChaVariantIETF (default) or ChaChaVariantX
+	NonceReuse  bool     // ChaCha20: encrypt every value of a key under one nonce, so equal values encrypt equally
+	Compress    bool     // Gzip values before encrypting them, as attackers do to encrypt faster
+	Prefix      string   // Marker prepended to encrypted values
+	Suffix      string   // Extension appended to encrypted values
+	Encoding    string   // Encoding of cipher output: EncodingBase64 (default), EncodingHex or EncodingRaw
+	Unprefixed  bool     // Omit the cipher label such as AES-256-GCM: that real attackers don't add
+	KeyRotation int      // Encrypted values after which the run's key is replaced by a new one; zero keeps it
+	KeyReuse    string   // KeyPerRun (default) or KeyPerValue
+	Keys        *Keyring // Encryptors reused across values; nil means the run's shared keyring
+
+	// FieldPercentages targets encryption at single fields, by column or
+	// table.column name: only they are encrypted, each with its percentage
+	// of Percentage, so 100 follows it and 50 encrypts half as often
+	FieldPercentages map[string]int
+
+	// Previous is the cipher that already encrypted the values, which are
+	// encrypted again in place: fresh values are generated encrypted with
+	// it, and the current value is encrypted rather than an edit of it
+	Previous *EncryptionConfig
 }
 
 // Encryptor defines the interface for encryption implementations
 type Encryptor interface {
 	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
 	Type() EncryptionType
+	Key() []byte // nil for keyless encodings
 }
 
 // GetEncryptor returns the appropriate encryptor based on the type and configuration
@@ -60,16 +120,86 @@ func GetEncryptor(config EncryptionConfig) (Encryptor, error) {
 			return NewAESCTREncryptor(config.KeySize)
 		case "GCM":
 			return NewAESGCMEncryptor(config.KeySize)
+		case "ECB":
+			return NewAESECBEncryptor(config.KeySize)
 		default:
 			return nil, fmt.Errorf("unsupported AES mode: %s", config.AESMode)
 		}
 	case EncryptionTypeChaCha20:
-		return NewChaCha20Encryptor()
+		extended, err := chachaExtended(config.Variant)
+		if err != nil {
+			return nil, err
+		}
+		return NewChaCha20Encryptor(extended, config.NonceReuse)
+	case EncryptionTypeXOR:
+		return NewXOREncryptor(XORKeySize)
+	case EncryptionType3DES:
+		return NewTripleDESEncryptor()
+	case EncryptionTypeBlowfish:
+		return NewBlowfishEncryptor()
+	case EncryptionTypeRC4:
+		return NewRC4Encryptor()
+	case EncryptionTypeBase64, EncryptionTypeHex, EncryptionTypeROT13:
+		return &ObfuscationEncryptor{obfuscation: config.Type}, nil
 	default:
 		return nil, fmt.Errorf("unsupported encryption type: %s", config.Type)
 	}
 }
 
+// EncryptorWithKey returns the encryptor of config's cipher under key, such
+// as one recovered from a key escrow, to decrypt the values it encrypted
+func EncryptorWithKey(config EncryptionConfig, key []byte) (Encryptor, error) {
+	switch config.Type {
+	case EncryptionTypeAES:
+		switch config.AESMode {
+		case "CBC", "":
+			return &AESCBCEncryptor{key: key}, nil
+		case "CTR":
+			return &AESCTREncryptor{key: key}, nil
+		case "GCM":
+			return &AESGCMEncryptor{key: key}, nil
+		case "ECB":
+			return &AESECBEncryptor{key: key}, nil
+		default:
+			return nil, fmt.Errorf("unsupported AES mode: %s", config.AESMode)
+		}
+	case EncryptionTypeChaCha20:
+		extended, err := chachaExtended(config.Variant)
+		if err != nil {
+			return nil, err
+		}
+		return &ChaCha20Encryptor{key: key, extended: extended}, nil
+	case EncryptionTypeXOR:
+		return &XOREncryptor{key: key}, nil
+	case EncryptionType3DES:
+		return &TripleDESEncryptor{key: key}, nil
+	case EncryptionTypeBlowfish:
+		return &BlowfishEncryptor{key: key}, nil
+	case EncryptionTypeRC4:
+		return &RC4Encryptor{key: key}, nil
+	}
+	// Keyless encodings
+	return GetEncryptor(config)
+}
+
+// CipherName returns the name of config's cipher as accepted by ParseCipher
+func CipherName(config EncryptionConfig) string {
+	if config.Type == EncryptionTypeChaCha20 && config.Variant == ChaChaVariantX {
+		return ChaChaVariantX
+	}
+	if config.Type != EncryptionTypeAES {
+		return string(config.Type)
+	}
+	keySize, mode := config.KeySize, config.AESMode
+	if keySize == 0 {
+		keySize = 32
+	}
+	if mode == "" {
+		mode = "CBC"
+	}
+	return fmt.Sprintf("AES-%d-%s", keySize*8, mode)
+}
+
 // NoneEncryptor is a pass-through implementation that does no encryption
 type NoneEncryptor struct{}
 
@@ -81,6 +211,14 @@ func (e *NoneEncryptor) Type() EncryptionType {
 	return EncryptionTypeNone
 }
 
+func (e *NoneEncryptor) Decrypt(ciphertext string) (string, error) {
+	return ciphertext, nil
+}
+
+func (e *NoneEncryptor) Key() []byte {
+	return nil
+}
+
 //-------------------- AES CBC Implementation --------------------
 
 // AESCBCEncryptor implements AES-CBC encryption with PKCS#7 padding
@@ -133,6 +271,23 @@ func (e *AESCBCEncryptor) Type() EncryptionType {
 	return EncryptionTypeAES
 }
 
+func (e *AESCBCEncryptor) Decrypt(ciphertext string) (string, error) {
+	data, err := decodeCiphertext(ciphertext, fmt.Sprintf("AES-%d-CBC:", len(e.key)*8))
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := decryptCBC(block, data)
+	return string(plaintext), err
+}
+
+func (e *AESCBCEncryptor) Key() []byte {
+	return e.key
+}
+
 //-------------------- AES CTR Implementation --------------------
 
 // AESCTREncryptor implements AES-CTR (Counter Mode) encryption
@@ -181,6 +336,27 @@ func (e *AESCTREncryptor) Type() EncryptionType {
 	return EncryptionTypeAES
 }
 
+func (e *AESCTREncryptor) Decrypt(ciphertext string) (string, error) {
+	data, err := decodeCiphertext(ciphertext, fmt.Sprintf("AES-%d-CTR:", len(e.key)*8))
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aes.BlockSize {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+	plaintext := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCTR(block, data[:aes.BlockSize]).XORKeyStream(plaintext, data[aes.BlockSize:])
+	return string(plaintext), nil
+}
+
+func (e *AESCTREncryptor) Key() []byte {
+	return e.key
+}
+
 //-------------------- AES GCM Implementation --------------------
 
 // AESGCMEncryptor implements AES-GCM (Galois/Counter Mode) authenticated encryption
@@ -235,51 +411,466 @@ func (e *AESGCMEncryptor) Type() EncryptionType {
 	return EncryptionTypeAES
 }
 
+func (e *AESGCMEncryptor) Decrypt(ciphertext string) (string, error) {
+	data, err := decodeCiphertext(ciphertext, fmt.Sprintf("AES-%d-GCM:", len(e.key)*8))
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	return openAEAD(aead, data)
+}
+
+func (e *AESGCMEncryptor) Key() []byte {
+	return e.key
+}
+
+//-------------------- AES ECB Implementation --------------------
+
+// AESECBEncryptor implements AES-ECB encryption with PKCS#7 padding. Blocks
+// are encrypted independently without an IV, so identical plaintexts
+// produce identical ciphertexts under the same key, as in lazily written
+// ransomware.
+type AESECBEncryptor struct {
+	key []byte
+}
+
+// NewAESECBEncryptor creates a new AES-ECB encryptor with a random key of specified size
+func NewAESECBEncryptor(keySize int) (*AESECBEncryptor, error) {
+	key, err := randomKey(keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AESECBEncryptor{key: key}, nil
+}
+
+func (e *AESECBEncryptor) Encrypt(plaintext string) (string, error) {
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+
+	// Encrypt each block on its own
+	paddedPlaintext := padPKCS7([]byte(plaintext), aes.BlockSize)
+	ciphertext := make([]byte, len(paddedPlaintext))
+	for i := 0; i < len(paddedPlaintext); i += aes.BlockSize {
+		block.Encrypt(ciphertext[i:i+aes.BlockSize], paddedPlaintext[i:i+aes.BlockSize])
+	}
+
+	// Base64 encode for storage
+	encoded := base64.StdEncoding.EncodeToString(ciphertext)
+
+	// Include key size in the prefix
+	keyBits := len(e.key) * 8
+	return fmt.Sprintf("AES-%d-ECB:%s", keyBits, encoded), nil
+}
+
+func (e *AESECBEncryptor) Type() EncryptionType {
+	return EncryptionTypeAES
+}
+
+func (e *AESECBEncryptor) Decrypt(ciphertext string) (string, error) {
+	data, err := decodeCiphertext(ciphertext, fmt.Sprintf("AES-%d-ECB:", len(e.key)*8))
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return "", fmt.Errorf("ciphertext is not a multiple of the block size")
+	}
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+	plaintext := make([]byte, len(data))
+	for i := 0; i < len(data); i += aes.BlockSize {
+		block.Decrypt(plaintext[i:i+aes.BlockSize], data[i:i+aes.BlockSize])
+	}
+	unpadded, err := unpadPKCS7(plaintext, aes.BlockSize)
+	return string(unpadded), err
+}
+
+func (e *AESECBEncryptor) Key() []byte {
+	return e.key
+}
+
 //-------------------- ChaCha20 Implementation --------------------
 
-// ChaCha20Encryptor implements ChaCha20-Poly1305 encryption
+// ChaCha20Encryptor implements ChaCha20-Poly1305 encryption, or
+// XChaCha20-Poly1305 with extended nonces
 type ChaCha20Encryptor struct {
-	key []byte
+	key      []byte
+	extended bool
+	nonce    []byte // Nonce of every value when reused; nil draws one per value
 }
 
-// NewChaCha20Encryptor creates a new ChaCha20 encryptor with a random key
-func NewChaCha20Encryptor() (*ChaCha20Encryptor, error) {
+// NewChaCha20Encryptor creates a new ChaCha20 encryptor with a random key,
+// using XChaCha20 if extended and a single random nonce if nonceReuse
+func NewChaCha20Encryptor(extended, nonceReuse bool) (*ChaCha20Encryptor, error) {
 	// Generate a random 32-byte key for ChaCha20-Poly1305
 	key := make([]byte, chacha20poly1305.KeySize)
 	if _, err := io.ReadFull(crypto_rand.Reader, key); err != nil {
 		return nil, err
 	}
 
-	return &ChaCha20Encryptor{key: key}, nil
+	e := &ChaCha20Encryptor{key: key, extended: extended}
+	if nonceReuse {
+		aead, err := e.aead()
+		if err != nil {
+			return nil, err
+		}
+		if e.nonce, err = randomKey(aead.NonceSize()); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// chachaExtended reports whether a ChaCha20 variant uses extended nonces
+func chachaExtended(variant string) (bool, error) {
+	switch variant {
+	case ChaChaVariantIETF, "":
+		return false, nil
+	case ChaChaVariantX:
+		return true, nil
+	}
+	return false, fmt.Errorf("unsupported ChaCha20 variant: %s", variant)
+}
+
+// aead returns the AEAD of the encryptor's variant
+func (e *ChaCha20Encryptor) aead() (cipher.AEAD, error) {
+	if e.extended {
+		return chacha20poly1305.NewX(e.key)
+	}
+	return chacha20poly1305.New(e.key)
+}
+
+// label is the prefix of the encryptor's ciphertexts
+func (e *ChaCha20Encryptor) label() string {
+	if e.extended {
+		return ChaChaVariantX + ":"
+	}
+	return "ChaCha20:"
 }
 
 func (e *ChaCha20Encryptor) Encrypt(plaintext string) (string, error) {
-	aead, err := chacha20poly1305.New(e.key)
+	aead, err := e.aead()
 	if err != nil {
 		return "", err
 	}
 
-	// Generate a random nonce
-	nonce := make([]byte, aead.NonceSize())
-	if _, err := io.ReadFull(crypto_rand.Reader, nonce); err != nil {
-		return "", err
+	// Generate a random nonce, unless one is reused
+	nonce := e.nonce
+	if nonce == nil {
+		nonce = make([]byte, aead.NonceSize())
+		if _, err := io.ReadFull(crypto_rand.Reader, nonce); err != nil {
+			return "", err
+		}
 	}
 
 	// Encrypt the plaintext
 	ciphertext := aead.Seal(nil, nonce, []byte(plaintext), nil)
 
 	// Prepend nonce to ciphertext for decryption later
-	result := append(nonce, ciphertext...)
+	result := append(append([]byte(nil), nonce...), ciphertext...)
 
 	// Base64 encode for storage
 	encoded := base64.StdEncoding.EncodeToString(result)
-	return fmt.Sprintf("ChaCha20:%s", encoded), nil
+	return e.label() + encoded, nil
 }
 
 func (e *ChaCha20Encryptor) Type() EncryptionType {
 	return EncryptionTypeChaCha20
 }
 
+func (e *ChaCha20Encryptor) Decrypt(ciphertext string) (string, error) {
+	data, err := decodeCiphertext(ciphertext, e.label())
+	if err != nil {
+		return "", err
+	}
+	aead, err := e.aead()
+	if err != nil {
+		return "", err
+	}
+	return openAEAD(aead, data)
+}
+
+func (e *ChaCha20Encryptor) Key() []byte {
+	return e.key
+}
+
+//-------------------- Legacy Implementations --------------------
+
+// Key sizes in bytes of the legacy ciphers
+const (
+	TripleDESKeySize = 24
+	BlowfishKeySize  = 16
+	RC4KeySize       = 16
+)
+
+// TripleDESEncryptor implements 3DES-CBC encryption with PKCS#7 padding
+type TripleDESEncryptor struct {
+	key []byte
+}
+
+// NewTripleDESEncryptor creates a new 3DES encryptor with a random key
+func NewTripleDESEncryptor() (*TripleDESEncryptor, error) {
+	key, err := randomKey(TripleDESKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TripleDESEncryptor{key: key}, nil
+}
+
+func (e *TripleDESEncryptor) Encrypt(plaintext string) (string, error) {
+	block, err := des.NewTripleDESCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := encryptCBC(block, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("3DES-CBC:%s", base64.StdEncoding.EncodeToString(ciphertext)), nil
+}
+
+func (e *TripleDESEncryptor) Type() EncryptionType {
+	return EncryptionType3DES
+}
+
+func (e *TripleDESEncryptor) Decrypt(ciphertext string) (string, error) {
+	data, err := decodeCiphertext(ciphertext, "3DES-CBC:")
+	if err != nil {
+		return "", err
+	}
+	block, err := des.NewTripleDESCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := decryptCBC(block, data)
+	return string(plaintext), err
+}
+
+func (e *TripleDESEncryptor) Key() []byte {
+	return e.key
+}
+
+// BlowfishEncryptor implements Blowfish-CBC encryption with PKCS#7 padding
+type BlowfishEncryptor struct {
+	key []byte
+}
+
+// NewBlowfishEncryptor creates a new Blowfish encryptor with a random key
+func NewBlowfishEncryptor() (*BlowfishEncryptor, error) {
+	key, err := randomKey(BlowfishKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlowfishEncryptor{key: key}, nil
+}
+
+func (e *BlowfishEncryptor) Encrypt(plaintext string) (string, error) {
+	block, err := blowfish.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := encryptCBC(block, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Blowfish-CBC:%s", base64.StdEncoding.EncodeToString(ciphertext)), nil
+}
+
+func (e *BlowfishEncryptor) Type() EncryptionType {
+	return EncryptionTypeBlowfish
+}
+
+func (e *BlowfishEncryptor) Decrypt(ciphertext string) (string, error) {
+	data, err := decodeCiphertext(ciphertext, "Blowfish-CBC:")
+	if err != nil {
+		return "", err
+	}
+	block, err := blowfish.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := decryptCBC(block, data)
+	return string(plaintext), err
+}
+
+func (e *BlowfishEncryptor) Key() []byte {
+	return e.key
+}
+
+// RC4Encryptor implements RC4 stream encryption. RC4 takes no IV, so the
+// ciphertext has exactly the length of the plaintext.
+type RC4Encryptor struct {
+	key []byte
+}
+
+// NewRC4Encryptor creates a new RC4 encryptor with a random key
+func NewRC4Encryptor() (*RC4Encryptor, error) {
+	key, err := randomKey(RC4KeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RC4Encryptor{key: key}, nil
+}
+
+func (e *RC4Encryptor) Encrypt(plaintext string) (string, error) {
+	stream, err := rc4.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext := make([]byte, len(plaintext))
+	stream.XORKeyStream(ciphertext, []byte(plaintext))
+	return fmt.Sprintf("RC4:%s", base64.StdEncoding.EncodeToString(ciphertext)), nil
+}
+
+func (e *RC4Encryptor) Type() EncryptionType {
+	return EncryptionTypeRC4
+}
+
+func (e *RC4Encryptor) Decrypt(ciphertext string) (string, error) {
+	data, err := decodeCiphertext(ciphertext, "RC4:")
+	if err != nil {
+		return "", err
+	}
+	stream, err := rc4.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+	plaintext := make([]byte, len(data))
+	stream.XORKeyStream(plaintext, data)
+	return string(plaintext), nil
+}
+
+func (e *RC4Encryptor) Key() []byte {
+	return e.key
+}
+
+//-------------------- XOR Implementation --------------------
+
+// XORKeySize is the length in bytes of the repeating XOR key
+const XORKeySize = 4
+
+// XOREncryptor XORs values with a short repeating key, as unsophisticated
+// attackers do. The ciphertext keeps the length of the plaintext, and bytes
+// a key length apart are XORed with the same key byte, so it stays well
+// below the entropy of real ciphers.
+type XOREncryptor struct {
+	key []byte
+}
+
+// NewXOREncryptor creates a new XOR encryptor with a random key of specified size
+func NewXOREncryptor(keySize int) (*XOREncryptor, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(crypto_rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	return &XOREncryptor{key: key}, nil
+}
+
+func (e *XOREncryptor) Encrypt(plaintext string) (string, error) {
+	ciphertext := []byte(plaintext)
+	for i := range ciphertext {
+		ciphertext[i] ^= e.key[i%len(e.key)]
+	}
+
+	// Base64 encode for storage
+	encoded := base64.StdEncoding.EncodeToString(ciphertext)
+	return fmt.Sprintf("XOR:%s", encoded), nil
+}
+
+func (e *XOREncryptor) Type() EncryptionType {
+	return EncryptionTypeXOR
+}
+
+func (e *XOREncryptor) Decrypt(ciphertext string) (string, error) {
+	data, err := decodeCiphertext(ciphertext, "XOR:")
+	if err != nil {
+		return "", err
+	}
+	for i := range data {
+		data[i] ^= e.key[i%len(e.key)]
+	}
+	return string(data), nil
+}
+
+func (e *XOREncryptor) Key() []byte {
+	return e.key
+}
+
+//-------------------- Obfuscation Implementation --------------------
+
+// ObfuscationEncryptor encodes values as base64 or hex, or rotates their
+// letters by 13 places. Unlike the ciphers, obfuscated values carry no
+// prefix, as attackers hiding from detectors would not add one.
+type ObfuscationEncryptor struct {
+	obfuscation EncryptionType
+}
+
+func (e *ObfuscationEncryptor) Encrypt(plaintext string) (string, error) {
+	switch e.obfuscation {
+	case EncryptionTypeBase64:
+		return base64.StdEncoding.EncodeToString([]byte(plaintext)), nil
+	case EncryptionTypeHex:
+		return hex.EncodeToString([]byte(plaintext)), nil
+	case EncryptionTypeROT13:
+		return rot13(plaintext), nil
+	}
+	return "", fmt.Errorf("unsupported obfuscation: %s", e.obfuscation)
+}
+
+func (e *ObfuscationEncryptor) Type() EncryptionType {
+	return e.obfuscation
+}
+
+func (e *ObfuscationEncryptor) Decrypt(ciphertext string) (string, error) {
+	switch e.obfuscation {
+	case EncryptionTypeBase64:
+		plaintext, err := base64.StdEncoding.DecodeString(ciphertext)
+		return string(plaintext), err
+	case EncryptionTypeHex:
+		plaintext, err := hex.DecodeString(ciphertext)
+		return string(plaintext), err
+	case EncryptionTypeROT13:
+		return rot13(ciphertext), nil
+	}
+	return "", fmt.Errorf("unsupported obfuscation: %s", e.obfuscation)
+}
+
+func (e *ObfuscationEncryptor) Key() []byte {
+	return nil
+}
+
+// rot13 rotates the ASCII letters of s by 13 places
+func rot13(s string) string {
+	rotated := []byte(s)
+	for i, c := range rotated {
+		switch {
+		case c >= 'a' && c <= 'z':
+			rotated[i] = 'a' + (c-'a'+13)%26
+		case c >= 'A' && c <= 'Z':
+			rotated[i] = 'A' + (c-'A'+13)%26
+		}
+	}
+	return string(rotated)
+}
+
 //-------------------- Helper Functions --------------------
 
 // padPKCS7 pads data to a multiple of blockSize according to PKCS#7
@@ -289,6 +880,85 @@ func padPKCS7(data []byte, blockSize int) []byte {
 	return append(data, padtext...)
 }
 
+// unpadPKCS7 removes the PKCS#7 padding of data
+func unpadPKCS7(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no padding")
+	}
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > blockSize || padding > len(data) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return data[:len(data)-padding], nil
+}
+
+// decodeCiphertext checks that ciphertext starts with prefix and returns
+// the base64-decoded bytes that follow it
+func decodeCiphertext(ciphertext string, prefix string) ([]byte, error) {
+	if !strings.HasPrefix(ciphertext, prefix) {
+		return nil, fmt.Errorf("ciphertext does not start with %s", prefix)
+	}
+	return base64.StdEncoding.DecodeString(ciphertext[len(prefix):])
+}
+
+// decryptCBC decrypts data encrypted by encryptCBC
+func decryptCBC(block cipher.Block, data []byte) ([]byte, error) {
+	size := block.BlockSize()
+	if len(data) < 2*size || len(data)%size != 0 {
+		return nil, fmt.Errorf("ciphertext is not a multiple of the block size")
+	}
+	plaintext := make([]byte, len(data)-size)
+	cipher.NewCBCDecrypter(block, data[:size]).CryptBlocks(plaintext, data[size:])
+	return unpadPKCS7(plaintext, size)
+}
+
+// openAEAD decrypts data sealed by an AEAD with its nonce prepended
+func openAEAD(aead cipher.AEAD, data []byte) (string, error) {
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	return string(plaintext), err
+}
+
+// randomKey returns size random bytes
+func randomKey(size int) ([]byte, error) {
+	key := make([]byte, size)
+	if _, err := io.ReadFull(crypto_rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// encryptCBC pads plaintext with PKCS#7 and encrypts it with block in CBC
+// mode under a random IV, which is prepended to the ciphertext
+func encryptCBC(block cipher.Block, plaintext string) ([]byte, error) {
+	iv := make([]byte, block.BlockSize())
+	if _, err := io.ReadFull(crypto_rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	paddedPlaintext := padPKCS7([]byte(plaintext), block.BlockSize())
+	ciphertext := make([]byte, len(paddedPlaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, paddedPlaintext)
+	return append(iv, ciphertext...), nil
+}
+
+// forField returns the config encrypting column of table: with targeted
+// fields, Percentage is scaled by the column's percentage, or zero when the
+// column is not targeted
+func (c EncryptionConfig) forField(table string, column string) EncryptionConfig {
+	if len(c.FieldPercentages) == 0 {
+		return c
+	}
+	p, ok := c.FieldPercentages[table+"."+column]
+	if !ok {
+		p = c.FieldPercentages[column]
+	}
+	c.Percentage = c.Percentage * p / 100
+	return c
+}
+
 // MaybeEncrypt encrypts a value based on encryption configuration and random chance
 func MaybeEncrypt(value string, config EncryptionConfig) (string, error) {
 	// If encryption is disabled or percentage is 0, return the original value
@@ -301,12 +971,157 @@ func MaybeEncrypt(value string, config EncryptionConfig) (string, error) {
 		return value, nil
 	}
 
-	// Get the appropriate encryptor
-	enc, err := GetEncryptor(config)
+	// Get the encryptor of the run
+	keys := config.Keys
+	if keys == nil {
+		keys = runKeys
+	}
+	enc, err := keys.Encryptor(config)
+	if err != nil {
+		return value, err
+	}
+
+	// Encrypt the value, compressed if configured
+	plaintext := value
+	if config.Compress {
+		if plaintext, err = gzipString(value); err != nil {
+			return value, err
+		}
+	}
+	encrypted, err := enc.Encrypt(plaintext)
 	if err != nil {
 		return value, err
 	}
+	if encrypted, err = encodeCiphertext(encrypted, config); err != nil {
+		return value, err
+	}
+	return config.Prefix + encrypted + config.Suffix, nil
+}
+
+// cipherLabel returns the label the encryptor of config's cipher prefixes
+// its base64 ciphertext with, or "" for encodings without one
+func cipherLabel(config EncryptionConfig) string {
+	switch config.Type {
+	case EncryptionTypeAES, EncryptionTypeChaCha20:
+		return CipherName(config) + ":"
+	case EncryptionType3DES, EncryptionTypeBlowfish:
+		return string(config.Type) + "-CBC:"
+	case EncryptionTypeRC4, EncryptionTypeXOR:
+		return string(config.Type) + ":"
+	}
+	return ""
+}
+
+// encodeCiphertext represents the labelled base64 output of a cipher in
+// config's encoding, without the label if unprefixed
+func encodeCiphertext(ciphertext string, config EncryptionConfig) (string, error) {
+	label := cipherLabel(config)
+	if label == "" || !strings.HasPrefix(ciphertext, label) {
+		return ciphertext, nil
+	}
+	data := ciphertext[len(label):]
+	switch config.Encoding {
+	case EncodingBase64, "":
+	case EncodingHex, EncodingRaw:
+		raw, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return "", err
+		}
+		data = hex.EncodeToString(raw)
+		if config.Encoding == EncodingRaw {
+			data = latin1(raw)
+		}
+	default:
+		return "", fmt.Errorf("unknown ciphertext encoding %q (expected one of %s)", config.Encoding, strings.Join(Encodings, ", "))
+	}
+	if config.Unprefixed {
+		label = ""
+	}
+	return label + data, nil
+}
+
+// decodeEncodedCiphertext reverses encodeCiphertext, returning the labelled
+// base64 form the encryptor decrypts
+func decodeEncodedCiphertext(ciphertext string, config EncryptionConfig) (string, error) {
+	label := cipherLabel(config)
+	if label == "" {
+		return ciphertext, nil
+	}
+	if !config.Unprefixed {
+		if !strings.HasPrefix(ciphertext, label) {
+			return "", fmt.Errorf("ciphertext does not start with %s", label)
+		}
+		ciphertext = ciphertext[len(label):]
+	}
+	switch config.Encoding {
+	case EncodingHex:
+		raw, err := hex.DecodeString(ciphertext)
+		if err != nil {
+			return "", err
+		}
+		ciphertext = base64.StdEncoding.EncodeToString(raw)
+	case EncodingRaw:
+		raw := make([]byte, 0, len(ciphertext))
+		for _, r := range ciphertext {
+			if r > 0xFF {
+				return "", fmt.Errorf("raw ciphertext holds character %U beyond a byte", r)
+			}
+			raw = append(raw, byte(r))
+		}
+		ciphertext = base64.StdEncoding.EncodeToString(raw)
+	}
+	return label + ciphertext, nil
+}
 
-	// Encrypt the value
-	return enc.Encrypt(value)
+// latin1 returns the string holding one character per byte of data
+func latin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// DecryptValue reverses MaybeEncrypt for a value enc encrypted under
+// config: it strips the configured prefix and suffix, decodes the
+// ciphertext, decrypts it and decompresses it if configured
+func DecryptValue(value string, config EncryptionConfig, enc Encryptor) (string, error) {
+	if !strings.HasPrefix(value, config.Prefix) || !strings.HasSuffix(value, config.Suffix) || len(value) < len(config.Prefix)+len(config.Suffix) {
+		return "", fmt.Errorf("value lacks the configured prefix or suffix")
+	}
+	ciphertext, err := decodeEncodedCiphertext(value[len(config.Prefix):len(value)-len(config.Suffix)], config)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := enc.Decrypt(ciphertext)
+	if err != nil || !config.Compress {
+		return plaintext, err
+	}
+	return gunzipString(plaintext)
+}
+
+// gzipString returns the gzip compression of s
+func gzipString(s string) (string, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// gunzipString returns the decompression of the gzip data s
+func gunzipString(s string) (string, error) {
+	r, err := gzip.NewReader(strings.NewReader(s))
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
@@ -0,0 +1,153 @@
+package logsimulator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// roundTripCiphers configures every cipher and obfuscation the simulator offers
+var roundTripCiphers = []EncryptionConfig{
+	{Type: EncryptionTypeAES, AESMode: "CBC", KeySize: 16},
+	{Type: EncryptionTypeAES, AESMode: "CTR", KeySize: 24},
+	{Type: EncryptionTypeAES, AESMode: "GCM", KeySize: 32},
+	{Type: EncryptionTypeAES, AESMode: "ECB", KeySize: 32},
+	{Type: EncryptionTypeChaCha20, Variant: ChaChaVariantIETF},
+	{Type: EncryptionTypeChaCha20, Variant: ChaChaVariantIETF, NonceReuse: true},
+	{Type: EncryptionTypeChaCha20, Variant: ChaChaVariantX},
+	{Type: EncryptionType3DES},
+	{Type: EncryptionTypeBlowfish},
+	{Type: EncryptionTypeRC4},
+	{Type: EncryptionTypeXOR},
+	{Type: EncryptionTypeBase64},
+	{Type: EncryptionTypeHex},
+	{Type: EncryptionTypeROT13},
+}
+
+// roundTripPlaintexts covers empty, block-aligned, multi-byte and long values
+var roundTripPlaintexts = []string{
+	"",
+	"0123456789abcdef",
+	"alice@example.com",
+	"Zoë Ångström, 東京 ✓",
+	strings.Repeat(`{"balance":1024.5}`, 40),
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	for _, cipher := range roundTripCiphers {
+		for _, encoding := range Encodings {
+			for _, unprefixed := range []bool{false, true} {
+				config := cipher
+				config.Encoding, config.Unprefixed = encoding, unprefixed
+				name := fmt.Sprintf("%s/%s", CipherName(config), encoding)
+				if cipher.NonceReuse {
+					name += "/nonce-reuse"
+				}
+				if unprefixed {
+					name += "/unprefixed"
+				}
+
+				t.Run(name, func(t *testing.T) {
+					enc, err := GetEncryptor(config)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, plaintext := range roundTripPlaintexts {
+						ciphertext, err := enc.Encrypt(plaintext)
+						if err != nil {
+							t.Fatalf("Encrypt(%q): %v", plaintext, err)
+						}
+						encoded, err := encodeCiphertext(ciphertext, config)
+						if err != nil {
+							t.Fatalf("encodeCiphertext(%q): %v", ciphertext, err)
+						}
+						decoded, err := decodeEncodedCiphertext(encoded, config)
+						if err != nil {
+							t.Fatalf("decodeEncodedCiphertext(%q): %v", encoded, err)
+						}
+						if decoded != ciphertext {
+							t.Fatalf("%q decoded to %q, want %q", encoded, decoded, ciphertext)
+						}
+						got, err := enc.Decrypt(decoded)
+						if err != nil {
+							t.Fatalf("Decrypt(%q): %v", decoded, err)
+						}
+						if got != plaintext {
+							t.Errorf("%q round-tripped to %q", plaintext, got)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestEscrowedKeysDecryptValues(t *testing.T) {
+	const values = 10
+	tests := []struct {
+		name     string
+		config   EncryptionConfig
+		perKey   int // Values encrypted under each key
+		distinct bool
+	}{
+		{"run key", EncryptionConfig{Type: EncryptionTypeAES, AESMode: "GCM", KeySize: 32}, values, true},
+		{"run key compressed", EncryptionConfig{Type: EncryptionTypeBlowfish, Compress: true, Prefix: "enc:", Suffix: ".locked"}, values, true},
+		{"key rotation", EncryptionConfig{Type: EncryptionTypeAES, AESMode: "CBC", KeySize: 16, KeyRotation: 3}, 3, true},
+		{"key rotation hex", EncryptionConfig{Type: EncryptionTypeRC4, KeyRotation: 4, Encoding: EncodingHex, Unprefixed: true}, 4, true},
+		{"per-value keys", EncryptionConfig{Type: EncryptionTypeChaCha20, Variant: ChaChaVariantX, KeyReuse: KeyPerValue}, 1, true},
+		{"per-value keys raw", EncryptionConfig{Type: EncryptionType3DES, KeyReuse: KeyPerValue, Encoding: EncodingRaw, Suffix: ".crypt"}, 1, true},
+		// Keyless obfuscations escrow an empty key per draw
+		{"keyless", EncryptionConfig{Type: EncryptionTypeROT13, KeyRotation: 5}, 5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var escrowed []EscrowedKey
+			EscrowKeys(func(key EscrowedKey) { escrowed = append(escrowed, key) })
+			defer EscrowKeys(nil)
+
+			config := tt.config
+			config.Percentage, config.Keys = 100, NewKeyring()
+			var plaintexts, encrypted []string
+			for i := 0; i < values; i++ {
+				plaintext := fmt.Sprintf("customer-%d@example.com", i)
+				value, err := MaybeEncrypt(plaintext, config)
+				if err != nil {
+					t.Fatal(err)
+				}
+				plaintexts = append(plaintexts, plaintext)
+				encrypted = append(encrypted, value)
+			}
+
+			if want := (values + tt.perKey - 1) / tt.perKey; len(escrowed) != want {
+				t.Fatalf("escrowed %d keys, want %d", len(escrowed), want)
+			}
+			seen := make(map[string]bool)
+			for _, key := range escrowed {
+				if key.Cipher != CipherName(config) {
+					t.Errorf("escrowed cipher %s, want %s", key.Cipher, CipherName(config))
+				}
+				if tt.distinct && seen[key.Key] {
+					t.Errorf("key %s was drawn twice", key.Key)
+				}
+				seen[key.Key] = true
+			}
+
+			for i, value := range encrypted {
+				if value == plaintexts[i] {
+					t.Fatalf("value %d was not encrypted", i)
+				}
+				decryptConfig, enc, err := escrowed[i/tt.perKey].Decryptor()
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := DecryptValue(value, decryptConfig, enc)
+				if err != nil {
+					t.Fatalf("value %d: %v", i, err)
+				}
+				if got != plaintexts[i] {
+					t.Errorf("value %d decrypted to %q, want %q", i, got, plaintexts[i])
+				}
+			}
+		})
+	}
+}
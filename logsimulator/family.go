@@ -0,0 +1,102 @@
+package logsimulator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// VictimIDPlaceholder is replaced by the run's victim ID in the suffixes
+// and notes of ransomware families
+const VictimIDPlaceholder = "{id}"
+
+// RansomwareFamily emulates the at-rest format of a known ransomware family:
+// its cipher, the marker bytes it prepends to encrypted data, the extension
+// it appends, and the ransom notes it drops.
+type RansomwareFamily struct {
+	Name      string
+	Cipher    EncryptionConfig
+	Marker    string // Prepended to every encrypted value
+	Suffix    string // Appended to every encrypted value, may embed the victim ID
+	NoteTable string // Table receiving the ransom note; empty inserts a note row into every table
+	Note      string // Ransom note, may embed the victim ID; empty means a generic note
+}
+
+// RansomwareFamilies lists the emulated families by name
+var RansomwareFamilies = map[string]RansomwareFamily{
+	"lockbit": {
+		Name:      "lockbit",
+		Cipher:    EncryptionConfig{Type: EncryptionTypeAES, AESMode: "CTR", KeySize: 32},
+		Suffix:    ".lockbit",
+		NoteTable: "restore_my_files",
+		Note:      "~~~ LockBit ~~~ All your important files are stolen and encrypted! Your decryption ID is " + VictimIDPlaceholder + ". Visit our site on TOR to contact us.",
+	},
+	"wannacry": {
+		Name:   "wannacry",
+		Cipher: EncryptionConfig{Type: EncryptionTypeAES, AESMode: "CBC", KeySize: 16},
+		Marker: "WANACRY!",
+		Suffix: ".WNCRY",
+		Note:   "Ooops, your important files are encrypted. Send $300 worth of bitcoin to the address below. Your ID: " + VictimIDPlaceholder,
+	},
+	"ryuk": {
+		Name:      "ryuk",
+		Cipher:    EncryptionConfig{Type: EncryptionTypeAES, AESMode: "CBC", KeySize: 32},
+		Marker:    "HERMES",
+		Suffix:    ".RYK",
+		NoteTable: "ryukreadme",
+		Note:      "Your network has been penetrated. All files on each host in the network have been encrypted with a strong algorithm. Balance of shadow universe: " + VictimIDPlaceholder + ". Ryuk. No system is safe.",
+	},
+	"conti": {
+		Name:      "conti",
+		Cipher:    EncryptionConfig{Type: EncryptionTypeChaCha20},
+		Suffix:    ".CONTI",
+		NoteTable: "conti_readme",
+		Note:      "All of your files are currently encrypted by CONTI strain. Your client ID: " + VictimIDPlaceholder,
+	},
+	"phobos": {
+		Name:   "phobos",
+		Cipher: EncryptionConfig{Type: EncryptionTypeAES, AESMode: "CBC", KeySize: 32},
+		Suffix: ".id[" + VictimIDPlaceholder + "].[decrypt@onionmail.org].eking",
+		Note:   "All your files have been encrypted due to a security problem with your PC. Write us to decrypt@onionmail.org with your ID " + VictimIDPlaceholder,
+	},
+}
+
+// RansomwareFamilyNames returns the names of the emulated families in order
+func RansomwareFamilyNames() []string {
+	names := make([]string, 0, len(RansomwareFamilies))
+	for name := range RansomwareFamilies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyFamily makes scenario emulate the ransomware family name: its
+// encrypted updates use the family's cipher and format under a fresh victim
+// ID, and it ends by dropping the family's ransom note
+func ApplyFamily(scenario *Scenario, name string) error {
+	family, ok := RansomwareFamilies[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown ransomware family %q (expected one of %s)", name, strings.Join(RansomwareFamilyNames(), ", "))
+	}
+	victimID := victimID()
+	encConfig := scenario.Encryption
+	encConfig.Type = family.Cipher.Type
+	encConfig.AESMode = family.Cipher.AESMode
+	encConfig.KeySize = family.Cipher.KeySize
+	encConfig.Unprefixed = true
+	encConfig.Prefix = family.Marker
+	encConfig.Suffix = strings.ReplaceAll(family.Suffix, VictimIDPlaceholder, victimID)
+	scenario.Encryption = encConfig
+	scenario.RansomNote = true
+	scenario.NoteTable = family.NoteTable
+	scenario.Note = strings.ReplaceAll(family.Note, VictimIDPlaceholder, victimID)
+	return nil
+}
+
+// victimID returns a random victim ID in the 8-4 hex form families embed
+func victimID() string {
+	return strings.ToUpper(gofakeit.HexUint(32)[2:] + "-" + gofakeit.HexUint(16)[2:])
+}
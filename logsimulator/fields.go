@@ -0,0 +1,58 @@
+package logsimulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// ibanFormats gives the layout of the BBAN (the part after the check digits)
+// for a few countries: # is a digit and ? an upper-case letter
+var ibanFormats = []struct {
+	country string
+	bban    string
+}{
+	{"DE", "##################"},
+	{"GB", "????##############"},
+	{"FR", "#######################"},
+	{"NL", "????##########"},
+	{"ES", "####################"},
+}
+
+// iban generates an IBAN with valid check digits
+func iban() string {
+	format := ibanFormats[rand.Intn(len(ibanFormats))]
+	bban := []byte(format.bban)
+	for i, c := range bban {
+		switch c {
+		case '#':
+			bban[i] = byte('0' + rand.Intn(10))
+		case '?':
+			bban[i] = byte('A' + rand.Intn(26))
+		}
+	}
+
+	// ISO 13616: move the country code and zero check digits to the end,
+	// read letters as 10-35 and take 98 minus the remainder modulo 97
+	remainder := 0
+	for _, c := range string(bban) + format.country + "00" {
+		if c >= 'A' && c <= 'Z' {
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		} else {
+			remainder = (remainder*10 + int(c-'0')) % 97
+		}
+	}
+	return fmt.Sprintf("%s%02d%s", format.country, 98-remainder, bban)
+}
+
+// jsonDocument generates a JSON object of random keys and nested values, like
+// the documents stored in JSON columns
+func jsonDocument() string {
+	data, err := json.Marshal(gofakeit.Map())
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
@@ -0,0 +1,135 @@
+package logsimulator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+)
+
+// Key reuse modes of EncryptionConfig
+const (
+	KeyPerRun   = "run"   // One key per cipher for the run, replaced after KeyRotation values
+	KeyPerValue = "value" // A fresh key for every value
+)
+
+// KeyReuseModes lists the accepted key reuse modes
+var KeyReuseModes = []string{KeyPerRun, KeyPerValue}
+
+// runKeys is the keyring shared by the simulated changes of a run
+var runKeys = NewKeyring()
+
+// EscrowedKey is a key drawn by a keyring, with the settings needed to
+// decrypt the values encrypted under it
+type EscrowedKey struct {
+	Cipher   string `json:"cipher"`        // As accepted by ParseCipher
+	Key      string `json:"key,omitempty"` // Base64; empty for keyless encodings
+	Prefix   string `json:"prefix,omitempty"`
+	Suffix   string `json:"suffix,omitempty"`
+	Compress bool   `json:"compress,omitempty"`
+
+	Encoding   string `json:"encoding,omitempty"`
+	Unprefixed bool   `json:"unprefixed,omitempty"`
+}
+
+// Decryptor returns the encryption config and encryptor of the escrowed
+// key, for DecryptValue
+func (k EscrowedKey) Decryptor() (EncryptionConfig, Encryptor, error) {
+	config, err := ParseCipher(k.Cipher)
+	if err != nil {
+		return EncryptionConfig{}, nil, err
+	}
+	config.Prefix, config.Suffix, config.Compress = k.Prefix, k.Suffix, k.Compress
+	config.Encoding, config.Unprefixed = k.Encoding, k.Unprefixed
+	key, err := base64.StdEncoding.DecodeString(k.Key)
+	if err != nil {
+		return EncryptionConfig{}, nil, fmt.Errorf("key: %w", err)
+	}
+	enc, err := EncryptorWithKey(config, key)
+	return config, enc, err
+}
+
+// escrow receives every key drawn by a keyring when set by EscrowKeys
+var (
+	escrow   func(EscrowedKey)
+	escrowMu sync.Mutex
+)
+
+// EscrowKeys calls record with every key the keyrings of the run draw from
+// now on, so the values encrypted under them can be verified
+func EscrowKeys(record func(EscrowedKey)) {
+	escrowMu.Lock()
+	defer escrowMu.Unlock()
+	escrow = record
+}
+
+// escrowKey passes the key of enc, drawn for config, to the escrow
+func escrowKey(config EncryptionConfig, enc Encryptor) {
+	escrowMu.Lock()
+	defer escrowMu.Unlock()
+	if escrow == nil {
+		return
+	}
+	escrow(EscrowedKey{
+		Cipher:   CipherName(config),
+		Key:      base64.StdEncoding.EncodeToString(enc.Key()),
+		Prefix:   config.Prefix,
+		Suffix:   config.Suffix,
+		Compress: config.Compress,
+
+		Encoding:   config.Encoding,
+		Unprefixed: config.Unprefixed,
+	})
+}
+
+// Keyring holds the encryptors of an attacker, one per cipher, so their keys
+// are reused across values like real ransomware's instead of drawn anew for
+// every value
+type Keyring struct {
+	mu         sync.Mutex
+	encryptors map[string]*keyedEncryptor
+}
+
+// keyedEncryptor is an encryptor of a keyring and the number of values it
+// encrypted
+type keyedEncryptor struct {
+	Encryptor
+	uses int
+}
+
+// NewKeyring creates an empty keyring
+func NewKeyring() *Keyring {
+	return &Keyring{encryptors: make(map[string]*keyedEncryptor)}
+}
+
+// Encryptor returns the keyring's encryptor of config's cipher, creating one
+// under a new key the first time, after config.KeyRotation values, or every
+// time with KeyPerValue reuse
+func (k *Keyring) Encryptor(config EncryptionConfig) (Encryptor, error) {
+	switch config.KeyReuse {
+	case KeyPerValue:
+		enc, err := GetEncryptor(config)
+		if err == nil {
+			escrowKey(config, enc)
+		}
+		return enc, err
+	case KeyPerRun, "":
+	default:
+		return nil, fmt.Errorf("unknown key reuse %q (expected %s or %s)", config.KeyReuse, KeyPerRun, KeyPerValue)
+	}
+	cipher := fmt.Sprintf("%s-%d-%s-%s-%t", config.Type, config.KeySize, config.AESMode, config.Variant, config.NonceReuse)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	current, ok := k.encryptors[cipher]
+	if !ok || (config.KeyRotation > 0 && current.uses >= config.KeyRotation) {
+		enc, err := GetEncryptor(config)
+		if err != nil {
+			return nil, err
+		}
+		current = &keyedEncryptor{Encryptor: enc}
+		k.encryptors[cipher] = current
+		escrowKey(config, enc)
+	}
+	current.uses++
+	return current.Encryptor, nil
+}
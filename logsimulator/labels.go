@@ -0,0 +1,65 @@
+package logsimulator
+
+import (
+	"context"
+	"time"
+)
+
+// Label is the ground truth of one simulated attack change: which attack
+// changed which fields of which row, and when
+type Label struct {
+	Attack    string    `json:"attack"`
+	Table     string    `json:"table"`
+	Row       string    `json:"row,omitempty"`
+	Operation string    `json:"operation"`
+	Fields    []string  `json:"fields"` // * for DDL affecting the whole table
+	Timestamp time.Time `json:"timestamp"`
+
+	// Plaintexts are the values of the encrypted fields before encryption
+	Plaintexts map[string]string `json:"plaintexts,omitempty"`
+}
+
+// LabelOf returns the label of a simulated log, reporting false for logs of
+// normal traffic
+func LabelOf(log interface{}) (Label, bool) {
+	m, ok := log.(map[string]interface{})
+	if !ok {
+		return Label{}, false
+	}
+	attack, _ := m[AttackLabel].(string)
+	if attack == "" {
+		return Label{}, false
+	}
+	change := nativeChange(m)
+	fields, _ := m[AttackFieldsLabel].([]string)
+	plaintexts, _ := m[AttackPlaintextsLabel].(map[string]string)
+	return Label{
+		Attack:     attack,
+		Table:      change.table,
+		Row:        change.key,
+		Operation:  change.operation,
+		Fields:     fields,
+		Timestamp:  change.timestamp,
+		Plaintexts: plaintexts,
+	}, true
+}
+
+// RecordLabels passes on the logs of stream, calling record with the label
+// of every attack change as it passes
+func RecordLabels(ctx context.Context, stream <-chan interface{}, record func(Label)) <-chan interface{} {
+	logs := make(chan interface{}, 64)
+	go func() {
+		defer close(logs)
+		for log := range stream {
+			if label, ok := LabelOf(log); ok {
+				record(label)
+			}
+			select {
+			case logs <- log:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return logs
+}
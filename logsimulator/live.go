@@ -0,0 +1,36 @@
+package logsimulator
+
+import (
+	"context"
+	"time"
+)
+
+// Pace passes on the logs of stream in real time: each log is held until the
+// wall clock reaches its timestamp, so a stream whose clock starts now is
+// emitted at the rate and with the activity pattern of its ClockConfig.
+// Logs timestamped in the past, or without a timestamp, pass straight on.
+func Pace(ctx context.Context, stream <-chan interface{}) <-chan interface{} {
+	logs := make(chan interface{}, 64)
+	go func() {
+		defer close(logs)
+		for log := range stream {
+			if ts, ok := timestamp(log); ok {
+				if wait := time.Until(ts); wait > 0 {
+					timer := time.NewTimer(wait)
+					select {
+					case <-timer.C:
+					case <-ctx.Done():
+						timer.Stop()
+						return
+					}
+				}
+			}
+			select {
+			case logs <- log:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return logs
+}
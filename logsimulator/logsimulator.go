@@ -1,8 +1,10 @@
 package logsimulator
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
@@ -10,17 +12,31 @@ import (
 
 // FieldConfig defines a field name and its corresponding data generator function.
 // The Generator function returns a string, aligning with most gofakeit functions.
+// A field referencing a table holds identifiers of its rows, which change
+// for another row's rather than being edited.
 type FieldConfig struct {
 	Name      string
 	Generator func() string
+	Reference string
 }
 
 // defaultFields provides a set of predefined fields with generators for common use cases.
+// Signals behave very differently across data types, so they cover free text,
+// identifiers, structured numbers, dates and JSON documents.
 var defaultFields = []FieldConfig{
 	{Name: "bio", Generator: func() string { return gofakeit.Sentence(5) }},
 	{Name: "email", Generator: gofakeit.Email},
 	{Name: "phone", Generator: gofakeit.Phone},
 	{Name: "address", Generator: func() string { return gofakeit.Address().Address }},
+	{Name: "ssn", Generator: gofakeit.SSN},
+	{Name: "credit_card", Generator: func() string { return gofakeit.CreditCardNumber(nil) }},
+	{Name: "iban", Generator: iban},
+	{Name: "uuid", Generator: gofakeit.UUID},
+	{Name: "ip_address", Generator: gofakeit.IPv4Address},
+	{Name: "url", Generator: gofakeit.URL},
+	{Name: "json", Generator: jsonDocument},
+	{Name: "amount", Generator: func() string { return strconv.FormatFloat(gofakeit.Price(1, 10000), 'f', 2, 64) }},
+	{Name: "date", Generator: func() string { return gofakeit.Date().Format(time.RFC3339) }},
 }
 
 // GetDefaultFields returns the predefined field configurations.
@@ -29,14 +45,11 @@ func GetDefaultFields() []FieldConfig {
 	return defaultFields
 }
 
-// GetFieldByName returns a field configuration by name
+// GetFieldByName returns a default or registered field configuration by name
 func GetFieldByName(name string) (FieldConfig, bool) {
-	for _, field := range defaultFields {
-		if field.Name == name {
-			return field, true
-		}
-	}
-	return FieldConfig{}, false
+	registeredFieldsMu.RLock()
+	defer registeredFieldsMu.RUnlock()
+	return lookupField(name)
 }
 
 // GenerateOracleUpdateLog creates a mock log entry for an Oracle UPDATE operation.
@@ -66,9 +79,24 @@ func GeneratePostgresUpdateLog(table string, primaryKey string, columns []string
 }
 
 // GenerateLogs generates a specified number of mock log entries based on the database type,
-// operation, table, and field configurations.
-func GenerateLogs(dbType string, operation string, table string, numRows int, fields []FieldConfig, encConfig EncryptionConfig) []interface{} {
+// operation, table, and field configurations. ddlConfig mixes DDL events in
+// among the updates, editConfig sets how after-values are derived and
+// clockConfig the simulated time of the entries.
+func GenerateLogs(dbType string, operation string, table string, numRows int, fields []FieldConfig, encConfig EncryptionConfig, ddlConfig DDLConfig, editConfig EditConfig, clockConfig ClockConfig) []interface{} {
 	logs := []interface{}{}
+	for log := range StreamLogs(context.Background(), dbType, operation, table, numRows, fields, encConfig, ddlConfig, editConfig, clockConfig) {
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+// StreamLogs generates the same mock log entries as GenerateLogs, but one at a
+// time on the returned channel so callers can process each entry as it is
+// produced instead of holding the whole run in memory. The channel is closed
+// once numRows entries have been sent or ctx is cancelled.
+func StreamLogs(ctx context.Context, dbType string, operation string, table string, numRows int, fields []FieldConfig, encConfig EncryptionConfig, ddlConfig DDLConfig, editConfig EditConfig, clockConfig ClockConfig) <-chan interface{} {
+	logs := make(chan interface{}, 64)
+	clock := NewClock(clockConfig)
 
 	// Initialize random seed
 	rand.Seed(time.Now().UnixNano())
@@ -79,41 +107,131 @@ func GenerateLogs(dbType string, operation string, table string, numRows int, fi
 		columns[i] = field.Name
 	}
 
-	// Generate the specified number of log entries
-	for i := 1; i <= numRows; i++ {
-		rowID := fmt.Sprintf("row%d", i)
-		before := make(map[string]interface{})
-		after := make(map[string]interface{})
-
-		// Populate before and after values using the field generators
-		for _, field := range fields {
-			beforeValue := field.Generator()
-			afterValue := field.Generator()
-
-			before[field.Name] = beforeValue
-
-			// Potentially encrypt the after value based on configuration
-			if encryptedValue, err := MaybeEncrypt(afterValue, encConfig); err == nil {
-				after[field.Name] = encryptedValue
-			} else {
-				// If encryption fails, use the original value
-				after[field.Name] = afterValue
+	go func() {
+		defer close(logs)
+		for i := 1; i <= numRows; i++ {
+			log, destroyed := generateLog(dbType, table, fmt.Sprintf("row%d", i), nil, columns, fields, encConfig, ddlConfig, editConfig)
+			setTimestamp(log, clock.Next())
+			if len(destroyed) > 0 {
+				setLabel(log, RandomAttack, destroyed)
+			}
+			select {
+			case logs <- log:
+			case <-ctx.Done():
+				return
 			}
 		}
+	}()
+	return logs
+}
+
+// generateLog creates one mock log entry for rowID with after values derived
+// as editConfig sets, or a DDL event for the table with the configured
+// chance. The before values are the row's current values, which the after
+// values replace, or fresh values when current is nil. current holds the
+// row's plaintexts: values are encrypted on output only, so later updates
+// edit the plaintext rather than the ciphertext, and only double encryption
+// (encConfig.Previous) encrypts a ciphertext again. Encrypted fields always change, at least one
+// field changes, and correlated fields follow the changes. It returns the fields the log destroys: those
+// of an update it encrypted, or those of a DDL event (see attackedFields); none for a benign update.
+func generateLog(dbType string, table string, rowID string, current map[string]interface{}, columns []string, fields []FieldConfig, encConfig EncryptionConfig, ddlConfig DDLConfig, editConfig EditConfig) (interface{}, []string) {
+	if log, ok := maybeDDL(dbType, table, columns, ddlConfig); ok {
+		return log, attackedFields(log)
+	}
 
-		// Generate the log based on the database type
-		var log interface{}
-		if dbType == "oracle" {
-			log = GenerateOracleUpdateLog(table, rowID, columns, before, after)
-		} else if dbType == "postgres" {
-			log = GeneratePostgresUpdateLog(table, rowID, columns, before, after)
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	edits := make(map[string]string, len(fields))
+	changed := make(map[string]bool, len(fields))
+	var encrypted []string
+	plaintexts := make(map[string]string)
+	rowValues := make(map[string]interface{}, len(fields)) // The row's plaintexts after the change
+
+	// Populate before and after values using the field generators
+	for _, field := range fields {
+		fieldEncConfig := encConfig.forField(table, field.Name)
+		rowValue, ok := current[field.Name]
+		if !ok {
+			rowValue = field.Generator()
+		}
+		// A nulled value is replaced afresh rather than edited
+		beforeValue := rowValue
+		afterValue := field.Generator()
+		if s, ok := rowValue.(string); ok {
+			afterValue = generateAfter(field, s, editConfig)
+			if encConfig.Previous != nil {
+				beforeValue, _ = MaybeEncrypt(s, encConfig.Previous.forField(table, field.Name))
+			}
+		}
+
+		before[field.Name] = beforeValue
+		edits[field.Name] = afterValue
+		rowValues[field.Name] = rowValue
+
+		// Potentially encrypt the after value based on configuration, or
+		// the current value when encrypting it again; if encryption fails,
+		// the original value is used
+		plaintext := afterValue
+		if s, ok := beforeValue.(string); ok && encConfig.Previous != nil {
+			plaintext = s
+		}
+		if encryptedValue, err := MaybeEncrypt(plaintext, fieldEncConfig); err == nil && encryptedValue != plaintext {
+			after[field.Name] = encryptedValue
+			changed[field.Name] = true
+			encrypted = append(encrypted, field.Name)
+			plaintexts[field.Name] = plaintext
+			if encConfig.Previous == nil {
+				rowValues[field.Name] = afterValue
+			}
+		} else if editConfig.changes(table, field.Name) {
+			after[field.Name] = afterValue
+			changed[field.Name] = true
+			rowValues[field.Name] = afterValue
+		} else {
+			after[field.Name] = beforeValue
 		}
-		logs = append(logs, log)
 	}
-	return logs
+	if len(changed) == 0 && len(fields) > 0 {
+		name := fields[rand.Intn(len(fields))].Name
+		after[name] = edits[name]
+		changed[name] = true
+		rowValues[name] = edits[name]
+	}
+	for _, correlation := range editConfig.Correlations {
+		for _, name := range correlation.follow(table, changed) {
+			if afterValue, ok := edits[name]; ok {
+				after[name] = afterValue
+				changed[name] = true
+				rowValues[name] = afterValue
+			}
+		}
+	}
+	if current != nil {
+		for name, value := range rowValues {
+			current[name] = value
+		}
+	}
+	changedColumns := make([]string, 0, len(changed))
+	for _, field := range fields {
+		if changed[field.Name] {
+			changedColumns = append(changedColumns, field.Name)
+		}
+	}
+
+	// Generate the log based on the database type
+	var log interface{}
+	if dbType == "oracle" {
+		log = GenerateOracleUpdateLog(table, rowID, changedColumns, before, after)
+	} else if dbType == "postgres" {
+		log = GeneratePostgresUpdateLog(table, rowID, changedColumns, before, after)
+	}
+	if m, ok := log.(map[string]interface{}); ok && len(plaintexts) > 0 {
+		m[AttackPlaintextsLabel] = plaintexts
+	}
+	return log, encrypted
 }
 
 // GenerateDefaultLogs generates a specified number of mock log entries using the default field configurations.
 func GenerateDefaultLogs(dbType string, operation string, table string, numRows int, encConfig EncryptionConfig) []interface{} {
-	return GenerateLogs(dbType, operation, table, numRows, defaultFields, encConfig)
+	return GenerateLogs(dbType, operation, table, numRows, defaultFields, encConfig, DDLConfig{}, EditConfig{}, ClockConfig{})
 }
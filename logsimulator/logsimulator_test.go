@@ -0,0 +1,66 @@
+package logsimulator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPopulatedValuesStayPlaintext(t *testing.T) {
+	schema := DefaultSchema()
+	for i := range schema.Tables {
+		schema.Tables[i].Rows = 10
+	}
+	encConfig := EncryptionConfig{Type: EncryptionTypeAES, AESMode: "GCM", KeySize: 32, Percentage: 50}
+	label := CipherName(encConfig) + ":"
+
+	logs, err := StreamSchemaLogs(context.Background(), "postgres", schema, 500, encConfig, DDLConfig{}, EditConfig{}, ClockConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted := 0
+	for log := range logs {
+		m := log.(map[string]interface{})
+		before := m["old_values"].(map[string]interface{})
+		after := m["new_values"].(map[string]interface{})
+		attack, _ := LabelOf(log)
+
+		for field, value := range before {
+			s, _ := value.(string)
+			if strings.HasPrefix(s, label) {
+				t.Fatalf("row %v: before value of %s is a ciphertext: the population kept it", m["primary_key"], field)
+			}
+		}
+		for field, value := range after {
+			s, _ := value.(string)
+			// The longest plaintexts are JSON documents of a few hundred characters
+			if len(s) > 2048 {
+				t.Fatalf("row %v: after value of %s is %d characters long", m["primary_key"], field, len(s))
+			}
+			if !strings.HasPrefix(s, label) {
+				continue
+			}
+			encrypted++
+			if !hasName(attack.Fields, field) {
+				t.Errorf("row %v: encrypted after value of %s is not labelled", m["primary_key"], field)
+			}
+			if attack.Plaintexts[field] == "" {
+				t.Errorf("row %v: label has no plaintext for %s", m["primary_key"], field)
+			}
+		}
+	}
+	if encrypted == 0 {
+		t.Fatal("no value was encrypted")
+	}
+}
+
+// hasName reports whether names contains name
+func hasName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
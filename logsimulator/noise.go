@@ -0,0 +1,114 @@
+package logsimulator
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+)
+
+// NoiseKind is a way of malforming a log
+type NoiseKind string
+
+// Malformed logs the simulator can inject
+const (
+	NoiseMissingFields NoiseKind = "missing_fields" // Random keys of the log removed
+	NoiseWrongTypes    NoiseKind = "wrong_types"    // Values replaced by values of other types
+	NoiseTruncated     NoiseKind = "truncated"      // The log as a string of JSON cut short
+	NoiseNilMaps       NoiseKind = "nil_maps"       // Before and after values set to nil maps
+)
+
+// NoiseKinds lists the malformed logs the simulator can inject
+var NoiseKinds = []NoiseKind{NoiseMissingFields, NoiseWrongTypes, NoiseTruncated, NoiseNilMaps}
+
+// NoiseConfig defines how often malformed logs are injected into a stream, as
+// broken producers and lossy transports mix them with real changes
+type NoiseConfig struct {
+	Percentage int         // Chance of a malformed copy being injected before each log
+	Kinds      []NoiseKind // Malformations to choose from; empty selects all of NoiseKinds
+}
+
+// InjectNoise passes on the logs of stream, injecting a malformed copy of a
+// log before it with the configured chance. The copies never replace logs,
+// so the stream's real changes and their labels are kept.
+func InjectNoise(ctx context.Context, stream <-chan interface{}, config NoiseConfig) <-chan interface{} {
+	if config.Percentage <= 0 {
+		return stream
+	}
+	kinds := config.Kinds
+	if len(kinds) == 0 {
+		kinds = NoiseKinds
+	}
+
+	logs := make(chan interface{}, 64)
+	go func() {
+		defer close(logs)
+		for log := range stream {
+			if config.Percentage >= 100 || rand.Intn(100) < config.Percentage {
+				select {
+				case logs <- Malform(log, kinds[rand.Intn(len(kinds))]):
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case logs <- log:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return logs
+}
+
+// Malform returns a malformed copy of log, leaving log itself unchanged.
+// Logs that are not maps are returned as they are.
+func Malform(log interface{}, kind NoiseKind) interface{} {
+	logMap, ok := log.(map[string]interface{})
+	if !ok {
+		return log
+	}
+	malformed := make(map[string]interface{}, len(logMap))
+	for key, value := range logMap {
+		malformed[key] = value
+	}
+
+	switch kind {
+	case NoiseMissingFields:
+		for key := range malformed {
+			if rand.Intn(2) == 0 {
+				delete(malformed, key)
+			}
+		}
+	case NoiseWrongTypes:
+		for key, value := range malformed {
+			malformed[key] = wrongType(value)
+		}
+	case NoiseTruncated:
+		data, err := json.Marshal(malformed)
+		if err != nil || len(data) < 2 {
+			return "{"
+		}
+		return string(data[:1+rand.Intn(len(data)-1)])
+	case NoiseNilMaps:
+		for key, value := range malformed {
+			if _, ok := value.(map[string]interface{}); ok {
+				malformed[key] = map[string]interface{}(nil)
+			}
+		}
+	}
+	return malformed
+}
+
+// wrongType returns a value of another type than value: strings become
+// numbers, lists become strings, maps become lists and anything else a map
+func wrongType(value interface{}) interface{} {
+	switch value.(type) {
+	case string:
+		return rand.Float64() * 1000
+	case []string, []interface{}:
+		return "not a list"
+	case map[string]interface{}:
+		return []interface{}{value}
+	}
+	return map[string]interface{}{"value": value}
+}
@@ -0,0 +1,39 @@
+package logsimulator
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// population holds the current plaintext values of the simulated rows of
+// each table, so repeated updates of a row continue its history: each
+// update's before-values are the previous update's after-values as they were
+// before any encryption
+type population map[string]map[string]map[string]interface{}
+
+// pick chooses one of the first size rows of table at random, returning its
+// identifier and current values; values are filled in as columns change
+func (p population) pick(table string, size int) (string, map[string]interface{}) {
+	rowID := fmt.Sprintf("row%d", rand.Intn(size)+1)
+	return rowID, p.row(table, rowID)
+}
+
+// row returns the current values of a row, creating it when missing
+func (p population) row(table string, rowID string) map[string]interface{} {
+	rows, ok := p[table]
+	if !ok {
+		rows = make(map[string]map[string]interface{})
+		p[table] = rows
+	}
+	values, ok := rows[rowID]
+	if !ok {
+		values = make(map[string]interface{})
+		rows[rowID] = values
+	}
+	return values
+}
+
+// remove deletes a row, so it is created afresh if picked again
+func (p population) remove(table string, rowID string) {
+	delete(p[table], rowID)
+}
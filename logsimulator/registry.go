@@ -0,0 +1,80 @@
+package logsimulator
+
+import (
+	"strconv"
+	"sync"
+)
+
+var (
+	registeredFieldsMu sync.RWMutex
+	registeredFields   []FieldConfig
+)
+
+// RegisterField makes a field available by name alongside the default
+// fields, so embedding programs can simulate domain-specific values such as
+// account numbers or ICD codes. Registered fields are offered by the TUI,
+// simulated in the default users table and usable as column types in schema
+// files. It is meant to be called from init functions, and panics if name is
+// already a field or gen is nil.
+func RegisterField(name string, gen func() string) {
+	registeredFieldsMu.Lock()
+	defer registeredFieldsMu.Unlock()
+	if gen == nil {
+		panic("logsimulator: RegisterField generator is nil for " + name)
+	}
+	if _, dup := lookupField(name); dup {
+		panic("logsimulator: RegisterField called twice for " + name)
+	}
+	registeredFields = append(registeredFields, FieldConfig{Name: name, Generator: gen})
+}
+
+// RegisterIntField registers a field of integer values
+func RegisterIntField(name string, gen func() int) {
+	if gen == nil {
+		panic("logsimulator: RegisterIntField generator is nil for " + name)
+	}
+	RegisterField(name, func() string { return strconv.Itoa(gen()) })
+}
+
+// RegisterFloatField registers a field of decimal values, formatted with the
+// given number of digits after the point (-1 for as many as needed)
+func RegisterFloatField(name string, decimals int, gen func() float64) {
+	if gen == nil {
+		panic("logsimulator: RegisterFloatField generator is nil for " + name)
+	}
+	RegisterField(name, func() string { return strconv.FormatFloat(gen(), 'f', decimals, 64) })
+}
+
+// RegisterBoolField registers a field of true and false values
+func RegisterBoolField(name string, gen func() bool) {
+	if gen == nil {
+		panic("logsimulator: RegisterBoolField generator is nil for " + name)
+	}
+	RegisterField(name, func() string { return strconv.FormatBool(gen()) })
+}
+
+// GetFields returns the default fields followed by the registered ones, in
+// order of registration
+func GetFields() []FieldConfig {
+	registeredFieldsMu.RLock()
+	defer registeredFieldsMu.RUnlock()
+	fields := make([]FieldConfig, 0, len(defaultFields)+len(registeredFields))
+	fields = append(fields, defaultFields...)
+	return append(fields, registeredFields...)
+}
+
+// lookupField finds a default or registered field; callers must hold
+// registeredFieldsMu
+func lookupField(name string) (FieldConfig, bool) {
+	for _, field := range defaultFields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	for _, field := range registeredFields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return FieldConfig{}, false
+}
@@ -0,0 +1,474 @@
+package logsimulator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// Built-in scenarios
+const (
+	ScenarioRansomware = "ransomware"
+	ScenarioWiper      = "wiper"
+	ScenarioSlowRoll   = "slow-roll"
+
+	ScenarioCompressEncrypt = "compress-encrypt"
+	ScenarioDoubleEncrypt   = "double-encrypt"
+)
+
+// Scenarios lists the built-in scenarios accepted by BuildScenario
+var Scenarios = []string{ScenarioRansomware, ScenarioWiper, ScenarioSlowRoll, ScenarioCompressEncrypt, ScenarioDoubleEncrypt}
+
+// DefaultSlowRollSchedule ramps from no encrypted updates to all of them
+// over two hours of simulated time
+var DefaultSlowRollSchedule = EncryptionSchedule{{At: 0, Percentage: 0}, {At: 2 * time.Hour, Percentage: 100}}
+
+// Defaults for scenarios
+const (
+	DefaultScenarioInterval = time.Second
+	DefaultWipeInterval     = 100 * time.Millisecond
+)
+
+// AttackLabel is the log key holding the scenario name on every log an
+// attack produced, the ground truth for evaluating detectors on simulated
+// data, and AttackFieldsLabel the key listing the fields the attack
+// encrypted, tampered with or destroyed. AttackPlaintextsLabel maps the
+// encrypted fields to their values before encryption. Logs of normal
+// traffic have no label.
+const (
+	AttackLabel           = "attack"
+	AttackFieldsLabel     = "attack_fields"
+	AttackPlaintextsLabel = "attack_plaintexts"
+)
+
+// RandomAttack labels the encrypted updates and DDL events of a run mixing
+// them into the traffic at random, without a scenario
+const RandomAttack = "random"
+
+// Scenario phase actions
+const (
+	PhaseUpdate    = "update"    // Updates, encrypted with the phase's percentage
+	PhaseReencrypt = "reencrypt" // Updates encrypting the current, already encrypted values again
+	PhaseNull      = "null"      // Updates setting one column of each table to null, row by row
+	PhaseDelete    = "delete"    // Deletes of the rows, table by table in turn
+)
+
+// ScenarioPhase is one stage of a scenario's timeline. In update phases the
+// share of encrypted updates ramps linearly from StartPercentage on its first
+// row to EndPercentage on its last, so equal percentages hold it steady.
+type ScenarioPhase struct {
+	Name            string
+	Action          string // One of the phase actions; empty means PhaseUpdate
+	Rows            int
+	StartPercentage int
+	EndPercentage   int
+	Interval        time.Duration // Simulated time between the phase's logs; zero means the scenario's
+	Attack          bool          // Label every log of the phase; otherwise only encrypted updates and DDL events
+}
+
+// Scenario is a scripted timeline of simulated changes. Logs are spaced
+// Interval apart in simulated time from Start, so window, rate and drift
+// detectors see the attack unfold instead of one random mix of updates.
+type Scenario struct {
+	Name       string
+	Phases     []ScenarioPhase
+	Encryption EncryptionConfig   // Cipher of encrypted updates; the phases set the percentage
+	RansomNote bool               // Insert a ransom note row into every table after the last phase
+	NoteTable  string             // Table receiving the ransom note instead of every table
+	Note       string             // Text of the ransom note; empty means a generic note
+	Start      time.Time          // Timestamp of the first log; zero means now
+	Interval   time.Duration      // Simulated time between logs; zero means DefaultScenarioInterval
+	Schedule   EncryptionSchedule // Encryption percentage by simulated time, replacing the update phases' ramps
+
+	// Reencryption is the cipher of a second attacker encrypting the values
+	// again in reencrypt phases; nil means the same algorithm as Encryption
+	Reencryption *EncryptionConfig
+}
+
+// BuildScenario creates the built-in scenario name spread over numRows
+// updates, encrypting with encConfig's cipher
+func BuildScenario(name string, numRows int, encConfig EncryptionConfig) (Scenario, error) {
+	switch name {
+	case ScenarioRansomware:
+		normal := numRows / 2
+		return RansomwareScenario(normal, numRows-normal, encConfig), nil
+	case ScenarioWiper:
+		normal := numRows / 2
+		return WiperScenario(normal, numRows-normal), nil
+	case ScenarioSlowRoll:
+		return ScheduledScenario(numRows, encConfig, DefaultSlowRollSchedule), nil
+	case ScenarioCompressEncrypt:
+		// Ransomware compressing values before encrypting them
+		normal := numRows / 2
+		scenario := RansomwareScenario(normal, numRows-normal, encConfig)
+		scenario.Name = ScenarioCompressEncrypt
+		scenario.Encryption.Compress = true
+		return scenario, nil
+	case ScenarioDoubleEncrypt:
+		// Ransomware whose victims are encrypted again by a second attacker
+		normal := numRows / 3
+		first := numRows / 3
+		scenario := RansomwareScenario(normal, first, encConfig)
+		scenario.Name = ScenarioDoubleEncrypt
+		scenario.Phases = append(scenario.Phases, ScenarioPhase{Name: "re-encryption", Action: PhaseReencrypt, Rows: numRows - normal - first, StartPercentage: 100, EndPercentage: 100})
+		return scenario, nil
+	}
+	return Scenario{}, fmt.Errorf("unknown scenario %q (expected one of %s)", name, strings.Join(Scenarios, ", "))
+}
+
+// RansomwareScenario creates a ransomware timeline: normalRows updates of
+// normal traffic, then attackRows updates in which the encrypted share grows
+// from nothing to every update across all tables, followed by a ransom note
+// row. Without a cipher in encConfig, AES-256-CBC is used.
+func RansomwareScenario(normalRows, attackRows int, encConfig EncryptionConfig) Scenario {
+	if encConfig.Type == "" || encConfig.Type == EncryptionTypeNone {
+		encConfig = EncryptionConfig{Type: EncryptionTypeAES, AESMode: "CBC", KeySize: 32}
+	}
+	return Scenario{
+		Name: ScenarioRansomware,
+		Phases: []ScenarioPhase{
+			{Name: "normal", Rows: normalRows},
+			{Name: "encryption", Rows: attackRows, StartPercentage: 0, EndPercentage: 100},
+		},
+		Encryption: encConfig,
+		RansomNote: true,
+	}
+}
+
+// WiperScenario creates a wiper timeline: normalRows updates of normal
+// traffic, then wipeRows destructive changes DefaultWipeInterval apart, so a
+// few thousand land within minutes. The first half of the wipe nulls one
+// column of each table row by row, and the second half deletes the rows.
+func WiperScenario(normalRows, wipeRows int) Scenario {
+	nulls := wipeRows / 2
+	return Scenario{
+		Name: ScenarioWiper,
+		Phases: []ScenarioPhase{
+			{Name: "normal", Rows: normalRows},
+			{Name: "null", Action: PhaseNull, Rows: nulls, Interval: DefaultWipeInterval, Attack: true},
+			{Name: "delete", Action: PhaseDelete, Rows: wipeRows - nulls, Interval: DefaultWipeInterval, Attack: true},
+		},
+	}
+}
+
+// ScheduledScenario creates a slow-roll timeline of numRows updates whose
+// encryption percentage follows schedule, spacing them so the run spans the
+// whole schedule. Without a cipher in encConfig, AES-256-CBC is used.
+func ScheduledScenario(numRows int, encConfig EncryptionConfig, schedule EncryptionSchedule) Scenario {
+	if encConfig.Type == "" || encConfig.Type == EncryptionTypeNone {
+		encConfig = EncryptionConfig{Type: EncryptionTypeAES, AESMode: "CBC", KeySize: 32}
+	}
+	var interval time.Duration
+	if numRows > 1 {
+		interval = schedule.Duration() / time.Duration(numRows-1)
+	}
+	return Scenario{
+		Name:       ScenarioSlowRoll,
+		Phases:     []ScenarioPhase{{Name: "encryption", Rows: numRows}},
+		Encryption: encConfig,
+		Interval:   interval,
+		Schedule:   schedule,
+	}
+}
+
+// percentage returns the share of encrypted updates on row i of the phase
+func (p ScenarioPhase) percentage(i int) int {
+	if p.Rows <= 1 {
+		return p.EndPercentage
+	}
+	return p.StartPercentage + (p.EndPercentage-p.StartPercentage)*i/(p.Rows-1)
+}
+
+// StreamScenarioLogs generates the logs of scenario on the tables of schema,
+// taking the tables in turn like StreamSchemaLogs. ddlConfig and editConfig
+// apply throughout the timeline.
+func StreamScenarioLogs(ctx context.Context, dbType string, schema *Schema, scenario Scenario, ddlConfig DDLConfig, editConfig EditConfig) (<-chan interface{}, error) {
+	if len(schema.Tables) == 0 {
+		return nil, fmt.Errorf("schema has no tables")
+	}
+	total := 0
+	for _, phase := range scenario.Phases {
+		total += phase.Rows
+	}
+	fields, err := schema.tableFields((total-1)/len(schema.Tables) + 1)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([][]string, len(schema.Tables))
+	for i, tableFields := range fields {
+		for _, field := range tableFields {
+			columns[i] = append(columns[i], field.Name)
+		}
+	}
+	start := scenario.Start
+	if start.IsZero() {
+		start = time.Now()
+	}
+	interval := scenario.Interval
+	if interval <= 0 {
+		interval = DefaultScenarioInterval
+	}
+
+	// The second attacker of reencrypt phases holds keys of its own
+	reencryption := scenario.Encryption
+	if scenario.Reencryption != nil {
+		reencryption = *scenario.Reencryption
+	}
+	reencryption.Keys = NewKeyring()
+	previous := scenario.Encryption
+	previous.Percentage = 100
+	reencryption.Previous = &previous
+
+	logs := make(chan interface{}, 64)
+	go func() {
+		defer close(logs)
+		n := 0
+		ts := start
+		// tick advances the simulated time to the next log
+		tick := func(step time.Duration) {
+			if n > 0 {
+				ts = ts.Add(step)
+			}
+			n++
+		}
+		send := func(log interface{}, attack bool, fields []string) bool {
+			setTimestamp(log, ts)
+			if attack {
+				setLabel(log, scenario.Name, fields)
+			}
+			select {
+			case logs <- log:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// rowOf returns the identifier and current values of the index'th row
+		// of table t, wrapping around its population if it has one
+		rows := make(population)
+		rowOf := func(t int, index int) (string, map[string]interface{}) {
+			table := schema.Tables[t]
+			if table.Rows == 0 {
+				return fmt.Sprintf("row%d", index+1), nil
+			}
+			rowID := fmt.Sprintf("row%d", index%table.Rows+1)
+			return rowID, rows.row(table.Name, rowID)
+		}
+
+		for _, phase := range scenario.Phases {
+			step := phase.Interval
+			if step <= 0 {
+				step = interval
+			}
+			encConfig := scenario.Encryption
+			if phase.Action == PhaseReencrypt {
+				encConfig = reencryption
+			}
+			// Null phases wipe one randomly chosen column of each table
+			var wiped []FieldConfig
+			if phase.Action == PhaseNull {
+				for t := range schema.Tables {
+					wiped = append(wiped, fields[t][rand.Intn(len(fields[t]))])
+				}
+			}
+			for i := 0; i < phase.Rows; i++ {
+				tick(step)
+				t := i % len(schema.Tables)
+				table := schema.Tables[t].Name
+				var log interface{}
+				var destroyed []string
+				switch phase.Action {
+				case PhaseNull:
+					rowID, current := rowOf(t, i/len(schema.Tables))
+					log = generateNullLog(dbType, table, rowID, current, wiped[t])
+				case PhaseDelete:
+					rowID, current := rowOf(t, i/len(schema.Tables))
+					log = generateDeleteLog(dbType, table, rowID, current, columns[t], fields[t])
+					rows.remove(table, rowID)
+				default:
+					t = (n - 1) % len(schema.Tables)
+					table = schema.Tables[t].Name
+					encConfig.Percentage = phase.percentage(i)
+					if len(scenario.Schedule) > 0 {
+						encConfig.Percentage = scenario.Schedule.Percentage(ts.Sub(start))
+					}
+					rowID, current := rowOf(t, (n-1)/len(schema.Tables))
+					if phase.Action == PhaseReencrypt {
+						// The second attacker sweeps the rows in order
+						rowID, current = rowOf(t, i/len(schema.Tables))
+					} else if size := schema.Tables[t].Rows; size > 0 {
+						rowID, current = rows.pick(table, size)
+					}
+					log, destroyed = generateLog(dbType, table, rowID, current, columns[t], fields[t], encConfig, ddlConfig, editConfig)
+				}
+				if !send(log, phase.Attack || len(destroyed) > 0, destroyed) {
+					return
+				}
+			}
+		}
+		if scenario.RansomNote {
+			note := scenario.Note
+			if note == "" {
+				note = ransomNote()
+			}
+			if scenario.NoteTable != "" {
+				tick(interval)
+				send(generateInsertLog(dbType, scenario.NoteTable, "ransom_note", []string{"note"}, note), true, nil)
+				return
+			}
+			for t, table := range schema.Tables {
+				tick(interval)
+				if !send(generateInsertLog(dbType, table.Name, "ransom_note", columns[t], note), true, nil) {
+					return
+				}
+			}
+		}
+	}()
+	return logs, nil
+}
+
+// GenerateOracleInsertLog creates a mock log entry for an Oracle INSERT operation
+func GenerateOracleInsertLog(table string, rowID string, columns []string, after map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"action":          "INSERT",
+		"table_name":      table,
+		"rowid":           rowID,
+		"changed_columns": columns,
+		"timestamp":       time.Now(),
+		"after_values":    after,
+	}
+}
+
+// GeneratePostgresInsertLog creates a mock log entry for a PostgreSQL INSERT operation
+func GeneratePostgresInsertLog(table string, primaryKey string, columns []string, after map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"operation":       "INSERT",
+		"table":           table,
+		"primary_key":     primaryKey,
+		"changed_columns": columns,
+		"timestamp":       time.Now(),
+		"new_values":      after,
+	}
+}
+
+// GenerateOracleDeleteLog creates a mock log entry for an Oracle DELETE operation
+func GenerateOracleDeleteLog(table string, rowID string, columns []string, before map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"action":          "DELETE",
+		"table_name":      table,
+		"rowid":           rowID,
+		"changed_columns": columns,
+		"timestamp":       time.Now(),
+		"before_values":   before,
+	}
+}
+
+// GeneratePostgresDeleteLog creates a mock log entry for a PostgreSQL DELETE operation
+func GeneratePostgresDeleteLog(table string, primaryKey string, columns []string, before map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"operation":       "DELETE",
+		"table":           table,
+		"primary_key":     primaryKey,
+		"changed_columns": columns,
+		"timestamp":       time.Now(),
+		"old_values":      before,
+	}
+}
+
+// generateDeleteLog creates a DELETE of a row with its current values, or
+// fresh values when current is nil
+func generateDeleteLog(dbType string, table string, rowID string, current map[string]interface{}, columns []string, fields []FieldConfig) interface{} {
+	before := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		value, ok := current[field.Name]
+		if !ok {
+			value = field.Generator()
+		}
+		before[field.Name] = value
+	}
+	var log interface{}
+	if dbType == "oracle" {
+		log = GenerateOracleDeleteLog(table, rowID, columns, before)
+	} else if dbType == "postgres" {
+		log = GeneratePostgresDeleteLog(table, rowID, columns, before)
+	}
+	return log
+}
+
+// generateNullLog creates an UPDATE setting field of a row to null, updating
+// the row's current values unless current is nil
+func generateNullLog(dbType string, table string, rowID string, current map[string]interface{}, field FieldConfig) interface{} {
+	columns := []string{field.Name}
+	value, ok := current[field.Name]
+	if !ok {
+		value = field.Generator()
+	}
+	before := map[string]interface{}{field.Name: value}
+	after := map[string]interface{}{field.Name: nil}
+	if current != nil {
+		current[field.Name] = nil
+	}
+	var log interface{}
+	if dbType == "oracle" {
+		log = GenerateOracleUpdateLog(table, rowID, columns, before, after)
+	} else if dbType == "postgres" {
+		log = GeneratePostgresUpdateLog(table, rowID, columns, before, after)
+	}
+	return log
+}
+
+// generateInsertLog creates an INSERT of a row holding value in every column
+func generateInsertLog(dbType string, table string, rowID string, columns []string, value string) interface{} {
+	after := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		after[column] = value
+	}
+	var log interface{}
+	if dbType == "oracle" {
+		log = GenerateOracleInsertLog(table, rowID, columns, after)
+	} else if dbType == "postgres" {
+		log = GeneratePostgresInsertLog(table, rowID, columns, after)
+	}
+	return log
+}
+
+// ransomNote returns the text of a ransom note demanding payment in bitcoin
+func ransomNote() string {
+	return fmt.Sprintf("YOUR DATA HAS BEEN ENCRYPTED. To recover it, send %.2f BTC to %s and email your ID %s to %s. Do not try to restore from backups.",
+		gofakeit.Float64Range(0.5, 5), gofakeit.BitcoinAddress(), gofakeit.UUID(), gofakeit.Email())
+}
+
+// setLabel marks a generated log as produced by the attack of scenario,
+// which hit fields, or when fields is empty those of attackedFields
+func setLabel(log interface{}, scenario string, fields []string) {
+	if m, ok := log.(map[string]interface{}); ok {
+		if len(fields) == 0 {
+			fields = attackedFields(log)
+		}
+		m[AttackLabel] = scenario
+		m[AttackFieldsLabel] = fields
+	}
+}
+
+// attackedFields returns the fields a log changes: its changed columns, or
+// * for DDL affecting the whole table
+func attackedFields(log interface{}) []string {
+	if m, ok := log.(map[string]interface{}); ok {
+		if columns, _ := m["changed_columns"].([]string); len(columns) > 0 {
+			return append([]string(nil), columns...)
+		}
+	}
+	return []string{"*"}
+}
+
+// setTimestamp replaces the timestamp of a generated log
+func setTimestamp(log interface{}, ts time.Time) {
+	if m, ok := log.(map[string]interface{}); ok {
+		m["timestamp"] = ts
+	}
+}
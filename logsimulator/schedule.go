@@ -0,0 +1,82 @@
+package logsimulator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduleStep sets the encryption percentage reached At simulated time
+// after the start of a run
+type ScheduleStep struct {
+	At         time.Duration
+	Percentage int
+}
+
+// EncryptionSchedule varies the share of encrypted updates over simulated
+// time, interpolating linearly between its steps, so slow-roll attacks that
+// stay below a flat threshold can be simulated
+type EncryptionSchedule []ScheduleStep
+
+// ParseEncryptionSchedule parses a schedule of comma-separated
+// offset:percentage steps in increasing offset order, e.g. "0s:0,2h:100"
+// ramps from no encrypted updates to all of them over two hours
+func ParseEncryptionSchedule(s string) (EncryptionSchedule, error) {
+	var schedule EncryptionSchedule
+	for _, part := range strings.Split(s, ",") {
+		at, pct, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("schedule step %q: expected offset:percentage", part)
+		}
+		offset, err := time.ParseDuration(at)
+		if err != nil {
+			return nil, fmt.Errorf("schedule step %q: %w", part, err)
+		}
+		percentage, err := strconv.Atoi(strings.TrimSuffix(pct, "%"))
+		if err != nil || percentage < 0 || percentage > 100 {
+			return nil, fmt.Errorf("schedule step %q: percentage must be between 0 and 100", part)
+		}
+		if n := len(schedule); offset < 0 || (n > 0 && offset <= schedule[n-1].At) {
+			return nil, fmt.Errorf("schedule step %q: offsets must be increasing", part)
+		}
+		schedule = append(schedule, ScheduleStep{At: offset, Percentage: percentage})
+	}
+	return schedule, nil
+}
+
+// String formats the schedule as ParseEncryptionSchedule accepts it
+func (s EncryptionSchedule) String() string {
+	parts := make([]string, len(s))
+	for i, step := range s {
+		parts[i] = fmt.Sprintf("%s:%d", step.At, step.Percentage)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Duration returns the offset of the last step
+func (s EncryptionSchedule) Duration() time.Duration {
+	if len(s) == 0 {
+		return 0
+	}
+	return s[len(s)-1].At
+}
+
+// Percentage returns the encryption percentage elapsed after the start,
+// holding the first step's before it and the last step's after it
+func (s EncryptionSchedule) Percentage(elapsed time.Duration) int {
+	if len(s) == 0 {
+		return 0
+	}
+	if elapsed <= s[0].At {
+		return s[0].Percentage
+	}
+	for i := 1; i < len(s); i++ {
+		if elapsed <= s[i].At {
+			prev, next := s[i-1], s[i]
+			frac := float64(elapsed-prev.At) / float64(next.At-prev.At)
+			return prev.Percentage + int(frac*float64(next.Percentage-prev.Percentage))
+		}
+	}
+	return s[len(s)-1].Percentage
+}
@@ -0,0 +1,468 @@
+package logsimulator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"gopkg.in/yaml.v3"
+)
+
+// Schema describes the tables a simulation generates changes for, as written
+// in a schema file (YAML or JSON):
+//
+//	tables:
+//	  - name: accounts
+//	    columns:
+//	      - name: iban
+//	        type: pattern
+//	        pattern: "GB## ???? ######## ##"
+//	      - name: balance
+//	        type: number
+//	        min: 0
+//	        max: 50000
+//	      - name: status
+//	        type: enum
+//	        values: [open, frozen, closed]
+//	    correlations:
+//	      - fields: [balance, status]
+//	  - name: payments
+//	    columns:
+//	      - name: account_id
+//	        type: reference
+//	        references: accounts
+type Schema struct {
+	Tables []TableSchema `json:"tables" yaml:"tables"`
+}
+
+// TableSchema is a simulated table, the columns its updates change and the
+// columns that change together (see FieldCorrelation). With Rows, updates
+// pick one of that many rows at random and evolve its values, so rows have
+// histories; otherwise every update is of a fresh row.
+type TableSchema struct {
+	Name         string             `json:"name" yaml:"name"`
+	Rows         int                `json:"rows,omitempty" yaml:"rows,omitempty"`
+	Columns      []ColumnSchema     `json:"columns" yaml:"columns"`
+	Correlations []FieldCorrelation `json:"correlations,omitempty" yaml:"correlations,omitempty"`
+}
+
+// ColumnSchema is a simulated column: its name, the type of value generated
+// for it (see ColumnTypes) and the type's parameters
+type ColumnSchema struct {
+	Name     string   `json:"name" yaml:"name"`
+	Type     string   `json:"type" yaml:"type"`
+	Min      *float64 `json:"min,omitempty" yaml:"min,omitempty"`           // integer and number: smallest value (default 0)
+	Max      *float64 `json:"max,omitempty" yaml:"max,omitempty"`           // integer and number: largest value (default 1000)
+	Decimals *int     `json:"decimals,omitempty" yaml:"decimals,omitempty"` // number: digits after the point (default 2)
+	Words    int      `json:"words,omitempty" yaml:"words,omitempty"`       // sentence: words per value (default 5)
+	Values   []string `json:"values,omitempty" yaml:"values,omitempty"`     // enum: values to choose from
+	Pattern  string   `json:"pattern,omitempty" yaml:"pattern,omitempty"`   // pattern: # becomes a digit, ? a letter; regex: the expression
+
+	References string `json:"references,omitempty" yaml:"references,omitempty"` // reference: table whose row identifiers the column holds
+
+	ChangeProbability *float64 `json:"change_probability,omitempty" yaml:"change_probability,omitempty"` // Chance of the column changing in an update (default the run's)
+}
+
+// columnTypes maps column types to generators of values with no parameters
+var columnTypes = map[string]func() string{
+	"word":       gofakeit.Word,
+	"name":       gofakeit.Name,
+	"first_name": gofakeit.FirstName,
+	"last_name":  gofakeit.LastName,
+	"email":      gofakeit.Email,
+	"phone":      gofakeit.Phone,
+	"address":    func() string { return gofakeit.Address().Address },
+	"city":       gofakeit.City,
+	"country":    gofakeit.Country,
+	"company":    gofakeit.Company,
+	"job_title":  gofakeit.JobTitle,
+	"username":   gofakeit.Username,
+	"bool":       func() string { return strconv.FormatBool(gofakeit.Bool()) },
+}
+
+// DefaultReferenceRows is the number of rows a reference column picks among
+// when the size of the referenced table is unknown
+const DefaultReferenceRows = 1000
+
+// ColumnTypes lists the column types a schema can use: the types in
+// columnTypes, sentence, integer, number, enum, pattern, regex and
+// reference, which take parameters, and the names of the default and
+// registered fields
+func ColumnTypes() []string {
+	types := []string{"sentence", "integer", "number", "enum", "pattern", "regex", "reference"}
+	for name := range columnTypes {
+		types = append(types, name)
+	}
+	for _, field := range GetFields() {
+		if _, ok := columnTypes[field.Name]; !ok {
+			types = append(types, field.Name)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// LoadSchemaFile reads and validates a schema file
+func LoadSchemaFile(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var schema Schema
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := schema.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &schema, nil
+}
+
+// Validate checks that the schema has tables, that their names and their
+// columns' names are unique and set, that every column's type and
+// parameters are valid (see ColumnTypes), that correlations name columns of
+// their table and that references name tables of the schema
+func (s *Schema) Validate() error {
+	if len(s.Tables) == 0 {
+		return fmt.Errorf("schema has no tables")
+	}
+	tables := make(map[string]bool)
+	for _, table := range s.Tables {
+		if table.Name == "" {
+			return fmt.Errorf("table name is required")
+		}
+		if tables[table.Name] {
+			return fmt.Errorf("duplicate table %q", table.Name)
+		}
+		if table.Rows < 0 {
+			return fmt.Errorf("table %q: rows must not be negative", table.Name)
+		}
+		tables[table.Name] = true
+		if len(table.Columns) == 0 {
+			return fmt.Errorf("table %q has no columns", table.Name)
+		}
+		if _, err := table.Fields(); err != nil {
+			return err
+		}
+		columns := make(map[string]bool, len(table.Columns))
+		for _, column := range table.Columns {
+			columns[column.Name] = true
+		}
+		for _, correlation := range table.Correlations {
+			if err := correlation.Validate(); err != nil {
+				return fmt.Errorf("table %q: %w", table.Name, err)
+			}
+			for _, name := range append(append([]string(nil), correlation.Fields...), correlation.Then...) {
+				if !columns[name] {
+					return fmt.Errorf("table %q: correlation names unknown column %q", table.Name, name)
+				}
+			}
+		}
+	}
+	for _, table := range s.Tables {
+		for _, column := range table.Columns {
+			if column.Type == "reference" && !tables[column.References] {
+				return fmt.Errorf("table %q: column %q references unknown table %q", table.Name, column.Name, column.References)
+			}
+		}
+	}
+	return nil
+}
+
+// Fields returns the field configurations generating the table's columns
+func (t TableSchema) Fields() ([]FieldConfig, error) {
+	return t.fields(nil)
+}
+
+// fields returns the field configurations generating the table's columns,
+// reference columns picking among the number of rows sizes gives the
+// referenced table
+func (t TableSchema) fields(sizes map[string]int) ([]FieldConfig, error) {
+	fields := make([]FieldConfig, 0, len(t.Columns))
+	seen := make(map[string]bool)
+	for _, column := range t.Columns {
+		if column.Name == "" {
+			return nil, fmt.Errorf("table %q: column name is required", t.Name)
+		}
+		if seen[column.Name] {
+			return nil, fmt.Errorf("table %q: duplicate column %q", t.Name, column.Name)
+		}
+		seen[column.Name] = true
+		field, err := column.field(sizes)
+		if err != nil {
+			return nil, fmt.Errorf("table %q: %w", t.Name, err)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// Field returns the field configuration generating the column's values. A
+// type naming a default or registered field (see RegisterField) uses that
+// field's generator, and a reference picks among DefaultReferenceRows rows.
+func (c ColumnSchema) Field() (FieldConfig, error) {
+	return c.field(nil)
+}
+
+// field returns the field configuration generating the column's values, a
+// reference picking among the number of rows sizes gives the referenced
+// table
+func (c ColumnSchema) field(sizes map[string]int) (FieldConfig, error) {
+	if p := c.ChangeProbability; p != nil && (*p < 0 || *p > 1) {
+		return FieldConfig{}, fmt.Errorf("column %q: change_probability must be between 0 and 1", c.Name)
+	}
+	if field, ok := GetFieldByName(c.Type); ok {
+		return FieldConfig{Name: c.Name, Generator: field.Generator}, nil
+	}
+	if generator, ok := columnTypes[c.Type]; ok {
+		return FieldConfig{Name: c.Name, Generator: generator}, nil
+	}
+
+	min, max := 0.0, 1000.0
+	if c.Min != nil {
+		min = *c.Min
+	}
+	if c.Max != nil {
+		max = *c.Max
+	}
+	var generator func() string
+	switch c.Type {
+	case "sentence":
+		words := c.Words
+		if words <= 0 {
+			words = 5
+		}
+		generator = func() string { return gofakeit.Sentence(words) }
+	case "integer":
+		if max < min {
+			return FieldConfig{}, fmt.Errorf("column %q: max is below min", c.Name)
+		}
+		low, high := int(min), int(max)
+		generator = func() string { return strconv.Itoa(gofakeit.IntRange(low, high)) }
+	case "number":
+		if max < min {
+			return FieldConfig{}, fmt.Errorf("column %q: max is below min", c.Name)
+		}
+		decimals := 2
+		if c.Decimals != nil {
+			decimals = *c.Decimals
+		}
+		generator = func() string {
+			return strconv.FormatFloat(gofakeit.Float64Range(min, max), 'f', decimals, 64)
+		}
+	case "enum":
+		if len(c.Values) == 0 {
+			return FieldConfig{}, fmt.Errorf("column %q: enum requires values", c.Name)
+		}
+		values := c.Values
+		generator = func() string { return values[rand.Intn(len(values))] }
+	case "pattern":
+		if c.Pattern == "" {
+			return FieldConfig{}, fmt.Errorf("column %q: pattern requires a pattern", c.Name)
+		}
+		pattern := c.Pattern
+		generator = func() string { return gofakeit.Numerify(gofakeit.Lexify(pattern)) }
+	case "regex":
+		if c.Pattern == "" {
+			return FieldConfig{}, fmt.Errorf("column %q: regex requires a pattern", c.Name)
+		}
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return FieldConfig{}, fmt.Errorf("column %q: %w", c.Name, err)
+		}
+		pattern := c.Pattern
+		generator = func() string { return gofakeit.Regex(pattern) }
+	case "reference":
+		if c.References == "" {
+			return FieldConfig{}, fmt.Errorf("column %q: reference requires references", c.Name)
+		}
+		size := sizes[c.References]
+		if size <= 0 {
+			size = DefaultReferenceRows
+		}
+		generator = func() string { return fmt.Sprintf("row%d", rand.Intn(size)+1) }
+		return FieldConfig{Name: c.Name, Generator: generator, Reference: c.References}, nil
+	case "":
+		return FieldConfig{}, fmt.Errorf("column %q: type is required", c.Name)
+	default:
+		return FieldConfig{}, fmt.Errorf("column %q: unknown type %q", c.Name, c.Type)
+	}
+	return FieldConfig{Name: c.Name, Generator: generator}, nil
+}
+
+// ColumnNames returns the distinct column names of every table, in order of
+// first appearance
+func (s *Schema) ColumnNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, table := range s.Tables {
+		for _, column := range table.Columns {
+			if !seen[column.Name] {
+				seen[column.Name] = true
+				names = append(names, column.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Select returns the schema restricted to the named columns, dropping tables
+// left without any
+func (s *Schema) Select(columns []string) *Schema {
+	selected := make(map[string]bool, len(columns))
+	for _, name := range columns {
+		selected[name] = true
+	}
+	restricted := &Schema{}
+	for _, table := range s.Tables {
+		kept := TableSchema{Name: table.Name, Rows: table.Rows, Correlations: table.Correlations}
+		for _, column := range table.Columns {
+			if selected[column.Name] {
+				kept.Columns = append(kept.Columns, column)
+			}
+		}
+		if len(kept.Columns) > 0 {
+			restricted.Tables = append(restricted.Tables, kept)
+		}
+	}
+	return restricted
+}
+
+// AddColumns adds columns to the first table, such as custom fields entered
+// in the TUI, replacing columns of the same name
+func (s *Schema) AddColumns(columns []ColumnSchema) error {
+	if len(columns) == 0 {
+		return nil
+	}
+	if len(s.Tables) == 0 {
+		return fmt.Errorf("schema has no tables")
+	}
+	table := &s.Tables[0]
+	for _, column := range columns {
+		if column.Name == "" {
+			return fmt.Errorf("column name is required")
+		}
+		if _, err := column.Field(); err != nil {
+			return err
+		}
+		replaced := false
+		for i := range table.Columns {
+			if table.Columns[i].Name == column.Name {
+				table.Columns[i] = column
+				replaced = true
+			}
+		}
+		if !replaced {
+			table.Columns = append(table.Columns, column)
+		}
+	}
+	return nil
+}
+
+// ChangeProbabilities returns the change probabilities set on columns, keyed
+// by table.column as EditConfig.FieldProbabilities takes them
+func (s *Schema) ChangeProbabilities() map[string]float64 {
+	probabilities := make(map[string]float64)
+	for _, table := range s.Tables {
+		for _, column := range table.Columns {
+			if column.ChangeProbability != nil {
+				probabilities[table.Name+"."+column.Name] = *column.ChangeProbability
+			}
+		}
+	}
+	return probabilities
+}
+
+// Correlations returns the correlations of the tables, each applying to its
+// own table
+func (s *Schema) Correlations() []FieldCorrelation {
+	var correlations []FieldCorrelation
+	for _, table := range s.Tables {
+		for _, correlation := range table.Correlations {
+			correlation.Table = table.Name
+			correlations = append(correlations, correlation)
+		}
+	}
+	return correlations
+}
+
+// tableFields returns the field configurations of every table. Reference
+// columns pick among the rows of the referenced table: its Rows, or rows
+// when it has none, the number of rows it gets in the run.
+func (s *Schema) tableFields(rows int) ([][]FieldConfig, error) {
+	sizes := make(map[string]int, len(s.Tables))
+	for _, table := range s.Tables {
+		sizes[table.Name] = table.Rows
+		if table.Rows == 0 {
+			sizes[table.Name] = rows
+		}
+	}
+	fields := make([][]FieldConfig, len(s.Tables))
+	for i, table := range s.Tables {
+		tableFields, err := table.fields(sizes)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = tableFields
+	}
+	return fields, nil
+}
+
+// DefaultSchema returns the users table with a column for each default and
+// registered field, simulated when no schema file is given
+func DefaultSchema() *Schema {
+	table := TableSchema{Name: "users"}
+	for _, field := range GetFields() {
+		table.Columns = append(table.Columns, ColumnSchema{Name: field.Name, Type: field.Name})
+	}
+	return &Schema{Tables: []TableSchema{table}}
+}
+
+// StreamSchemaLogs generates numRows mock update logs like StreamLogs, taking
+// the tables from schema in turn. Each table numbers its rows separately,
+// and reference columns hold identifiers of rows of the referenced table.
+func StreamSchemaLogs(ctx context.Context, dbType string, schema *Schema, numRows int, encConfig EncryptionConfig, ddlConfig DDLConfig, editConfig EditConfig, clockConfig ClockConfig) (<-chan interface{}, error) {
+	if len(schema.Tables) == 0 {
+		return nil, fmt.Errorf("schema has no tables")
+	}
+	fields, err := schema.tableFields((numRows-1)/len(schema.Tables) + 1)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([][]string, len(schema.Tables))
+	for i, tableFields := range fields {
+		for _, field := range tableFields {
+			columns[i] = append(columns[i], field.Name)
+		}
+	}
+
+	logs := make(chan interface{}, 64)
+	clock := NewClock(clockConfig)
+	rows := make(population)
+	go func() {
+		defer close(logs)
+		for i := 0; i < numRows; i++ {
+			t := i % len(schema.Tables)
+			table := schema.Tables[t]
+			rowID, current := fmt.Sprintf("row%d", i/len(schema.Tables)+1), map[string]interface{}(nil)
+			if table.Rows > 0 {
+				rowID, current = rows.pick(table.Name, table.Rows)
+			}
+			log, destroyed := generateLog(dbType, table.Name, rowID, current, columns[t], fields[t], encConfig, ddlConfig, editConfig)
+			setTimestamp(log, clock.Next())
+			if len(destroyed) > 0 {
+				setLabel(log, RandomAttack, destroyed)
+			}
+			select {
+			case logs <- log:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return logs, nil
+}
@@ -0,0 +1,72 @@
+package logsimulator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+)
+
+// TransactionConfig defines how a stream's changes are grouped into
+// transactions, as applications change several rows in one transaction
+type TransactionConfig struct {
+	Size int // Average changes per transaction; zero or one gives every change its own
+}
+
+// transaction is an open transaction and the changes it has left
+type transaction struct {
+	id        uint64
+	remaining int
+}
+
+// GroupTransactions passes on the logs of stream, grouping consecutive
+// changes into transactions of random size averaging config.Size and
+// recording each change's transaction as its database logs it: Oracle logs
+// carry the transaction's xid and commit scn, PostgreSQL logs its xid and
+// the change's lsn. Changes of different actors, told apart by their attack
+// label, never share a transaction.
+func GroupTransactions(ctx context.Context, stream <-chan interface{}, dbType string, config TransactionConfig) <-chan interface{} {
+	logs := make(chan interface{}, 64)
+	go func() {
+		defer close(logs)
+		open := make(map[string]*transaction)
+		next := uint64(1000 + rand.Intn(9000))
+		lsn := uint64(0x1000000 + rand.Intn(0x1000000))
+		for log := range stream {
+			m, ok := log.(map[string]interface{})
+			if ok {
+				label, _ := m[AttackLabel].(string)
+				txn := open[label]
+				if txn == nil || txn.remaining == 0 {
+					txn = &transaction{id: next, remaining: 1}
+					if config.Size > 1 {
+						txn.remaining = 1 + rand.Intn(2*config.Size-1)
+					}
+					open[label] = txn
+					next++
+				}
+				txn.remaining--
+				lsn += uint64(64 + rand.Intn(512))
+				setTransaction(m, dbType, txn.id, lsn)
+			}
+			select {
+			case logs <- log:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return logs
+}
+
+// setTransaction records the transaction of a log in its database's fields
+func setTransaction(log map[string]interface{}, dbType string, id uint64, lsn uint64) {
+	switch dbType {
+	case "oracle":
+		// XIDs print the undo segment, slot and sequence of the transaction
+		log["xid"] = fmt.Sprintf("%04X%04X%08X", id%10+1, id%32, id)
+		log["scn"] = 1000000 + id
+	case "postgres":
+		log["xid"] = id
+		log["lsn"] = fmt.Sprintf("%X/%X", lsn>>32, lsn&0xFFFFFFFF)
+	}
+}
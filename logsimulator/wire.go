@@ -0,0 +1,244 @@
+package logsimulator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WireFormat is a format real change data capture tools write changes in
+type WireFormat string
+
+// Wire formats simulated logs can be written in
+const (
+	WireFormatNative   WireFormat = "native"   // The simulator's own log maps
+	WireFormatDebezium WireFormat = "debezium" // Debezium change event values, with schemas disabled
+	WireFormatWal2JSON WireFormat = "wal2json" // wal2json format-version 2, with include-xids and include-timestamp
+)
+
+// WireFormats lists the formats simulated logs can be written in
+var WireFormats = []WireFormat{WireFormatNative, WireFormatDebezium, WireFormatWal2JSON}
+
+// DebeziumVersion is the Debezium release simulated change events claim to
+// come from
+const DebeziumVersion = "2.5.0.Final"
+
+// KeyColumn names the key column added to row images in wire formats, which
+// carries the simulated row identifier
+const KeyColumn = "id"
+
+// WireEncoder converts simulated logs of one database type to a wire format.
+// Encoders of stateful formats keep track of the open transaction.
+type WireEncoder struct {
+	format WireFormat
+	dbType string
+	xid    interface{} // Transaction of the last wal2json change
+	ts     time.Time   // Timestamp of the last wal2json change
+	open   bool        // A wal2json transaction is open
+}
+
+// NewWireEncoder creates an encoder of dbType logs to format. wal2json only
+// decodes PostgreSQL.
+func NewWireEncoder(format WireFormat, dbType string) (*WireEncoder, error) {
+	switch format {
+	case WireFormatNative, WireFormatDebezium:
+	case WireFormatWal2JSON:
+		if dbType != "postgres" {
+			return nil, fmt.Errorf("wal2json output requires postgres logs, not %s", dbType)
+		}
+	default:
+		return nil, fmt.Errorf("unknown wire format %q", format)
+	}
+	return &WireEncoder{format: format, dbType: dbType}, nil
+}
+
+// Encode returns the messages a log is written as: none for changes the
+// format cannot express, several when a wal2json transaction ends or
+// begins. Logs that are not maps, such as injected noise, pass as they are.
+func (e *WireEncoder) Encode(log interface{}) []interface{} {
+	m, ok := log.(map[string]interface{})
+	if !ok || e.format == WireFormatNative {
+		return []interface{}{log}
+	}
+	change := nativeChange(m)
+	if e.format == WireFormatDebezium {
+		return []interface{}{e.debezium(change)}
+	}
+	return e.wal2json(change)
+}
+
+// Close returns the messages ending the stream: the commit of an open
+// wal2json transaction
+func (e *WireEncoder) Close() []interface{} {
+	if !e.open {
+		return nil
+	}
+	e.open = false
+	return []interface{}{e.wal2jsonMarker("C")}
+}
+
+// change is a simulated log in a database-neutral form
+type change struct {
+	operation string
+	table     string
+	key       string
+	before    map[string]interface{}
+	after     map[string]interface{}
+	timestamp time.Time
+	xid       interface{}
+	position  interface{} // Oracle SCN or PostgreSQL LSN
+	statement string
+}
+
+// nativeChange reads a simulated Oracle or PostgreSQL log
+func nativeChange(m map[string]interface{}) change {
+	var c change
+	c.timestamp, _ = m["timestamp"].(time.Time)
+	c.xid = m["xid"]
+	if _, oracle := m["action"]; oracle {
+		c.operation, _ = m["action"].(string)
+		c.table, _ = m["table_name"].(string)
+		c.key, _ = m["rowid"].(string)
+		c.before, _ = m["before_values"].(map[string]interface{})
+		c.after, _ = m["after_values"].(map[string]interface{})
+		c.position = m["scn"]
+		c.statement, _ = m["sql_text"].(string)
+		return c
+	}
+	c.operation, _ = m["operation"].(string)
+	c.table, _ = m["table"].(string)
+	c.key, _ = m["primary_key"].(string)
+	c.before, _ = m["old_values"].(map[string]interface{})
+	c.after, _ = m["new_values"].(map[string]interface{})
+	c.position = m["lsn"]
+	c.statement, _ = m["statement"].(string)
+	return c
+}
+
+// debezium returns the Debezium change event value of a change: a row event
+// for DML and TRUNCATE, a schema change event for other DDL
+func (e *WireEncoder) debezium(c change) map[string]interface{} {
+	source := map[string]interface{}{
+		"version":  DebeziumVersion,
+		"name":     "simulator",
+		"ts_ms":    c.timestamp.UnixMilli(),
+		"snapshot": "false",
+		"table":    c.table,
+		"txId":     c.xid,
+	}
+	if e.dbType == "oracle" {
+		source["connector"] = "oracle"
+		source["db"] = "ORCLCDB"
+		source["schema"] = "SIMULATOR"
+		source["scn"] = fmt.Sprint(c.position)
+		source["commit_scn"] = fmt.Sprint(c.position)
+	} else {
+		source["connector"] = "postgresql"
+		source["db"] = "postgres"
+		source["schema"] = "public"
+		source["lsn"] = c.position
+	}
+
+	var op string
+	switch c.operation {
+	case "INSERT":
+		op = "c"
+	case "UPDATE":
+		op = "u"
+	case "DELETE":
+		op = "d"
+	case DDLTruncate, "TRUNCATE TABLE":
+		op = "t"
+	default:
+		return map[string]interface{}{
+			"source":       source,
+			"ts_ms":        c.timestamp.UnixMilli(),
+			"databaseName": source["db"],
+			"schemaName":   source["schema"],
+			"ddl":          c.statement,
+			"tableChanges": []interface{}{},
+		}
+	}
+	return map[string]interface{}{
+		"before":      rowImage(c.key, c.before),
+		"after":       rowImage(c.key, c.after),
+		"source":      source,
+		"op":          op,
+		"ts_ms":       c.timestamp.UnixMilli(),
+		"transaction": nil,
+	}
+}
+
+// rowImage returns a row's values with its key, or nil for no values
+func rowImage(key string, values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	image := make(map[string]interface{}, len(values)+1)
+	for name, value := range values {
+		image[name] = value
+	}
+	image[KeyColumn] = key
+	return image
+}
+
+// wal2json returns the wal2json messages of a change, committing the open
+// transaction and beginning the change's when it belongs to another. DDL
+// other than TRUNCATE is not decoded by wal2json and gives no change message.
+func (e *WireEncoder) wal2json(c change) []interface{} {
+	var messages []interface{}
+	if e.open && fmt.Sprint(e.xid) != fmt.Sprint(c.xid) {
+		messages = append(messages, e.wal2jsonMarker("C"))
+		e.open = false
+	}
+	e.ts = c.timestamp
+	if !e.open {
+		e.xid, e.open = c.xid, true
+		messages = append(messages, e.wal2jsonMarker("B"))
+	}
+
+	message := map[string]interface{}{"schema": "public", "table": c.table}
+	switch c.operation {
+	case "INSERT":
+		message["action"] = "I"
+		message["columns"] = wal2jsonColumns(c.key, c.after)
+	case "UPDATE":
+		message["action"] = "U"
+		message["columns"] = wal2jsonColumns(c.key, c.after)
+		// Tables with REPLICA IDENTITY FULL identify rows by all old values
+		message["identity"] = wal2jsonColumns(c.key, c.before)
+	case "DELETE":
+		message["action"] = "D"
+		message["identity"] = wal2jsonColumns(c.key, c.before)
+	case DDLTruncate:
+		message["action"] = "T"
+	default:
+		return messages
+	}
+	return append(messages, message)
+}
+
+// wal2jsonMarker returns the begin (B) or commit (C) message of the open
+// transaction
+func (e *WireEncoder) wal2jsonMarker(action string) map[string]interface{} {
+	return map[string]interface{}{
+		"action":    action,
+		"xid":       e.xid,
+		"timestamp": e.ts.UTC().Format("2006-01-02 15:04:05.000000+00"),
+	}
+}
+
+// wal2jsonColumns returns a row's values as wal2json columns, the key first
+// and the rest by name
+func wal2jsonColumns(key string, values map[string]interface{}) []interface{} {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	columns := []interface{}{map[string]interface{}{"name": KeyColumn, "type": "text", "value": key}}
+	for _, name := range names {
+		columns = append(columns, map[string]interface{}{"name": name, "type": "text", "value": values[name]})
+	}
+	return columns
+}
@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"expvar"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// DurationBuckets are histogram upper bounds, in seconds, suited to per-event work
+var DurationBuckets = []float64{0.000001, 0.000005, 0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// LatencyBuckets are histogram upper bounds, in seconds, suited to the age of
+// events, from milliseconds to hours
+var LatencyBuckets = []float64{0.001, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600, 14400}
+
+// Histogram counts observations into fixed buckets and tracks their sum
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64 // counts[i] holds observations <= bounds[i]; the last entry is +Inf
+	count  uint64
+	sum    float64
+}
+
+// HistogramSnapshot is a point-in-time copy of a histogram
+type HistogramSnapshot struct {
+	Count   uint64    `json:"count"`
+	Sum     float64   `json:"sum"`
+	Bounds  []float64 `json:"bounds"`
+	Buckets []uint64  `json:"buckets"` // Non-cumulative counts; one more than Bounds for +Inf
+}
+
+// NewHistogram creates a histogram with the given ascending bucket bounds
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+// Observe records a value
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v)
+
+	h.mu.Lock()
+	h.counts[i]++
+	h.count++
+	h.sum += v
+	h.mu.Unlock()
+}
+
+// Snapshot returns a copy of the histogram's current state
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return HistogramSnapshot{
+		Count:   h.count,
+		Sum:     h.sum,
+		Bounds:  h.bounds,
+		Buckets: append([]uint64(nil), h.counts...),
+	}
+}
+
+// Mean returns the average observed value
+func (s HistogramSnapshot) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Quantile estimates the q-quantile (0 to 1) of the observed values as the
+// upper bound of the bucket holding it. Values beyond the last bound report
+// that bound.
+func (s HistogramSnapshot) Quantile(q float64) float64 {
+	if s.Count == 0 || len(s.Bounds) == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(s.Count)))
+	var seen uint64
+	for i, n := range s.Buckets {
+		seen += n
+		if seen >= rank && i < len(s.Bounds) {
+			return s.Bounds[i]
+		}
+	}
+	return s.Bounds[len(s.Bounds)-1]
+}
+
+// Gauge holds a value that can go up and down
+type Gauge struct {
+	value atomic.Int64
+}
+
+func (g *Gauge) Set(v int64)  { g.value.Store(v) }
+func (g *Gauge) Add(d int64)  { g.value.Add(d) }
+func (g *Gauge) Value() int64 { return g.value.Load() }
+
+// Counter holds a monotonically increasing value
+type Counter struct {
+	value atomic.Uint64
+}
+
+func (c *Counter) Inc()          { c.value.Add(1) }
+func (c *Counter) Add(d uint64)  { c.value.Add(d) }
+func (c *Counter) Value() uint64 { return c.value.Load() }
+
+// Registry holds named metrics
+type Registry struct {
+	mu         sync.Mutex
+	histograms map[string]*Histogram
+	gauges     map[string]*Gauge
+	counters   map[string]*Counter
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		histograms: make(map[string]*Histogram),
+		gauges:     make(map[string]*Gauge),
+		counters:   make(map[string]*Counter),
+	}
+}
+
+// Default is the process-wide registry, published at /debug/vars as "metrics"
+var Default = NewRegistry()
+
+func init() {
+	expvar.Publish("metrics", expvar.Func(func() interface{} { return Default.Snapshot() }))
+}
+
+// Histogram returns the named histogram, creating it with bounds if needed
+func (r *Registry) Histogram(name string, bounds []float64) *Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.histograms[name]
+	if !ok {
+		h = NewHistogram(bounds)
+		r.histograms[name] = h
+	}
+	return h
+}
+
+// Gauge returns the named gauge, creating it if needed
+func (r *Registry) Gauge(name string) *Gauge {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g, ok := r.gauges[name]
+	if !ok {
+		g = &Gauge{}
+		r.gauges[name] = g
+	}
+	return g
+}
+
+// Counter returns the named counter, creating it if needed
+func (r *Registry) Counter(name string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.counters[name]
+	if !ok {
+		c = &Counter{}
+		r.counters[name] = c
+	}
+	return c
+}
+
+// Snapshot is a point-in-time copy of every metric in a registry
+type Snapshot struct {
+	Histograms map[string]HistogramSnapshot `json:"histograms"`
+	Gauges     map[string]int64             `json:"gauges"`
+	Counters   map[string]uint64            `json:"counters"`
+}
+
+// Snapshot copies the current value of every metric
+func (r *Registry) Snapshot() Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snap := Snapshot{
+		Histograms: make(map[string]HistogramSnapshot, len(r.histograms)),
+		Gauges:     make(map[string]int64, len(r.gauges)),
+		Counters:   make(map[string]uint64, len(r.counters)),
+	}
+	for name, h := range r.histograms {
+		snap.Histograms[name] = h.Snapshot()
+	}
+	for name, g := range r.gauges {
+		snap.Gauges[name] = g.Value()
+	}
+	for name, c := range r.counters {
+		snap.Counters[name] = c.Value()
+	}
+	return snap
+}
@@ -0,0 +1,192 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log-signal-processor/sigv4"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config describes an S3-compatible bucket
+type Config struct {
+	Bucket      string
+	Endpoint    string // Base URL; empty means AWS S3 in Region
+	Region      string
+	PathStyle   bool // Address the bucket in the path rather than the host name
+	Credentials sigv4.Credentials
+}
+
+// Object is an entry returned by List
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Client reads and writes objects using the S3 REST API. GCS is supported
+// through its S3-interoperable XML API with HMAC keys.
+type Client struct {
+	config Config
+	signer *sigv4.Signer
+	http   *http.Client
+}
+
+// New creates a client for the configured bucket
+func New(config Config) (*Client, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("bucket name is required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	return &Client{
+		config: config,
+		signer: &sigv4.Signer{Credentials: config.Credentials, Region: config.Region, Service: "s3"},
+		http:   &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// ParseURL splits an s3://bucket/prefix or gs://bucket/prefix URL into a client
+// configuration and key prefix. Credentials and region come from the AWS
+// environment variables; for GCS these hold an HMAC key pair.
+func ParseURL(rawURL string) (Config, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Config{}, "", err
+	}
+
+	config := Config{
+		Bucket:      u.Host,
+		Credentials: sigv4.CredentialsFromEnv(),
+	}
+	switch u.Scheme {
+	case "s3":
+		config.Region = sigv4.RegionFromEnv("us-east-1")
+	case "gs":
+		config.Endpoint = "https://storage.googleapis.com"
+		config.Region = "auto"
+		config.PathStyle = true
+	default:
+		return Config{}, "", fmt.Errorf("unsupported object store scheme %q (expected s3 or gs)", u.Scheme)
+	}
+	return config, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// listBucketResult is the XML body of a ListObjects response
+type listBucketResult struct {
+	IsTruncated bool   `xml:"IsTruncated"`
+	NextMarker  string `xml:"NextMarker"`
+	Contents    []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// List returns every object under prefix in lexical key order
+func (c *Client) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("prefix", prefix)
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil, "")
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding object listing: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			objects = append(objects, Object{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified})
+		}
+		if !result.IsTruncated || len(result.Contents) == 0 {
+			return objects, nil
+		}
+
+		// NextMarker is only returned with a delimiter; otherwise continue after the last key
+		marker = result.NextMarker
+		if marker == "" {
+			marker = result.Contents[len(result.Contents)-1].Key
+		}
+	}
+}
+
+// Get opens an object for reading. The caller must close the returned reader.
+func (c *Client) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Put uploads an object
+func (c *Client) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	resp, err := c.do(ctx, http.MethodPut, key, nil, body, contentType)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends a signed request and returns the response if it succeeded
+func (c *Client) do(ctx context.Context, method, key string, query url.Values, body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, query), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c.signer.Sign(req, body, time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s: %s", method, key, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// objectURL builds the request URL using path-style or virtual-hosted addressing
+func (c *Client) objectURL(key string, query url.Values) string {
+	endpoint := c.config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", c.config.Region)
+	}
+	u, _ := url.Parse(endpoint)
+
+	path := "/" + key
+	if c.config.PathStyle {
+		path = "/" + c.config.Bucket + path
+	} else {
+		u.Host = c.config.Bucket + "." + u.Host
+	}
+	u.Path = path
+	u.RawPath = sigv4.EscapePath(path)
+	if len(query) > 0 {
+		u.RawQuery = sigv4.EncodeQuery(query)
+	}
+	return u.String()
+}
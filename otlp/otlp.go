@@ -0,0 +1,369 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SignalBounds are the histogram bucket boundaries for signal values
+var SignalBounds = []float64{-8, -4, -2, -1, -0.5, 0, 0.5, 1, 2, 4, 8, 16, 32, 64}
+
+// maxPendingSpans bounds the spans buffered between exports; later spans are dropped
+const maxPendingSpans = 10000
+
+// Config selects the OTLP/HTTP collector to export to
+type Config struct {
+	Endpoint      string            // Collector base URL, e.g. http://localhost:4318
+	ServiceName   string            // service.name resource attribute
+	Headers       map[string]string // Extra request headers, e.g. authentication
+	FlushInterval time.Duration
+}
+
+// Exporter sends spans and signal metrics to an OpenTelemetry collector using
+// OTLP/HTTP with JSON encoding. Data is buffered and exported periodically.
+type Exporter struct {
+	config Config
+	http   *http.Client
+
+	mu           sync.Mutex
+	spans        []Span
+	droppedSpans int
+	histograms   map[string]*histogram
+	windowStart  time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Span is a timed operation within a trace
+type Span struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]interface{}
+}
+
+// histogram aggregates the values of one signal series over an export window
+type histogram struct {
+	attributes map[string]interface{}
+	count      uint64
+	sum        float64
+	min, max   float64
+	buckets    []uint64
+}
+
+// NewExporter starts an exporter that flushes every config.FlushInterval
+func NewExporter(config Config) (*Exporter, error) {
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("otlp endpoint is required")
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = "log-signal-processor"
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 10 * time.Second
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+
+	e := &Exporter{
+		config:      config,
+		http:        &http.Client{Timeout: 30 * time.Second},
+		histograms:  make(map[string]*histogram),
+		windowStart: time.Now(),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+// HeadersFromEnv parses OTEL_EXPORTER_OTLP_HEADERS ("key1=value1,key2=value2")
+func HeadersFromEnv() map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+			value = unescaped
+		}
+		headers[strings.TrimSpace(name)] = value
+	}
+	return headers
+}
+
+// NewTraceID returns a random trace ID
+func NewTraceID() string {
+	return randomID(16)
+}
+
+// NewSpanID returns a random span ID
+func NewSpanID() string {
+	return randomID(8)
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RecordSpan queues a finished span for export
+func (e *Exporter) RecordSpan(span Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.spans) >= maxPendingSpans {
+		e.droppedSpans++
+		return
+	}
+	e.spans = append(e.spans, span)
+}
+
+// RecordSignal adds a signal value to the histogram of its table, column and signal
+func (e *Exporter) RecordSignal(table, column, signal string, value float64) {
+	key := table + "\x00" + column + "\x00" + signal
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	h, ok := e.histograms[key]
+	if !ok {
+		h = &histogram{
+			attributes: map[string]interface{}{"db.table": table, "db.column": column, "signal": signal},
+			min:        value,
+			max:        value,
+			buckets:    make([]uint64, len(SignalBounds)+1),
+		}
+		e.histograms[key] = h
+	}
+	h.count++
+	h.sum += value
+	if value < h.min {
+		h.min = value
+	}
+	if value > h.max {
+		h.max = value
+	}
+	h.buckets[sort.SearchFloat64s(SignalBounds, value)]++
+}
+
+// Close exports any buffered data and stops the exporter
+func (e *Exporter) Close() error {
+	close(e.stop)
+	<-e.done
+	return e.Flush()
+}
+
+// Flush exports the buffered spans and the current metrics window
+func (e *Exporter) Flush() error {
+	e.mu.Lock()
+	spans, dropped := e.spans, e.droppedSpans
+	histograms, start := e.histograms, e.windowStart
+	e.spans, e.droppedSpans = nil, 0
+	e.histograms, e.windowStart = make(map[string]*histogram), time.Now()
+	e.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("OTLP exporter dropped %d spans", dropped)
+	}
+
+	var errs []string
+	if len(spans) > 0 {
+		if err := e.post("/v1/traces", e.tracesRequest(spans)); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(histograms) > 0 {
+		if err := e.post("/v1/metrics", e.metricsRequest(histograms, start, time.Now())); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("otlp export: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// run flushes periodically until Close is called
+func (e *Exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.Flush(); err != nil {
+				log.Printf("Failed to export telemetry: %v", err)
+			}
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// tracesRequest builds an ExportTraceServiceRequest
+func (e *Exporter) tracesRequest(spans []Span) map[string]interface{} {
+	otlpSpans := make([]map[string]interface{}, len(spans))
+	for i, span := range spans {
+		s := map[string]interface{}{
+			"traceId":           span.TraceID,
+			"spanId":            span.SpanID,
+			"name":              span.Name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": unixNano(span.Start),
+			"endTimeUnixNano":   unixNano(span.End),
+			"attributes":        attributes(span.Attributes),
+		}
+		if span.ParentSpanID != "" {
+			s["parentSpanId"] = span.ParentSpanID
+		}
+		otlpSpans[i] = s
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource":   e.resource(),
+			"scopeSpans": []interface{}{map[string]interface{}{"scope": e.scope(), "spans": otlpSpans}},
+		}},
+	}
+}
+
+// metricsRequest builds an ExportMetricsServiceRequest with one delta histogram per series
+func (e *Exporter) metricsRequest(histograms map[string]*histogram, start, end time.Time) map[string]interface{} {
+	keys := make([]string, 0, len(histograms))
+	for key := range histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	points := make([]map[string]interface{}, len(keys))
+	for i, key := range keys {
+		h := histograms[key]
+		buckets := make([]string, len(h.buckets))
+		for j, n := range h.buckets {
+			buckets[j] = strconv.FormatUint(n, 10)
+		}
+		points[i] = map[string]interface{}{
+			"attributes":        attributes(h.attributes),
+			"startTimeUnixNano": unixNano(start),
+			"timeUnixNano":      unixNano(end),
+			"count":             strconv.FormatUint(h.count, 10),
+			"sum":               h.sum,
+			"min":               h.min,
+			"max":               h.max,
+			"bucketCounts":      buckets,
+			"explicitBounds":    SignalBounds,
+		}
+	}
+
+	metric := map[string]interface{}{
+		"name":        "log_signal.value",
+		"description": "Signal values generated per table, column and signal",
+		"unit":        "1",
+		"histogram": map[string]interface{}{
+			"aggregationTemporality": 1, // AGGREGATION_TEMPORALITY_DELTA
+			"dataPoints":             points,
+		},
+	}
+	return map[string]interface{}{
+		"resourceMetrics": []interface{}{map[string]interface{}{
+			"resource":     e.resource(),
+			"scopeMetrics": []interface{}{map[string]interface{}{"scope": e.scope(), "metrics": []interface{}{metric}}},
+		}},
+	}
+}
+
+func (e *Exporter) resource() map[string]interface{} {
+	return map[string]interface{}{
+		"attributes": attributes(map[string]interface{}{"service.name": e.config.ServiceName}),
+	}
+}
+
+func (e *Exporter) scope() map[string]interface{} {
+	return map[string]interface{}{"name": "log-signal-processor"}
+}
+
+// post sends an export request as JSON
+func (e *Exporter) post(path string, request interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, e.config.Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// attributes converts a map to OTLP key-value attributes in key order
+func attributes(m map[string]interface{}) []map[string]interface{} {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, map[string]interface{}{"key": key, "value": anyValue(m[key])})
+	}
+	return attrs
+}
+
+// anyValue converts a Go value to an OTLP AnyValue. 64-bit integers are
+// strings in the JSON encoding.
+func anyValue(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": strconv.Itoa(v)}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
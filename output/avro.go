@@ -0,0 +1,194 @@
+package output
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"log-signal-processor/logprocessor"
+	"math"
+)
+
+// AnomalyInputAvroSchema is the Avro schema used for anomaly inputs. Column
+// values are strings since their type varies between columns.
+const AnomalyInputAvroSchema = `{
+  "type": "record",
+  "name": "AnomalyInput",
+  "namespace": "logsignalprocessor",
+  "fields": [
+    {"name": "operation", "type": "string"},
+    {"name": "table", "type": "string"},
+    {"name": "column", "type": "string"},
+    {"name": "timestamp", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+    {"name": "before_value", "type": ["null", "string"], "default": null},
+    {"name": "after_value", "type": ["null", "string"], "default": null},
+    {"name": "signal_vector", "type": {"type": "array", "items": "double"}},
+    {"name": "row_identifier", "type": "string", "default": ""},
+    {"name": "schema_version", "type": "int", "default": 1}
+  ]
+}`
+
+// AnomalyInputAvroSchemaV1 is the version 1 layout of AnomalyInputAvroSchema,
+// without the schema_version field
+const AnomalyInputAvroSchemaV1 = `{
+  "type": "record",
+  "name": "AnomalyInput",
+  "namespace": "logsignalprocessor",
+  "fields": [
+    {"name": "operation", "type": "string"},
+    {"name": "table", "type": "string"},
+    {"name": "column", "type": "string"},
+    {"name": "timestamp", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+    {"name": "before_value", "type": ["null", "string"], "default": null},
+    {"name": "after_value", "type": ["null", "string"], "default": null},
+    {"name": "signal_vector", "type": {"type": "array", "items": "double"}},
+    {"name": "row_identifier", "type": "string", "default": ""}
+  ]
+}`
+
+// AvroSchema returns the Avro schema of the given output schema version
+func AvroSchema(version int) string {
+	if version == 1 {
+		return AnomalyInputAvroSchemaV1
+	}
+	return AnomalyInputAvroSchema
+}
+
+// avroBlockSize is the number of records per object container file block
+const avroBlockSize = 1000
+
+// AvroEncoder encodes an anomaly input as a bare Avro binary datum
+type AvroEncoder struct {
+	SchemaVersion int // Output schema version; defaults to AnomalyInputSchemaVersion
+}
+
+// Encode returns input in Avro binary encoding
+func (e AvroEncoder) Encode(input logprocessor.AnomalyInput) ([]byte, error) {
+	return appendAvroInput(nil, input, e.SchemaVersion), nil
+}
+
+// appendAvroInput appends the Avro binary encoding of input in the layout of
+// the given schema version to buf
+func appendAvroInput(buf []byte, input logprocessor.AnomalyInput, version int) []byte {
+	buf = appendAvroString(buf, input.Operation)
+	buf = appendAvroString(buf, input.Table)
+	buf = appendAvroString(buf, input.Column)
+	buf = binary.AppendVarint(buf, input.Timestamp.UnixMicro())
+	buf = appendAvroOptional(buf, input.BeforeValue)
+	buf = appendAvroOptional(buf, input.AfterValue)
+
+	// Arrays are written as a single block followed by the zero-length terminator
+	if n := len(input.SignalVector); n > 0 {
+		buf = binary.AppendVarint(buf, int64(n))
+		for _, v := range input.SignalVector {
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+		}
+	}
+	buf = binary.AppendVarint(buf, 0)
+
+	buf = appendAvroString(buf, input.RowIdentifier)
+	if version == 1 {
+		return buf
+	}
+	return binary.AppendVarint(buf, int64(AnomalyInputSchemaVersion))
+}
+
+// appendAvroString appends a length-prefixed string. Avro longs use the same
+// zig-zag varint encoding as encoding/binary.
+func appendAvroString(buf []byte, s string) []byte {
+	buf = binary.AppendVarint(buf, int64(len(s)))
+	return append(buf, s...)
+}
+
+// appendAvroOptional appends a ["null", "string"] union
+func appendAvroOptional(buf []byte, v interface{}) []byte {
+	if v == nil {
+		return binary.AppendVarint(buf, 0)
+	}
+	buf = binary.AppendVarint(buf, 1)
+	return appendAvroString(buf, formatValue(v))
+}
+
+// AvroWriter writes anomaly inputs as an Avro object container file
+type AvroWriter struct {
+	version int
+	w       io.WriteCloser
+	buf     *bufio.Writer
+	sync    [16]byte
+	block   []byte
+	count   int64
+	header  bool
+}
+
+// NewAvroWriter creates a writer for the given output schema version (0 for
+// the current one) that closes w when it is closed
+func NewAvroWriter(w io.WriteCloser, schemaVersion int) *AvroWriter {
+	a := &AvroWriter{version: schemaVersion, w: w, buf: bufio.NewWriter(w)}
+	rand.Read(a.sync[:])
+	return a
+}
+
+// Write adds input to the current block, writing the block once it is full
+func (a *AvroWriter) Write(input logprocessor.AnomalyInput) error {
+	a.block = appendAvroInput(a.block, input, a.version)
+	a.count++
+	if a.count < avroBlockSize {
+		return nil
+	}
+	return a.flushBlock()
+}
+
+// Flush writes the current block to the underlying writer
+func (a *AvroWriter) Flush() error {
+	if err := a.flushBlock(); err != nil {
+		return err
+	}
+	if err := a.buf.Flush(); err != nil {
+		return err
+	}
+	return flushFile(a.w)
+}
+
+// Close writes the last block and closes the underlying writer
+func (a *AvroWriter) Close() error {
+	if err := a.Flush(); err != nil {
+		a.w.Close()
+		return err
+	}
+	return a.w.Close()
+}
+
+// flushBlock writes the file header if needed, then the buffered records as one block
+func (a *AvroWriter) flushBlock() error {
+	var out []byte
+	if !a.header {
+		out = a.appendHeader(out)
+		a.header = true
+	}
+	if a.count > 0 {
+		out = binary.AppendVarint(out, a.count)
+		out = binary.AppendVarint(out, int64(len(a.block)))
+		out = append(out, a.block...)
+		out = append(out, a.sync[:]...)
+	}
+	a.block = a.block[:0]
+	a.count = 0
+
+	_, err := a.buf.Write(out)
+	return err
+}
+
+// appendHeader appends the container file magic, metadata and sync marker
+func (a *AvroWriter) appendHeader(buf []byte) []byte {
+	buf = append(buf, 'O', 'b', 'j', 1)
+
+	// Metadata is a map of bytes, written as one block
+	buf = binary.AppendVarint(buf, 2)
+	buf = appendAvroString(buf, "avro.schema")
+	buf = appendAvroString(buf, AvroSchema(a.version))
+	buf = appendAvroString(buf, "avro.codec")
+	buf = appendAvroString(buf, "null")
+	buf = binary.AppendVarint(buf, 0)
+
+	return append(buf, a.sync[:]...)
+}
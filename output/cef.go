@@ -0,0 +1,170 @@
+package output
+
+import (
+	"fmt"
+	"log-signal-processor/logprocessor"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Defaults for the device fields of CEF and LEEF headers
+const (
+	DefaultSIEMVendor  = "LogSignalProcessor"
+	DefaultSIEMProduct = "log-signal-processor"
+	DefaultSIEMVersion = "1.0"
+)
+
+// SIEMConfig fills the device fields of CEF and LEEF headers
+type SIEMConfig struct {
+	Vendor      string
+	Product     string
+	Version     string
+	SignalNames []string // Names of the signal vector positions
+}
+
+func (c SIEMConfig) withDefaults() SIEMConfig {
+	if c.Vendor == "" {
+		c.Vendor = DefaultSIEMVendor
+	}
+	if c.Product == "" {
+		c.Product = DefaultSIEMProduct
+	}
+	if c.Version == "" {
+		c.Version = DefaultSIEMVersion
+	}
+	return c
+}
+
+// score rates how anomalous a signal vector is as its largest absolute value
+func score(signals []float64) float64 {
+	max := 0.0
+	for _, v := range signals {
+		if a := math.Abs(v); a > max {
+			max = a
+		}
+	}
+	return max
+}
+
+// severity maps a signal vector to the 0-10 scale used by CEF and LEEF: the
+// score rounded up and capped at 10
+func severity(signals []float64) int {
+	return int(math.Min(10, math.Ceil(score(signals))))
+}
+
+// signalName returns the name of signal vector position i
+func signalName(names []string, i int) string {
+	if i < len(names) {
+		return names[i]
+	}
+	return fmt.Sprintf("signal%d", i)
+}
+
+// CEFEncoder encodes anomaly inputs as ArcSight Common Event Format (CEF:0)
+// events. The table, column, row, before and after values use the cs1-cs5
+// custom string fields and the first four signals the cfp1-cfp4 float fields;
+// any further signals are listed in cs6 as name=value pairs.
+type CEFEncoder struct {
+	Config SIEMConfig
+}
+
+// Encode returns input as a single CEF line
+func (e CEFEncoder) Encode(input logprocessor.AnomalyInput) ([]byte, error) {
+	config := e.Config.withDefaults()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|%s|%s|%s|%s|%s|%d|",
+		cefHeader(config.Vendor), cefHeader(config.Product), cefHeader(config.Version),
+		cefHeader(strings.ToLower(input.Operation)),
+		cefHeader(fmt.Sprintf("%s change to %s.%s", input.Operation, input.Table, input.Column)),
+		severity(input.SignalVector))
+
+	ext := []string{
+		"rt=" + strconv.FormatInt(input.Timestamp.UnixMilli(), 10),
+		"act=" + cefValue(input.Operation),
+		"cs1Label=table", "cs1=" + cefValue(input.Table),
+		"cs2Label=column", "cs2=" + cefValue(input.Column),
+	}
+	if input.RowIdentifier != "" {
+		ext = append(ext, "cs3Label=rowIdentifier", "cs3="+cefValue(input.RowIdentifier))
+	}
+	ext = append(ext,
+		"cs4Label=beforeValue", "cs4="+cefValue(formatValue(input.BeforeValue)),
+		"cs5Label=afterValue", "cs5="+cefValue(formatValue(input.AfterValue)),
+	)
+
+	var extra []string
+	for i, v := range input.SignalVector {
+		value := strconv.FormatFloat(v, 'g', -1, 64)
+		if i < 4 {
+			n := strconv.Itoa(i + 1)
+			ext = append(ext, "cfp"+n+"Label="+cefValue(signalName(config.SignalNames, i)), "cfp"+n+"="+value)
+		} else {
+			extra = append(extra, signalName(config.SignalNames, i)+"="+value)
+		}
+	}
+	if len(extra) > 0 {
+		ext = append(ext, "cs6Label=signals", "cs6="+cefValue(strings.Join(extra, " ")))
+	}
+
+	b.WriteString(strings.Join(ext, " "))
+	return []byte(b.String()), nil
+}
+
+// cefHeader escapes a CEF header field
+func cefHeader(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ").Replace(s)
+}
+
+// cefValue escapes a CEF extension value
+func cefValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// LEEFEncoder encodes anomaly inputs as IBM QRadar Log Event Extended Format
+// (LEEF 1.0) events with tab-separated attributes. Each signal becomes an
+// attribute named after its generator.
+type LEEFEncoder struct {
+	Config SIEMConfig
+}
+
+// leefTimeFormat is the devTimeFormat declared with each event
+const leefTimeFormat = "yyyy-MM-dd'T'HH:mm:ss.SSSZ"
+
+// Encode returns input as a single LEEF line
+func (e LEEFEncoder) Encode(input logprocessor.AnomalyInput) ([]byte, error) {
+	config := e.Config.withDefaults()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "LEEF:1.0|%s|%s|%s|%s|",
+		cefHeader(config.Vendor), cefHeader(config.Product), cefHeader(config.Version),
+		cefHeader(strings.ToLower(input.Operation)))
+
+	attrs := []string{
+		"devTime=" + input.Timestamp.Format("2006-01-02T15:04:05.000-0700"),
+		"devTimeFormat=" + leefTimeFormat,
+		"cat=" + leefValue(input.Operation),
+		"sev=" + strconv.Itoa(severity(input.SignalVector)),
+		"table=" + leefValue(input.Table),
+		"column=" + leefValue(input.Column),
+	}
+	if input.RowIdentifier != "" {
+		attrs = append(attrs, "rowIdentifier="+leefValue(input.RowIdentifier))
+	}
+	attrs = append(attrs,
+		"beforeValue="+leefValue(formatValue(input.BeforeValue)),
+		"afterValue="+leefValue(formatValue(input.AfterValue)),
+	)
+	for i, v := range input.SignalVector {
+		attrs = append(attrs, signalName(config.SignalNames, i)+"="+strconv.FormatFloat(v, 'g', -1, 64))
+	}
+
+	b.WriteString(strings.Join(attrs, "\t"))
+	return []byte(b.String()), nil
+}
+
+// leefValue escapes the attribute delimiter and line breaks in a LEEF value
+func leefValue(s string) string {
+	return strings.NewReplacer("\t", `\t`, "\r", `\r`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,26 @@
+package output
+
+import "log-signal-processor/logprocessor"
+
+// ConsoleSink logs each anomaly input in the compact console format
+type ConsoleSink struct {
+	SignalNames []string // Label the signal vector positions
+}
+
+// Write logs input
+func (c ConsoleSink) Write(input logprocessor.AnomalyInput) error {
+	logprocessor.LogAnomalyInput(input, c.SignalNames)
+	return nil
+}
+
+// WriteManifest logs the signal vector layout
+func (ConsoleSink) WriteManifest(manifest SignalManifest) error {
+	logprocessor.LogSignalLayout(manifest.Names())
+	return nil
+}
+
+// Flush does nothing; inputs are logged as they are written
+func (ConsoleSink) Flush() error { return nil }
+
+// Close does nothing
+func (ConsoleSink) Close() error { return nil }
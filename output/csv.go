@@ -0,0 +1,92 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log-signal-processor/logprocessor"
+	"strconv"
+	"time"
+)
+
+// csvFixedColumns precede the signal columns in every CSV row
+var csvFixedColumns = []string{"operation", "table", "row_identifier", "column", "timestamp", "before_value", "after_value"}
+
+// CSVWriter writes one row per anomaly input, with a named column for each
+// entry of the signal vector
+type CSVWriter struct {
+	w           io.WriteCloser
+	csv         *csv.Writer
+	signalNames []string
+	wroteHeader bool
+}
+
+// NewCSVWriter creates a writer whose signal columns are named after the
+// generators producing each vector position
+func NewCSVWriter(w io.WriteCloser, signalNames []string) *CSVWriter {
+	return &CSVWriter{w: w, csv: csv.NewWriter(w), signalNames: signalNames}
+}
+
+// Write appends input as a row, preceded by the header on the first call
+func (c *CSVWriter) Write(input logprocessor.AnomalyInput) error {
+	if len(input.SignalVector) != len(c.signalNames) {
+		return fmt.Errorf("signal vector has %d entries, expected %d", len(input.SignalVector), len(c.signalNames))
+	}
+
+	if !c.wroteHeader {
+		header := append(append([]string{}, csvFixedColumns...), c.signalNames...)
+		if err := c.csv.Write(header); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+
+	row := []string{
+		input.Operation,
+		input.Table,
+		input.RowIdentifier,
+		input.Column,
+		input.Timestamp.Format(time.RFC3339Nano),
+		formatValue(input.BeforeValue),
+		formatValue(input.AfterValue),
+	}
+	for _, v := range input.SignalVector {
+		row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	return c.csv.Write(row)
+}
+
+// Flush writes buffered rows to the underlying writer
+func (c *CSVWriter) Flush() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+	return flushFile(c.w)
+}
+
+// Close flushes buffered rows and closes the underlying writer
+func (c *CSVWriter) Close() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		c.w.Close()
+		return err
+	}
+	return c.w.Close()
+}
+
+// formatValue renders a column value as a CSV cell, leaving nil values empty
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,20 @@
+package output
+
+import (
+	"encoding/json"
+	"log-signal-processor/logprocessor"
+)
+
+// Encoder serializes a single anomaly input as a message, for sinks that
+// publish records individually rather than writing a file
+type Encoder interface {
+	Encode(input logprocessor.AnomalyInput) ([]byte, error)
+}
+
+// JSONEncoder encodes an anomaly input as a JSON object
+type JSONEncoder struct{}
+
+// Encode returns input as JSON
+func (JSONEncoder) Encode(input logprocessor.AnomalyInput) ([]byte, error) {
+	return json.Marshal(input)
+}
@@ -0,0 +1,284 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log-signal-processor/logprocessor"
+	"log-signal-processor/metrics"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Defaults for forwarding to an external anomaly detection service
+const (
+	DefaultForwardBatchSize       = 500
+	DefaultForwardFlushInterval   = 5 * time.Second
+	DefaultForwardRetries         = 3
+	DefaultForwardBreakerFailures = 5
+	DefaultForwardBreakerCooldown = 30 * time.Second
+)
+
+// Forwarder metric names
+const (
+	forwardSentMetric    = "forward_sent"
+	forwardFailedMetric  = "forward_failed"
+	forwardSpooledMetric = "forward_spooled"
+)
+
+// ForwarderConfig selects the service anomaly inputs are forwarded to and how
+// failures are handled
+type ForwarderConfig struct {
+	URL             string // Endpoint batches are POSTed to; user info is sent as basic auth
+	Token           string // Bearer token, if the service requires one
+	SignalNames     []string
+	BatchSize       int           // Inputs per request
+	FlushInterval   time.Duration // Maximum age of a partial batch before it is sent
+	Timeout         time.Duration // Per request
+	Retries         int           // Attempts after the first before a batch is spooled
+	RetryBackoff    time.Duration // Wait before the first retry, doubling for each one after it
+	BreakerFailures int           // Consecutive failed batches that open the circuit breaker
+	BreakerCooldown time.Duration // How long the breaker stays open before sending is tried again
+	SpoolDir        string        // Directory for batches that could not be delivered
+}
+
+// forwardBatch is the request body of a forwarded batch
+type forwardBatch struct {
+	SignalNames []string                    `json:"signal_names"`
+	Inputs      []logprocessor.AnomalyInput `json:"inputs"`
+}
+
+// Forwarder batches anomaly inputs and POSTs them as JSON to an external
+// anomaly detection service. Failed requests are retried with exponential
+// backoff; batches that still fail are spooled to disk and re-sent, oldest
+// first, once the service recovers, including after a restart. After
+// BreakerFailures consecutive failures the circuit breaker opens and batches
+// go straight to the spool until BreakerCooldown has passed, so an outage
+// does not stall the pipeline on timeouts.
+type Forwarder struct {
+	config ForwarderConfig
+	http   *http.Client
+
+	batch      []logprocessor.AnomalyInput
+	batchStart time.Time
+
+	failures  int       // Consecutive failed batches
+	openUntil time.Time // Breaker is open until then
+
+	sent    *metrics.Counter
+	failed  *metrics.Counter
+	spooled *metrics.Gauge
+}
+
+// NewForwarder creates a forwarder, creating the spool directory if needed
+func NewForwarder(config ForwarderConfig) (*Forwarder, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("forwarder URL is required")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultForwardBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultForwardFlushInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = time.Second
+	}
+	if config.BreakerFailures <= 0 {
+		config.BreakerFailures = DefaultForwardBreakerFailures
+	}
+	if config.BreakerCooldown <= 0 {
+		config.BreakerCooldown = DefaultForwardBreakerCooldown
+	}
+	if config.SpoolDir == "" {
+		config.SpoolDir = filepath.Join(os.TempDir(), "log-signal-processor-spool")
+	}
+	if err := os.MkdirAll(config.SpoolDir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating spool directory: %w", err)
+	}
+
+	f := &Forwarder{
+		config:  config,
+		http:    &http.Client{Timeout: config.Timeout},
+		sent:    metrics.Default.Counter(forwardSentMetric),
+		failed:  metrics.Default.Counter(forwardFailedMetric),
+		spooled: metrics.Default.Gauge(forwardSpooledMetric),
+	}
+	spooled, err := f.spooledBatches()
+	if err != nil {
+		return nil, err
+	}
+	f.spooled.Set(int64(len(spooled)))
+	return f, nil
+}
+
+// Write adds input to the current batch, sending the batch when it is full or old enough
+func (f *Forwarder) Write(input logprocessor.AnomalyInput) error {
+	if len(f.batch) == 0 {
+		f.batchStart = time.Now()
+	}
+	f.batch = append(f.batch, input)
+	if len(f.batch) < f.config.BatchSize && time.Since(f.batchStart) < f.config.FlushInterval {
+		return nil
+	}
+	return f.Flush()
+}
+
+// Close sends the last partial batch. Batches the service did not accept
+// stay in the spool for the next run.
+func (f *Forwarder) Close() error {
+	return f.Flush()
+}
+
+// Flush re-sends spooled batches and then the current one. Only failing to
+// spool a batch is an error: delivery failures are retried later.
+func (f *Forwarder) Flush() error {
+	var body []byte
+	if len(f.batch) > 0 {
+		var err error
+		body, err = json.Marshal(forwardBatch{SignalNames: f.config.SignalNames, Inputs: f.batch})
+		if err != nil {
+			return err
+		}
+		f.batch = f.batch[:0]
+	}
+
+	if time.Now().Before(f.openUntil) {
+		return f.spool(body)
+	}
+	if err := f.drainSpool(); err != nil {
+		log.Printf("Forwarding spooled batches failed: %v", err)
+		return f.spool(body)
+	}
+	if body == nil {
+		return nil
+	}
+	if err := f.deliver(body); err != nil {
+		log.Printf("Forwarding batch failed: %v", err)
+		return f.spool(body)
+	}
+	return nil
+}
+
+// drainSpool sends spooled batches oldest first, stopping at the first failure
+func (f *Forwarder) drainSpool() error {
+	paths, err := f.spooledBatches()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := f.deliver(body); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		f.spooled.Add(-1)
+	}
+	return nil
+}
+
+// deliver sends one batch, retrying failures, and tracks the circuit breaker
+func (f *Forwarder) deliver(body []byte) error {
+	backoff := f.config.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= f.config.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		var retryable bool
+		if retryable, err = f.post(body); err == nil {
+			f.failures = 0
+			f.sent.Inc()
+			return nil
+		}
+		if !retryable {
+			break
+		}
+	}
+
+	f.failed.Inc()
+	f.failures++
+	if f.failures >= f.config.BreakerFailures {
+		f.openUntil = time.Now().Add(f.config.BreakerCooldown)
+		log.Printf("Forwarder circuit breaker open for %s after %d failed batches", f.config.BreakerCooldown, f.failures)
+	}
+	return err
+}
+
+// post sends one request, reporting whether a failure is worth retrying:
+// network errors, timeouts, throttling and server errors are
+func (f *Forwarder) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, f.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if f.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.config.Token)
+	} else if req.URL.User != nil {
+		password, _ := req.URL.User.Password()
+		req.SetBasicAuth(req.URL.User.Username(), password)
+	}
+
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return retryable, fmt.Errorf("anomaly detection service returned %s", resp.Status)
+}
+
+// spool writes an undelivered batch to the spool directory. Batch files are
+// named by time so they are re-sent in order.
+func (f *Forwarder) spool(body []byte) error {
+	if body == nil {
+		return nil
+	}
+	name := fmt.Sprintf("batch-%020d.json", time.Now().UnixNano())
+	tmp := filepath.Join(f.config.SpoolDir, "."+name)
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return fmt.Errorf("spooling batch: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(f.config.SpoolDir, name)); err != nil {
+		return fmt.Errorf("spooling batch: %w", err)
+	}
+	f.spooled.Add(1)
+	return nil
+}
+
+// spooledBatches lists the spooled batch files, oldest first
+func (f *Forwarder) spooledBatches() ([]string, error) {
+	entries, err := os.ReadDir(f.config.SpoolDir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "batch-") && strings.HasSuffix(entry.Name(), ".json") {
+			paths = append(paths, filepath.Join(f.config.SpoolDir, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,116 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"log-signal-processor/logprocessor"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka partitioning keys
+const (
+	// KafkaPartitionByTable keeps every change to a table in one partition
+	KafkaPartitionByTable = "table"
+	// KafkaPartitionByRow keeps the changes to each row in order while
+	// spreading a table's rows across partitions
+	KafkaPartitionByRow = "row"
+)
+
+// DefaultKafkaBatchSize is the number of messages sent per produce request when none is configured
+const DefaultKafkaBatchSize = 500
+
+// KafkaConfig selects the topic anomaly inputs are published to
+type KafkaConfig struct {
+	Brokers     []string
+	Topic       string
+	Encoder     Encoder
+	PartitionBy string // KafkaPartitionByTable (default) or KafkaPartitionByRow
+	BatchSize   int
+}
+
+// KafkaWriter publishes anomaly inputs to a Kafka topic. Messages are keyed by
+// table or row and assigned to partitions with the murmur2 hash used by the
+// Java client, so they line up with other producers keyed the same way.
+type KafkaWriter struct {
+	config  KafkaConfig
+	writer  *kafka.Writer
+	pending []kafka.Message
+}
+
+// NewKafkaWriter creates a producer for the configured topic
+func NewKafkaWriter(config KafkaConfig) (*KafkaWriter, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("at least one kafka broker is required")
+	}
+	if config.Topic == "" {
+		return nil, fmt.Errorf("kafka topic is required")
+	}
+	if config.Encoder == nil {
+		config.Encoder = JSONEncoder{}
+	}
+	switch config.PartitionBy {
+	case "":
+		config.PartitionBy = KafkaPartitionByTable
+	case KafkaPartitionByTable, KafkaPartitionByRow:
+	default:
+		return nil, fmt.Errorf("unknown kafka partitioning %q (expected table or row)", config.PartitionBy)
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultKafkaBatchSize
+	}
+
+	return &KafkaWriter{
+		config: config,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(config.Brokers...),
+			Topic:        config.Topic,
+			Balancer:     &kafka.Murmur2Balancer{},
+			BatchSize:    config.BatchSize,
+			BatchTimeout: 100 * time.Millisecond,
+			RequiredAcks: kafka.RequireAll,
+		},
+	}, nil
+}
+
+// Write encodes input and queues it, sending the batch once it is full
+func (k *KafkaWriter) Write(input logprocessor.AnomalyInput) error {
+	value, err := k.config.Encoder.Encode(input)
+	if err != nil {
+		return err
+	}
+
+	key := input.Table
+	if k.config.PartitionBy == KafkaPartitionByRow && input.RowIdentifier != "" {
+		key = input.Table + "/" + input.RowIdentifier
+	}
+	k.pending = append(k.pending, kafka.Message{Key: []byte(key), Value: value, Time: input.Timestamp})
+
+	if len(k.pending) < k.config.BatchSize {
+		return nil
+	}
+	return k.Flush()
+}
+
+// Close sends any queued messages and closes the producer
+func (k *KafkaWriter) Close() error {
+	err := k.Flush()
+	if closeErr := k.writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Flush sends the queued messages, waiting for every in-sync replica to acknowledge them
+func (k *KafkaWriter) Flush() error {
+	if len(k.pending) == 0 {
+		return nil
+	}
+	err := k.writer.WriteMessages(context.Background(), k.pending...)
+	k.pending = k.pending[:0]
+	if err != nil {
+		return fmt.Errorf("publishing to %s: %w", k.config.Topic, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ManifestRecordType marks a manifest record in a stream of anomaly inputs
+const ManifestRecordType = "manifest"
+
+// SignalManifest documents the signal vector layout of a run. Sinks that
+// support it write the manifest before any anomaly input, so consumers can map
+// vector positions to generators without relying on a hard-coded order.
+type SignalManifest struct {
+	RecordType    string           `json:"record_type"` // Always ManifestRecordType
+	SchemaVersion int              `json:"schema_version"`
+	Signals       []ManifestSignal `json:"signals"`
+}
+
+// ManifestSignal names one signal vector position
+type ManifestSignal struct {
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+}
+
+// NewSignalManifest describes vectors whose positions hold the named signals
+func NewSignalManifest(schemaVersion int, signalNames []string) SignalManifest {
+	manifest := SignalManifest{RecordType: ManifestRecordType, SchemaVersion: schemaVersion}
+	for i, name := range signalNames {
+		manifest.Signals = append(manifest.Signals, ManifestSignal{Position: i, Name: name})
+	}
+	return manifest
+}
+
+// Names returns the signal names in vector order
+func (m SignalManifest) Names() []string {
+	names := make([]string, len(m.Signals))
+	for i, s := range m.Signals {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// ManifestWriter is implemented by sinks that can record a SignalManifest
+// ahead of the anomaly inputs
+type ManifestWriter interface {
+	WriteManifest(manifest SignalManifest) error
+}
+
+// ParseManifestJSON decodes a manifest record, returning ok=false if data is
+// not one (e.g. it is an anomaly input)
+func ParseManifestJSON(data []byte) (manifest SignalManifest, ok bool, err error) {
+	var probe struct {
+		RecordType string `json:"record_type"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil || probe.RecordType != ManifestRecordType {
+		return SignalManifest{}, false, nil
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return SignalManifest{}, true, err
+	}
+	for i, s := range manifest.Signals {
+		if s.Position != i || s.Name == "" {
+			return manifest, true, fmt.Errorf("manifest signal %d: expected position %d with a name", i, i)
+		}
+	}
+	return manifest, true, nil
+}
@@ -0,0 +1,64 @@
+package output
+
+import (
+	"errors"
+	"log-signal-processor/logprocessor"
+)
+
+// MultiSink fans each anomaly input out to several sinks in order. A failing
+// sink does not stop the others from receiving the input; the errors of all
+// sinks are joined.
+type MultiSink struct {
+	sinks []OutputSink
+}
+
+// NewMultiSink creates a sink writing to every one of sinks
+func NewMultiSink(sinks ...OutputSink) *MultiSink {
+	return &MultiSink{sinks: sinks}
+}
+
+// Write writes input to every sink
+func (m *MultiSink) Write(input logprocessor.AnomalyInput) error {
+	var errs []error
+	for _, sink := range m.sinks {
+		if err := sink.Write(input); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WriteManifest writes manifest to every sink that records manifests
+func (m *MultiSink) WriteManifest(manifest SignalManifest) error {
+	var errs []error
+	for _, sink := range m.sinks {
+		if mw, ok := sink.(ManifestWriter); ok {
+			if err := mw.WriteManifest(manifest); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush flushes every sink
+func (m *MultiSink) Flush() error {
+	var errs []error
+	for _, sink := range m.sinks {
+		if err := sink.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every sink, even if some fail
+func (m *MultiSink) Close() error {
+	var errs []error
+	for _, sink := range m.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,67 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log-signal-processor/logprocessor"
+)
+
+// NDJSONWriter writes each anomaly input as one JSON object per line
+type NDJSONWriter struct {
+	w       io.WriteCloser
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewNDJSONWriter creates a writer that closes w when it is closed
+func NewNDJSONWriter(w io.WriteCloser) *NDJSONWriter {
+	buf := bufio.NewWriter(w)
+	return &NDJSONWriter{w: w, buf: buf, encoder: json.NewEncoder(buf)}
+}
+
+// Write encodes input followed by a newline
+func (n *NDJSONWriter) Write(input logprocessor.AnomalyInput) error {
+	return n.encoder.Encode(input)
+}
+
+// WriteManifest writes manifest as a line of its own, marked by its
+// record_type field. It should precede the first input.
+func (n *NDJSONWriter) WriteManifest(manifest SignalManifest) error {
+	return n.encoder.Encode(manifest)
+}
+
+// WriteWindow writes a window aggregate as a line of its own, marked by its
+// record_type field
+func (n *NDJSONWriter) WriteWindow(window logprocessor.WindowAggregate) error {
+	return n.encoder.Encode(window)
+}
+
+// WriteTableAggregate writes a table aggregate as a line of its own, marked by
+// its record_type field
+func (n *NDJSONWriter) WriteTableAggregate(table logprocessor.TableAggregate) error {
+	return n.encoder.Encode(table)
+}
+
+// WriteDriftReport writes a drift report as a line of its own, marked by its
+// record_type field
+func (n *NDJSONWriter) WriteDriftReport(report logprocessor.DriftReport) error {
+	return n.encoder.Encode(report)
+}
+
+// Flush writes buffered lines to the underlying writer
+func (n *NDJSONWriter) Flush() error {
+	if err := n.buf.Flush(); err != nil {
+		return err
+	}
+	return flushFile(n.w)
+}
+
+// Close flushes buffered lines and closes the underlying writer
+func (n *NDJSONWriter) Close() error {
+	if err := n.buf.Flush(); err != nil {
+		n.w.Close()
+		return err
+	}
+	return n.w.Close()
+}
@@ -0,0 +1,127 @@
+package output
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log-signal-processor/logprocessor"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// OutputSink delivers anomaly inputs to a downstream consumer. Sinks may
+// buffer inputs; Flush hands everything written so far to the destination and
+// Close flushes and releases the sink.
+type OutputSink interface {
+	Write(input logprocessor.AnomalyInput) error
+	Flush() error
+	Close() error
+}
+
+// Output file compression
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// CompressionFromPath picks the compression implied by a file extension
+func CompressionFromPath(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		return CompressionGzip
+	case strings.HasSuffix(path, ".zst"):
+		return CompressionZstd
+	default:
+		return CompressionNone
+	}
+}
+
+// CreateFile opens path for writing, truncating it. The path "-" selects
+// stdout, which is left open when the returned file is closed. The file is
+// compressed with compression, or as its extension implies when that is empty.
+func CreateFile(path string, compression string) (io.WriteCloser, error) {
+	if compression == "" {
+		compression = CompressionFromPath(path)
+	}
+	switch compression {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		return nil, fmt.Errorf("unsupported output compression %q (expected none, gzip or zstd)", compression)
+	}
+
+	var file io.WriteCloser = nopCloser{os.Stdout}
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		file = f
+	}
+	return compressFile(file, compression)
+}
+
+// compressFile wraps file with the given compression. Closing the returned
+// writer ends the compressed stream and closes file.
+func compressFile(file io.WriteCloser, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionGzip:
+		return &compressedFile{compressor: gzip.NewWriter(file), file: file}, nil
+	case CompressionZstd:
+		enc, err := zstd.NewWriter(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &compressedFile{compressor: enc, file: file}, nil
+	default:
+		return file, nil
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// compressor is implemented by the gzip and zstd stream writers
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressedFile compresses writes to file. Closing it ends the compressed
+// stream and closes the file.
+type compressedFile struct {
+	compressor compressor
+	file       io.WriteCloser
+}
+
+func (c *compressedFile) Write(p []byte) (int, error) {
+	return c.compressor.Write(p)
+}
+
+// Flush completes the current compressed block so everything written so far
+// can be decompressed
+func (c *compressedFile) Flush() error {
+	return c.compressor.Flush()
+}
+
+func (c *compressedFile) Close() error {
+	err := c.compressor.Close()
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// flushFile flushes w if it buffers writes itself, as compressed files do
+func flushFile(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
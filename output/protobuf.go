@@ -0,0 +1,222 @@
+package output
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"log-signal-processor/logprocessor"
+	"math"
+	"sort"
+	"time"
+)
+
+// Protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+)
+
+// ProtoEncoder encodes anomaly inputs as the AnomalyInput message of
+// proto/anomaly.proto
+type ProtoEncoder struct {
+	// SignalNames label the signal vector positions in the signals field;
+	// it is left empty when no names are given
+	SignalNames []string
+}
+
+// Encode returns input in protobuf binary encoding
+func (e ProtoEncoder) Encode(input logprocessor.AnomalyInput) ([]byte, error) {
+	return e.appendInput(nil, input), nil
+}
+
+func (e ProtoEncoder) appendInput(buf []byte, input logprocessor.AnomalyInput) []byte {
+	buf = appendProtoString(buf, 1, input.Operation)
+	buf = appendProtoString(buf, 2, input.Table)
+	buf = appendProtoString(buf, 3, input.Column)
+	buf = appendProtoTimestamp(buf, 4, input.Timestamp)
+
+	// Optional fields are written whenever set, even if empty
+	if input.BeforeValue != nil {
+		buf = appendProtoBytes(buf, 5, []byte(formatValue(input.BeforeValue)))
+	}
+	if input.AfterValue != nil {
+		buf = appendProtoBytes(buf, 6, []byte(formatValue(input.AfterValue)))
+	}
+
+	// Repeated scalars are packed
+	if len(input.SignalVector) > 0 {
+		packed := make([]byte, 0, 8*len(input.SignalVector))
+		for _, v := range input.SignalVector {
+			packed = binary.LittleEndian.AppendUint64(packed, math.Float64bits(v))
+		}
+		buf = appendProtoBytes(buf, 7, packed)
+	}
+
+	if len(e.SignalNames) == len(input.SignalVector) {
+		for i, v := range input.SignalVector {
+			var signal []byte
+			signal = appendProtoString(signal, 1, e.SignalNames[i])
+			signal = appendProtoDouble(signal, 2, v)
+			buf = appendProtoBytes(buf, 8, signal)
+		}
+	}
+	buf = appendProtoString(buf, 9, input.RowIdentifier)
+	if input.SchemaVersion != 0 {
+		buf = appendProtoTag(buf, 10, protoVarint)
+		buf = binary.AppendUvarint(buf, uint64(input.SchemaVersion))
+	}
+	if input.Anomalous {
+		buf = appendProtoTag(buf, 11, protoVarint)
+		buf = append(buf, 1)
+	}
+	for _, rule := range input.MatchedRules {
+		buf = appendProtoString(buf, 12, rule)
+	}
+	for _, d := range input.Detections {
+		var detection []byte
+		detection = appendProtoString(detection, 1, d.Detector)
+		detection = appendProtoString(detection, 2, d.Signal)
+		detection = appendProtoDouble(detection, 3, d.Value)
+		detection = appendProtoDouble(detection, 4, d.Score)
+		for _, c := range d.Contributions {
+			detection = appendProtoBytes(detection, 5, appendProtoContribution(nil, c))
+		}
+		detection = appendProtoInt64(detection, 6, int64(d.Samples))
+		detection = appendProtoDouble(detection, 7, d.Uncertainty)
+		buf = appendProtoBytes(buf, 13, detection)
+	}
+	buf = appendProtoString(buf, 14, input.Session)
+	for _, c := range input.Contributions {
+		buf = appendProtoBytes(buf, 15, appendProtoContribution(nil, c))
+	}
+	buf = appendProtoString(buf, 16, string(input.Severity))
+	buf = appendProtoString(buf, 17, input.Suppressed)
+	return buf
+}
+
+// EncodeLogData returns logData encoded as the LogData message of proto/anomaly.proto
+func EncodeLogData(logData logprocessor.LogData) []byte {
+	var buf []byte
+	buf = appendProtoString(buf, 1, logData.Operation)
+	buf = appendProtoString(buf, 2, logData.Table)
+	buf = appendProtoString(buf, 3, logData.RowIdentifier)
+	for _, column := range logData.Columns {
+		buf = appendProtoBytes(buf, 4, []byte(column))
+	}
+	buf = appendProtoTimestamp(buf, 5, logData.Timestamp)
+	buf = appendProtoMap(buf, 6, logData.Before)
+	buf = appendProtoMap(buf, 7, logData.After)
+	return buf
+}
+
+// ProtoWriter writes anomaly inputs as a stream of varint length-delimited
+// AnomalyInput messages, the format read by parseDelimitedFrom and similar helpers
+type ProtoWriter struct {
+	w       io.WriteCloser
+	buf     *bufio.Writer
+	encoder ProtoEncoder
+}
+
+// NewProtoWriter creates a writer that closes w when it is closed
+func NewProtoWriter(w io.WriteCloser, signalNames []string) *ProtoWriter {
+	return &ProtoWriter{w: w, buf: bufio.NewWriter(w), encoder: ProtoEncoder{SignalNames: signalNames}}
+}
+
+// Write appends input preceded by its length
+func (p *ProtoWriter) Write(input logprocessor.AnomalyInput) error {
+	msg := p.encoder.appendInput(nil, input)
+	if _, err := p.buf.Write(binary.AppendUvarint(nil, uint64(len(msg)))); err != nil {
+		return err
+	}
+	_, err := p.buf.Write(msg)
+	return err
+}
+
+// Flush writes buffered messages to the underlying writer
+func (p *ProtoWriter) Flush() error {
+	if err := p.buf.Flush(); err != nil {
+		return err
+	}
+	return flushFile(p.w)
+}
+
+// Close flushes buffered messages and closes the underlying writer
+func (p *ProtoWriter) Close() error {
+	if err := p.buf.Flush(); err != nil {
+		p.w.Close()
+		return err
+	}
+	return p.w.Close()
+}
+
+func appendProtoTag(buf []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(field)<<3|uint64(wireType))
+}
+
+// appendProtoBytes appends a length-delimited field (bytes, string or message)
+func appendProtoBytes(buf []byte, field int, data []byte) []byte {
+	buf = appendProtoTag(buf, field, protoBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// appendProtoString appends a proto3 string field, omitted when empty
+// appendProtoContribution appends the fields of a Contribution message
+func appendProtoContribution(buf []byte, c logprocessor.Contribution) []byte {
+	buf = appendProtoString(buf, 1, c.Signal)
+	return appendProtoDouble(buf, 2, c.Percent)
+}
+
+func appendProtoString(buf []byte, field int, s string) []byte {
+	if s == "" {
+		return buf
+	}
+	return appendProtoBytes(buf, field, []byte(s))
+}
+
+// appendProtoDouble appends a proto3 double field, omitted when zero
+func appendProtoDouble(buf []byte, field int, v float64) []byte {
+	if v == 0 && !math.Signbit(v) {
+		return buf
+	}
+	buf = appendProtoTag(buf, field, protoFixed64)
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+}
+
+// appendProtoInt64 appends a proto3 int64 field, omitted when zero
+func appendProtoInt64(buf []byte, field int, v int64) []byte {
+	if v == 0 {
+		return buf
+	}
+	buf = appendProtoTag(buf, field, protoVarint)
+	return binary.AppendUvarint(buf, uint64(v))
+}
+
+// appendProtoTimestamp appends a google.protobuf.Timestamp field, omitted for the zero time
+func appendProtoTimestamp(buf []byte, field int, t time.Time) []byte {
+	if t.IsZero() {
+		return buf
+	}
+	var ts []byte
+	ts = appendProtoInt64(ts, 1, t.Unix())
+	ts = appendProtoInt64(ts, 2, int64(t.Nanosecond()))
+	return appendProtoBytes(buf, field, ts)
+}
+
+// appendProtoMap appends a map<string, string> field with entries in key order
+func appendProtoMap(buf []byte, field int, m map[string]interface{}) []byte {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var entry []byte
+		entry = appendProtoString(entry, 1, key)
+		entry = appendProtoString(entry, 2, formatValue(m[key]))
+		buf = appendProtoBytes(buf, field, entry)
+	}
+	return buf
+}
@@ -0,0 +1,120 @@
+package output
+
+import (
+	"fmt"
+	"log-signal-processor/logprocessor"
+	"time"
+)
+
+// RedactionConfig selects AnomalyInput fields to remove or pseudonymize before
+// inputs reach any sink. Fields are named as in the JSON output.
+type RedactionConfig struct {
+	Exclude []string                  // Fields cleared from every input
+	Hash    []string                  // Fields replaced by a salted HMAC-SHA256 of their value
+	Hasher  *logprocessor.ValueHasher // Hashes values; required when Hash is set
+}
+
+// redactableFields lists the fields that can be excluded and whether they can be hashed
+var redactableFields = map[string]bool{
+	"operation":      true,
+	"table":          true,
+	"row_identifier": true,
+	"column":         true,
+	"timestamp":      false,
+	"before_value":   true,
+	"after_value":    true,
+}
+
+// RedactingSink applies a RedactionConfig to each input before writing it to
+// the wrapped sink. Excluded fields are cleared (empty or null) rather than
+// removed so every format keeps its layout; hashed values keep nulls as nulls
+// so inserts and deletes remain recognizable.
+type RedactingSink struct {
+	sink    OutputSink
+	exclude map[string]bool
+	hash    map[string]bool
+	hasher  *logprocessor.ValueHasher
+}
+
+// NewRedactingSink wraps sink with the given redaction
+func NewRedactingSink(sink OutputSink, config RedactionConfig) (*RedactingSink, error) {
+	r := &RedactingSink{sink: sink, exclude: make(map[string]bool), hash: make(map[string]bool), hasher: config.Hasher}
+	for _, field := range config.Exclude {
+		if _, ok := redactableFields[field]; !ok {
+			return nil, fmt.Errorf("cannot exclude field %q", field)
+		}
+		r.exclude[field] = true
+	}
+	for _, field := range config.Hash {
+		if !redactableFields[field] {
+			return nil, fmt.Errorf("cannot hash field %q", field)
+		}
+		if r.exclude[field] {
+			return nil, fmt.Errorf("field %q is both excluded and hashed", field)
+		}
+		r.hash[field] = true
+	}
+	if len(r.hash) > 0 && (config.Hasher == nil || !config.Hasher.Enabled) {
+		return nil, fmt.Errorf("hashing fields requires an enabled value hasher")
+	}
+	return r, nil
+}
+
+// Write redacts input and writes it to the wrapped sink
+func (r *RedactingSink) Write(input logprocessor.AnomalyInput) error {
+	return r.sink.Write(r.Redact(input))
+}
+
+// Redact returns a copy of input with the configured fields cleared or hashed
+func (r *RedactingSink) Redact(input logprocessor.AnomalyInput) logprocessor.AnomalyInput {
+	input.Operation = r.redactString("operation", input.Operation)
+	input.Table = r.redactString("table", input.Table)
+	input.RowIdentifier = r.redactString("row_identifier", input.RowIdentifier)
+	input.Column = r.redactString("column", input.Column)
+	input.BeforeValue = r.redactValue("before_value", input.BeforeValue)
+	input.AfterValue = r.redactValue("after_value", input.AfterValue)
+	if r.exclude["timestamp"] {
+		input.Timestamp = time.Time{}
+	}
+	return input
+}
+
+func (r *RedactingSink) redactString(field, value string) string {
+	switch {
+	case r.exclude[field]:
+		return ""
+	case r.hash[field] && value != "":
+		return r.hasher.Key(value)
+	default:
+		return value
+	}
+}
+
+func (r *RedactingSink) redactValue(field string, value interface{}) interface{} {
+	switch {
+	case r.exclude[field]:
+		return nil
+	case r.hash[field] && value != nil:
+		return r.hasher.Key(value)
+	default:
+		return value
+	}
+}
+
+// WriteManifest forwards manifest to the wrapped sink, if it records manifests
+func (r *RedactingSink) WriteManifest(manifest SignalManifest) error {
+	if mw, ok := r.sink.(ManifestWriter); ok {
+		return mw.WriteManifest(manifest)
+	}
+	return nil
+}
+
+// Flush flushes the wrapped sink
+func (r *RedactingSink) Flush() error {
+	return r.sink.Flush()
+}
+
+// Close closes the wrapped sink
+func (r *RedactingSink) Close() error {
+	return r.sink.Close()
+}
@@ -0,0 +1,212 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"log-signal-processor/logprocessor"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// rotationTimeFormat stamps rotated files; it sorts lexically in time order
+const rotationTimeFormat = "20060102T150405.000000"
+
+// RotationConfig controls when a rotating output file is rotated and how many
+// rotated files are kept
+type RotationConfig struct {
+	Path        string
+	Compression string        // As for CreateFile
+	MaxSize     int64         // Rotate once the file reaches this many bytes; 0 disables
+	MaxAge      time.Duration // Rotate once the file has been open this long; 0 disables
+	MaxFiles    int           // Rotated files to keep, oldest deleted first; 0 keeps all
+}
+
+// RotatingSink writes to Path through a sink created by newSink and rotates
+// the file by size or age. On rotation the sink is closed, so every file is
+// complete on its own (e.g. has its own CSV header or Avro header), and the
+// file is renamed with a timestamp before its extension:
+// anomalies.ndjson.gz becomes anomalies-20240501T120000.000000.ndjson.gz.
+type RotatingSink struct {
+	config  RotationConfig
+	newSink func(w io.WriteCloser) (OutputSink, error)
+
+	sink     OutputSink
+	file     *countingFile
+	opened   time.Time
+	manifest *SignalManifest // Repeated at the start of every rotated file
+}
+
+// NewRotatingSink opens config.Path and creates its first sink
+func NewRotatingSink(config RotationConfig, newSink func(w io.WriteCloser) (OutputSink, error)) (*RotatingSink, error) {
+	if config.Path == "" || config.Path == "-" {
+		return nil, fmt.Errorf("output rotation requires a file path")
+	}
+	if config.Compression == "" {
+		config.Compression = CompressionFromPath(config.Path)
+	}
+	r := &RotatingSink{config: config, newSink: newSink}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write writes input to the current file, rotating first if it is due
+func (r *RotatingSink) Write(input logprocessor.AnomalyInput) error {
+	if r.due() {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	return r.sink.Write(input)
+}
+
+// WriteManifest writes manifest to the current file and to every file opened
+// after a rotation
+func (r *RotatingSink) WriteManifest(manifest SignalManifest) error {
+	r.manifest = &manifest
+	if mw, ok := r.sink.(ManifestWriter); ok {
+		return mw.WriteManifest(manifest)
+	}
+	return nil
+}
+
+// Flush flushes the current file's sink
+func (r *RotatingSink) Flush() error {
+	return r.sink.Flush()
+}
+
+// Close closes the current file, which keeps the unstamped name
+func (r *RotatingSink) Close() error {
+	return r.sink.Close()
+}
+
+// due reports whether the current file has reached its size or age limit.
+// Sizes are counted after compression and buffering, so files may slightly
+// exceed MaxSize.
+func (r *RotatingSink) due() bool {
+	if r.config.MaxSize > 0 && r.file.written >= r.config.MaxSize {
+		return true
+	}
+	return r.config.MaxAge > 0 && time.Since(r.opened) >= r.config.MaxAge
+}
+
+// rotate closes the current file, renames it with a timestamp, prunes old
+// files and opens a new one
+func (r *RotatingSink) rotate() error {
+	if err := r.sink.Close(); err != nil {
+		return err
+	}
+	// Rotations within the same microsecond get the next free timestamp
+	stamp := r.opened
+	for {
+		if _, err := os.Stat(r.rotatedName(stamp)); os.IsNotExist(err) {
+			break
+		}
+		stamp = stamp.Add(time.Microsecond)
+	}
+	if err := os.Rename(r.config.Path, r.rotatedName(stamp)); err != nil {
+		return err
+	}
+	if err := r.prune(); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// open creates the file at Path and its sink
+func (r *RotatingSink) open() error {
+	f, err := os.Create(r.config.Path)
+	if err != nil {
+		return err
+	}
+	r.file = &countingFile{file: f}
+	compressed, err := compressFile(r.file, r.config.Compression)
+	if err != nil {
+		return err
+	}
+	sink, err := r.newSink(compressed)
+	if err != nil {
+		compressed.Close()
+		return err
+	}
+	if r.manifest != nil {
+		if mw, ok := sink.(ManifestWriter); ok {
+			if err := mw.WriteManifest(*r.manifest); err != nil {
+				sink.Close()
+				return err
+			}
+		}
+	}
+	r.sink = sink
+	r.opened = time.Now()
+	return nil
+}
+
+// splitPath splits Path into the part before the first dot of its base name
+// and the extensions, so "out/a.ndjson.gz" gives "out/a" and ".ndjson.gz"
+func (r *RotatingSink) splitPath() (string, string) {
+	dir, base := filepath.Split(r.config.Path)
+	if i := strings.Index(base, "."); i > 0 {
+		return dir + base[:i], base[i:]
+	}
+	return r.config.Path, ""
+}
+
+// rotatedName is the name a file opened at t is renamed to
+func (r *RotatingSink) rotatedName(t time.Time) string {
+	stem, ext := r.splitPath()
+	return stem + "-" + t.UTC().Format(rotationTimeFormat) + ext
+}
+
+// prune deletes the oldest rotated files beyond MaxFiles
+func (r *RotatingSink) prune() error {
+	if r.config.MaxFiles <= 0 {
+		return nil
+	}
+	stem, ext := r.splitPath()
+	matches, err := filepath.Glob(escapeGlob(stem) + "-*" + escapeGlob(ext))
+	if err != nil {
+		return err
+	}
+	var rotated []string
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, stem+"-"), ext)
+		if _, err := time.Parse(rotationTimeFormat, stamp); err == nil {
+			rotated = append(rotated, m)
+		}
+	}
+	sort.Strings(rotated)
+
+	for len(rotated) > r.config.MaxFiles {
+		if err := os.Remove(rotated[0]); err != nil {
+			return err
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
+
+// escapeGlob escapes the glob metacharacters in a literal path
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`).Replace(s)
+}
+
+// countingFile counts the bytes written to a file
+type countingFile struct {
+	file    *os.File
+	written int64
+}
+
+func (c *countingFile) Write(p []byte) (int, error) {
+	n, err := c.file.Write(p)
+	c.written += int64(n)
+	return n, err
+}
+
+func (c *countingFile) Close() error {
+	return c.file.Close()
+}
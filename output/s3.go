@@ -0,0 +1,193 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log-signal-processor/logprocessor"
+	"log-signal-processor/objectstore"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+)
+
+// Object formats written by S3Writer
+const (
+	S3FormatNDJSON  = "ndjson"
+	S3FormatParquet = "parquet"
+)
+
+// DefaultS3BatchSize is the number of rows per object when none is configured
+const DefaultS3BatchSize = 10000
+
+// S3Config selects where and how anomaly inputs are archived
+type S3Config struct {
+	URL           string // s3://bucket/prefix or gs://bucket/prefix
+	Endpoint      string // Overrides the service endpoint (e.g. MinIO)
+	Format        string // S3FormatNDJSON (gzip compressed, default) or S3FormatParquet
+	Parquet       ParquetConfig
+	BatchSize     int           // Rows per object
+	FlushInterval time.Duration // Maximum age of a partial object before it is uploaded
+}
+
+// s3Batch buffers the rows of one object
+type s3Batch struct {
+	partition string
+	buf       *bytes.Buffer
+	writer    OutputSink
+	rows      int
+	started   time.Time
+}
+
+// S3Writer buffers anomaly inputs and uploads them as objects partitioned by
+// table and date, e.g. prefix/table=users/dt=2024-05-01/<id>.ndjson.gz, the
+// Hive-style layout understood by Athena, Spark and DuckDB.
+type S3Writer struct {
+	config S3Config
+	client *objectstore.Client
+	prefix string
+
+	batches map[string]*s3Batch
+	seq     int
+}
+
+// NewS3Writer creates a writer for the bucket and prefix in config.URL
+func NewS3Writer(config S3Config) (*S3Writer, error) {
+	switch config.Format {
+	case "":
+		config.Format = S3FormatNDJSON
+	case S3FormatNDJSON, S3FormatParquet:
+	default:
+		return nil, fmt.Errorf("unsupported object format %q (expected ndjson or parquet)", config.Format)
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultS3BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Minute
+	}
+	if _, err := parquetCodec(config.Parquet.Compression); err != nil {
+		return nil, err
+	}
+
+	storeConfig, prefix, err := objectstore.ParseURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	if config.Endpoint != "" {
+		storeConfig.Endpoint = config.Endpoint
+		storeConfig.PathStyle = true
+	}
+	client, err := objectstore.New(storeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Writer{config: config, client: client, prefix: prefix, batches: make(map[string]*s3Batch)}, nil
+}
+
+// Write adds input to its partition's batch, uploading batches that are full or old enough
+func (s *S3Writer) Write(input logprocessor.AnomalyInput) error {
+	ts := input.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	partition := fmt.Sprintf("table=%s/dt=%s", url.PathEscape(input.Table), ts.UTC().Format("2006-01-02"))
+
+	batch, ok := s.batches[partition]
+	if !ok {
+		var err error
+		if batch, err = s.newBatch(partition); err != nil {
+			return err
+		}
+		s.batches[partition] = batch
+	}
+	if err := batch.writer.Write(input); err != nil {
+		return err
+	}
+	batch.rows++
+
+	if batch.rows >= s.config.BatchSize {
+		if err := s.upload(batch); err != nil {
+			return err
+		}
+	}
+	return s.uploadExpired()
+}
+
+// Flush uploads every partial batch
+func (s *S3Writer) Flush() error {
+	partitions := make([]string, 0, len(s.batches))
+	for partition := range s.batches {
+		partitions = append(partitions, partition)
+	}
+	sort.Strings(partitions)
+
+	for _, partition := range partitions {
+		if err := s.upload(s.batches[partition]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close uploads every partial batch
+func (s *S3Writer) Close() error {
+	return s.Flush()
+}
+
+// newBatch starts an object for partition in the configured format
+func (s *S3Writer) newBatch(partition string) (*s3Batch, error) {
+	batch := &s3Batch{partition: partition, buf: &bytes.Buffer{}, started: time.Now()}
+	switch s.config.Format {
+	case S3FormatParquet:
+		writer, err := NewParquetWriter(nopCloser{batch.buf}, s.config.Parquet)
+		if err != nil {
+			return nil, err
+		}
+		batch.writer = writer
+	default:
+		batch.writer = NewNDJSONWriter(gzip.NewWriter(batch.buf))
+	}
+	return batch, nil
+}
+
+// uploadExpired uploads batches older than the flush interval
+func (s *S3Writer) uploadExpired() error {
+	for _, batch := range s.batches {
+		if time.Since(batch.started) < s.config.FlushInterval {
+			continue
+		}
+		if err := s.upload(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upload finishes a batch's object and puts it in the bucket
+func (s *S3Writer) upload(batch *s3Batch) error {
+	delete(s.batches, batch.partition)
+	if err := batch.writer.Close(); err != nil {
+		return err
+	}
+
+	s.seq++
+	name := fmt.Sprintf("%d-%06d", time.Now().UnixNano(), s.seq)
+	contentType := "application/x-ndjson"
+	switch s.config.Format {
+	case S3FormatParquet:
+		name += ".parquet"
+		contentType = "application/vnd.apache.parquet"
+	default:
+		name += ".ndjson.gz"
+	}
+
+	key := path.Join(s.prefix, batch.partition, name)
+	if err := s.client.Put(context.Background(), key, batch.buf.Bytes(), contentType); err != nil {
+		return fmt.Errorf("uploading %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package output
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Output schema versions. Version 1 is the original layout; version 2 adds
+// the schema_version field to every record. Older versions can still be
+// emitted so consumers upgrade independently of the processor.
+const (
+	AnomalyInputSchemaVersion    = 2 // Current version
+	MinAnomalyInputSchemaVersion = 1 // Oldest version that can still be emitted
+)
+
+//go:embed anomaly_input.v1.schema.json
+var anomalyInputJSONSchemaV1 []byte
+
+//go:embed anomaly_input.v2.schema.json
+var anomalyInputJSONSchemaV2 []byte
+
+// AnomalyInputJSONSchema is the JSON Schema (draft 2020-12) of an AnomalyInput
+// in the current layout, as written by NDJSONWriter and JSONEncoder
+var AnomalyInputJSONSchema = anomalyInputJSONSchemaV2
+
+// JSONSchema returns the JSON Schema of the given output schema version
+func JSONSchema(version int) ([]byte, error) {
+	switch version {
+	case 1:
+		return anomalyInputJSONSchemaV1, nil
+	case 2:
+		return anomalyInputJSONSchemaV2, nil
+	default:
+		return nil, fmt.Errorf("unknown output schema version %d (supported: %d to %d)", version, MinAnomalyInputSchemaVersion, AnomalyInputSchemaVersion)
+	}
+}
+
+// CheckSchemaVersion returns an error unless version can be emitted
+func CheckSchemaVersion(version int) error {
+	_, err := JSONSchema(version)
+	return err
+}
+
+// anomalyInputSchemas are the JSON schemas decoded for validation, by version
+var anomalyInputSchemas = func() map[int]map[string]interface{} {
+	schemas := make(map[int]map[string]interface{})
+	for version := MinAnomalyInputSchemaVersion; version <= AnomalyInputSchemaVersion; version++ {
+		data, _ := JSONSchema(version)
+		var schema map[string]interface{}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			panic(fmt.Sprintf("invalid embedded AnomalyInput schema version %d: %v", version, err))
+		}
+		schemas[version] = schema
+	}
+	return schemas
+}()
+
+// ValidateAnomalyInputJSON checks that data is a single AnomalyInput JSON
+// object conforming to the JSON Schema of its version: the version given by
+// its schema_version field, or version 1 when that is absent. Every violation
+// is reported.
+func ValidateAnomalyInputJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	version := 1
+	if object, ok := value.(map[string]interface{}); ok {
+		if v, ok := object["schema_version"].(float64); ok {
+			version = int(v)
+		}
+	}
+	schema, ok := anomalyInputSchemas[version]
+	if !ok {
+		return CheckSchemaVersion(version)
+	}
+
+	var errs []string
+	validateSchema(schema, value, "$", &errs)
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validateSchema checks value against the JSON Schema keywords used by the
+// AnomalyInput schemas: type, const, required, properties,
+// additionalProperties, items, minLength and the date-time format
+func validateSchema(schema map[string]interface{}, value interface{}, path string, errs *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if typ, ok := schema["type"].(string); ok && !hasJSONType(value, typ) {
+		fail("expected %s, got %s", typ, jsonType(value))
+		return
+	}
+	if c, ok := schema["const"]; ok && c != value {
+		fail("expected %v, got %v", c, value)
+	}
+
+	switch v := value.(type) {
+	case string:
+		if min, ok := schema["minLength"].(float64); ok && float64(len([]rune(v))) < min {
+			fail("shorter than %g characters", min)
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				fail("not an RFC 3339 date-time: %q", v)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := v[name.(string)]; !ok {
+					fail("missing required property %q", name)
+				}
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propSchema, ok := properties[name].(map[string]interface{})
+			if !ok {
+				if schema["additionalProperties"] == false {
+					fail("unexpected property %q", name)
+				}
+				continue
+			}
+			validateSchema(propSchema, v[name], path+"."+name, errs)
+		}
+	}
+}
+
+// hasJSONType reports whether a decoded JSON value has the named schema type
+func hasJSONType(value interface{}, typ string) bool {
+	switch typ {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	default:
+		return jsonType(value) == typ
+	}
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
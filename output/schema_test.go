@@ -0,0 +1,106 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"log-signal-processor/logprocessor"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeNDJSON writes inputs through an NDJSONWriter and returns its lines
+func writeNDJSON(t *testing.T, inputs ...logprocessor.AnomalyInput) [][]byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := NewNDJSONWriter(nopCloser{&buf})
+	for _, input := range inputs {
+		if err := writer.Write(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+}
+
+// schemaInput returns an input laid out the way the processor emits version
+// version: the version 1 layout has no schema_version or session field, and
+// rules and detectors, which flag inputs, need version 2
+func schemaInput(version int) logprocessor.AnomalyInput {
+	input := logprocessor.AnomalyInput{
+		SchemaVersion: version,
+		Operation:     "UPDATE",
+		Table:         "users",
+		RowIdentifier: "42",
+		Session:       "tx-7",
+		Column:        "email",
+		Timestamp:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		BeforeValue:   "a@example.com",
+		AfterValue:    "b@example.com",
+		SignalVector:  []float64{0.5, 1, 0},
+	}
+	if version == 1 {
+		input.SchemaVersion, input.Session = 0, ""
+		return input
+	}
+	input.Anomalous = true
+	input.Severity = logprocessor.SeverityWarn
+	input.MatchedRules = []string{"email-change"}
+	return input
+}
+
+func TestNDJSONRecordsMatchSchema(t *testing.T) {
+	for version := MinAnomalyInputSchemaVersion; version <= AnomalyInputSchemaVersion; version++ {
+		insert := schemaInput(version)
+		insert.Operation, insert.BeforeValue = "INSERT", nil
+		for i, line := range writeNDJSON(t, schemaInput(version), insert) {
+			if err := ValidateAnomalyInputJSON(line); err != nil {
+				t.Errorf("version %d, record %d: %v\n%s", version, i, err, line)
+			}
+		}
+	}
+}
+
+func TestValidateAnomalyInputJSONRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		edit    func(record map[string]interface{})
+		want    string
+	}{
+		{"missing table", 1, func(r map[string]interface{}) { delete(r, "table") }, `missing required property "table"`},
+		{"missing signal vector", 2, func(r map[string]interface{}) { delete(r, "signal_vector") }, `missing required property "signal_vector"`},
+		{"missing schema version", 2, func(r map[string]interface{}) {
+			// Without the field the record is read as version 1, which has no session
+			delete(r, "schema_version")
+		}, "session"},
+		{"table not a string", 1, func(r map[string]interface{}) { r["table"] = 7 }, "$.table: expected string"},
+		{"signal vector not an array", 2, func(r map[string]interface{}) { r["signal_vector"] = "0.5,1,0" }, "$.signal_vector: expected array"},
+		{"signal not a number", 2, func(r map[string]interface{}) { r["signal_vector"] = []interface{}{0.5, "1"} }, "$.signal_vector[1]: expected number"},
+		{"timestamp not a date-time", 1, func(r map[string]interface{}) { r["timestamp"] = "yesterday" }, "$.timestamp: not an RFC 3339 date-time"},
+		{"wrong schema version", 2, func(r map[string]interface{}) { r["schema_version"] = 3 }, "unknown output schema version 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var record map[string]interface{}
+			if err := json.Unmarshal(writeNDJSON(t, schemaInput(tt.version))[0], &record); err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(record)
+			data, err := json.Marshal(record)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ValidateAnomalyInputJSON(data)
+			if err == nil {
+				t.Fatalf("accepted %s", data)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package output
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log-signal-processor/logprocessor"
+	"strings"
+	"time"
+	"unicode"
+
+	// Database drivers for SQLWriter
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// Supported SQL drivers
+const (
+	SQLDriverSQLite   = "sqlite"
+	SQLDriverPostgres = "postgres"
+)
+
+// Defaults for SQL output
+const (
+	DefaultSQLTable     = "anomaly_inputs"
+	DefaultSQLBatchSize = 500
+)
+
+// SQLConfig selects the database and table anomaly inputs are stored in
+type SQLConfig struct {
+	Driver string // SQLDriverSQLite or SQLDriverPostgres
+	DSN    string // File path for SQLite, connection string or URL for Postgres
+	Table  string
+	// SignalNames become one numeric column per signal (e.g. signal_entropy),
+	// so results can be filtered and ranked by signal in plain SQL
+	SignalNames []string
+	BatchSize   int // Rows per transaction
+}
+
+// SQLWriter stores anomaly inputs in a SQLite or Postgres table, creating it
+// on first use. Besides one column per named signal, the full vector is kept
+// as JSON in signal_vector.
+type SQLWriter struct {
+	config  SQLConfig
+	db      *sql.DB
+	insert  string
+	pending []logprocessor.AnomalyInput
+}
+
+// NewSQLWriter opens the database and creates the table if it does not exist
+func NewSQLWriter(config SQLConfig) (*SQLWriter, error) {
+	if config.Driver != SQLDriverSQLite && config.Driver != SQLDriverPostgres {
+		return nil, fmt.Errorf("unsupported sql driver %q (expected sqlite or postgres)", config.Driver)
+	}
+	if config.Table == "" {
+		config.Table = DefaultSQLTable
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultSQLBatchSize
+	}
+
+	db, err := sql.Open(config.Driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	s := &SQLWriter{config: config, db: db}
+	if err := s.createTable(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.insert = s.insertStatement()
+	return s, nil
+}
+
+// Write queues input, committing the batch once it is full
+func (s *SQLWriter) Write(input logprocessor.AnomalyInput) error {
+	if len(s.config.SignalNames) > 0 && len(input.SignalVector) != len(s.config.SignalNames) {
+		return fmt.Errorf("signal vector has %d entries, expected %d", len(input.SignalVector), len(s.config.SignalNames))
+	}
+	s.pending = append(s.pending, input)
+	if len(s.pending) < s.config.BatchSize {
+		return nil
+	}
+	return s.Flush()
+}
+
+// Close commits the last batch and closes the database
+func (s *SQLWriter) Close() error {
+	err := s.Flush()
+	if closeErr := s.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Flush inserts the queued inputs in a single transaction
+func (s *SQLWriter) Flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(s.insert)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, input := range s.pending {
+		vector, err := json.Marshal(input.SignalVector)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		args := []interface{}{
+			input.Operation,
+			input.Table,
+			input.RowIdentifier,
+			input.Column,
+			s.timestamp(input.Timestamp),
+			optionalValue(input.BeforeValue),
+			optionalValue(input.AfterValue),
+			string(vector),
+		}
+		for i := range s.config.SignalNames {
+			args = append(args, input.SignalVector[i])
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	s.pending = s.pending[:0]
+	return tx.Commit()
+}
+
+// createTable creates the results table and its timestamp index
+func (s *SQLWriter) createTable() error {
+	timestampType, realType := "TEXT", "REAL"
+	if s.config.Driver == SQLDriverPostgres {
+		timestampType, realType = "TIMESTAMPTZ", "DOUBLE PRECISION"
+	}
+
+	columns := []string{
+		"operation TEXT NOT NULL",
+		"table_name TEXT NOT NULL",
+		"row_identifier TEXT NOT NULL",
+		"column_name TEXT NOT NULL",
+		"timestamp " + timestampType + " NOT NULL",
+		"before_value TEXT",
+		"after_value TEXT",
+		"signal_vector TEXT NOT NULL",
+	}
+	for _, name := range s.config.SignalNames {
+		columns = append(columns, signalColumn(name)+" "+realType)
+	}
+
+	table := quoteSQLIdentifier(s.config.Table)
+	ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s\n)", table, strings.Join(columns, ",\n\t"))
+	if _, err := s.db.Exec(ddl); err != nil {
+		return fmt.Errorf("creating table %s: %w", s.config.Table, err)
+	}
+
+	index := quoteSQLIdentifier(s.config.Table + "_timestamp_idx")
+	if _, err := s.db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (timestamp)", index, table)); err != nil {
+		return fmt.Errorf("creating index on %s: %w", s.config.Table, err)
+	}
+	return nil
+}
+
+// insertStatement builds the INSERT with the driver's placeholder style
+func (s *SQLWriter) insertStatement() string {
+	columns := []string{"operation", "table_name", "row_identifier", "column_name", "timestamp", "before_value", "after_value", "signal_vector"}
+	for _, name := range s.config.SignalNames {
+		columns = append(columns, signalColumn(name))
+	}
+
+	placeholders := make([]string, len(columns))
+	for i := range placeholders {
+		placeholders[i] = "?"
+		if s.config.Driver == SQLDriverPostgres {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteSQLIdentifier(s.config.Table), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+}
+
+// timestamp converts t for the driver. SQLite stores UTC text in the format
+// its date functions understand.
+func (s *SQLWriter) timestamp(t time.Time) interface{} {
+	if s.config.Driver == SQLDriverSQLite {
+		return t.UTC().Format("2006-01-02 15:04:05.000000")
+	}
+	return t
+}
+
+// signalColumn derives a column name from a signal name, e.g. "Entropy" -> "signal_entropy"
+func signalColumn(name string) string {
+	var b strings.Builder
+	b.WriteString("signal_")
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// quoteSQLIdentifier quotes an identifier for SQLite and Postgres
+func quoteSQLIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,192 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log-signal-processor/logprocessor"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSTIXMinScore is the signal score an input needs to be exported when none is configured
+const DefaultSTIXMinScore = 4
+
+// stixNamespace is the UUIDv5 namespace STIX 2.1 defines for deterministic
+// cyber-observable identifiers
+var stixNamespace = uuid.MustParse("00abedb4-aa42-466c-9c01-fed23315a9b7")
+
+// stixTimeFormat is the STIX timestamp format (UTC, millisecond precision)
+const stixTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// STIXConfig selects which anomaly inputs are exported as STIX
+type STIXConfig struct {
+	MinScore    float64 // Inputs whose largest absolute signal is below this are skipped
+	SignalNames []string
+	Identity    string // Name of the producing identity; defaults to DefaultSIEMVendor
+}
+
+// STIXWriter collects high-scoring anomaly inputs and writes them as a STIX
+// 2.1 bundle when closed, for sharing with threat-intel platforms such as
+// MISP. Each input becomes an x-database-change observable, the observed-data
+// object that saw it, and an indicator matching the changed row based on
+// that observation.
+type STIXWriter struct {
+	w        io.WriteCloser
+	config   STIXConfig
+	identity string
+	created  string
+	objects  []interface{}
+	seen     map[string]bool // Observables already in the bundle
+}
+
+// NewSTIXWriter creates a writer that closes w when it is closed
+func NewSTIXWriter(w io.WriteCloser, config STIXConfig) *STIXWriter {
+	if config.Identity == "" {
+		config.Identity = DefaultSIEMVendor
+	}
+	identity := "identity--" + uuid.NewSHA1(stixNamespace, []byte(config.Identity)).String()
+	created := time.Now().UTC().Format(stixTimeFormat)
+
+	return &STIXWriter{
+		w:        w,
+		config:   config,
+		identity: identity,
+		created:  created,
+		seen:     make(map[string]bool),
+		objects: []interface{}{map[string]interface{}{
+			"type":           "identity",
+			"spec_version":   "2.1",
+			"id":             identity,
+			"created":        created,
+			"modified":       created,
+			"name":           config.Identity,
+			"identity_class": "system",
+		}},
+	}
+}
+
+// Write adds input to the bundle if its score reaches the configured minimum
+func (s *STIXWriter) Write(input logprocessor.AnomalyInput) error {
+	inputScore := score(input.SignalVector)
+	if inputScore < s.config.MinScore {
+		return nil
+	}
+	observed := input.Timestamp.UTC().Format(stixTimeFormat)
+
+	change := map[string]interface{}{
+		"type":         "x-database-change",
+		"spec_version": "2.1",
+		"operation":    input.Operation,
+		"table_name":   input.Table,
+		"column_name":  input.Column,
+	}
+	if input.RowIdentifier != "" {
+		change["row_identifier"] = input.RowIdentifier
+	}
+	// SCO identifiers are derived from their identifying properties, so the
+	// same row and column always map to the same observable
+	key, err := json.Marshal([]string{input.Table, input.Column, input.RowIdentifier})
+	if err != nil {
+		return err
+	}
+	change["id"] = "x-database-change--" + uuid.NewSHA1(stixNamespace, key).String()
+
+	signals := make(map[string]float64, len(input.SignalVector))
+	for i, v := range input.SignalVector {
+		signals[signalName(s.config.SignalNames, i)] = v
+	}
+
+	observation := map[string]interface{}{
+		"type":            "observed-data",
+		"spec_version":    "2.1",
+		"id":              "observed-data--" + uuid.NewString(),
+		"created_by_ref":  s.identity,
+		"created":         s.created,
+		"modified":        s.created,
+		"first_observed":  observed,
+		"last_observed":   observed,
+		"number_observed": 1,
+		"object_refs":     []string{change["id"].(string)},
+		"x_signals":       signals,
+	}
+
+	pattern := []string{
+		fmt.Sprintf("x-database-change:table_name = '%s'", stixString(input.Table)),
+		fmt.Sprintf("x-database-change:column_name = '%s'", stixString(input.Column)),
+	}
+	if input.RowIdentifier != "" {
+		pattern = append(pattern, fmt.Sprintf("x-database-change:row_identifier = '%s'", stixString(input.RowIdentifier)))
+	}
+	indicator := map[string]interface{}{
+		"type":            "indicator",
+		"spec_version":    "2.1",
+		"id":              "indicator--" + uuid.NewString(),
+		"created_by_ref":  s.identity,
+		"created":         s.created,
+		"modified":        s.created,
+		"name":            fmt.Sprintf("Anomalous %s of %s.%s", strings.ToLower(input.Operation), input.Table, input.Column),
+		"description":     fmt.Sprintf("Signal score %g (%s)", inputScore, formatSignals(s.config.SignalNames, input.SignalVector)),
+		"indicator_types": []string{"anomalous-activity"},
+		"pattern":         "[" + strings.Join(pattern, " AND ") + "]",
+		"pattern_type":    "stix",
+		"valid_from":      observed,
+	}
+
+	relationship := map[string]interface{}{
+		"type":              "relationship",
+		"spec_version":      "2.1",
+		"id":                "relationship--" + uuid.NewString(),
+		"created_by_ref":    s.identity,
+		"created":           s.created,
+		"modified":          s.created,
+		"relationship_type": "based-on",
+		"source_ref":        indicator["id"],
+		"target_ref":        observation["id"],
+	}
+
+	if !s.seen[change["id"].(string)] {
+		s.seen[change["id"].(string)] = true
+		s.objects = append(s.objects, change)
+	}
+	s.objects = append(s.objects, observation, indicator, relationship)
+	return nil
+}
+
+// Flush does nothing; a bundle is a single JSON document, so it is only
+// written when the writer is closed
+func (s *STIXWriter) Flush() error {
+	return nil
+}
+
+// Close writes the bundle and closes the underlying writer
+func (s *STIXWriter) Close() error {
+	bundle := map[string]interface{}{
+		"type":    "bundle",
+		"id":      "bundle--" + uuid.NewString(),
+		"objects": s.objects,
+	}
+	encoder := json.NewEncoder(s.w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(bundle); err != nil {
+		s.w.Close()
+		return err
+	}
+	return s.w.Close()
+}
+
+// stixString escapes a string literal for a STIX pattern
+func stixString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+// formatSignals lists signal values in vector order as name=value pairs
+func formatSignals(names []string, signals []float64) string {
+	parts := make([]string, len(signals))
+	for i, v := range signals {
+		parts[i] = fmt.Sprintf("%s=%g", signalName(names, i), v)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,109 @@
+package output
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log-signal-processor/logprocessor"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Syslog facilities commonly used for security events
+const (
+	SyslogFacilityAuthPriv = 10
+	SyslogFacilityLocal0   = 16
+)
+
+// syslogSeverityNotice is the severity of every message; the event's own
+// severity is carried in the CEF or LEEF payload
+const syslogSeverityNotice = 5
+
+// SyslogConfig selects the SIEM collector anomaly events are sent to
+type SyslogConfig struct {
+	Network  string // "udp", "tcp" or "tls"
+	Addr     string // Collector address (host:port)
+	Encoder  Encoder
+	Facility int    // Defaults to SyslogFacilityLocal0
+	AppName  string // APP-NAME header field
+	Hostname string // HOSTNAME header field; defaults to os.Hostname
+}
+
+// SyslogWriter sends each anomaly input as an RFC 5424 syslog message, for
+// SIEMs that ingest CEF or LEEF over syslog. TCP and TLS streams use
+// octet-counting framing (RFC 6587).
+type SyslogWriter struct {
+	config SyslogConfig
+	conn   net.Conn
+}
+
+// NewSyslogWriter connects to the configured collector
+func NewSyslogWriter(config SyslogConfig) (*SyslogWriter, error) {
+	if config.Addr == "" {
+		return nil, fmt.Errorf("syslog address is required")
+	}
+	if config.Encoder == nil {
+		config.Encoder = CEFEncoder{}
+	}
+	if config.Facility == 0 {
+		config.Facility = SyslogFacilityLocal0
+	}
+	if config.AppName == "" {
+		config.AppName = DefaultSIEMProduct
+	}
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+		if config.Hostname == "" {
+			config.Hostname = "-"
+		}
+	}
+
+	var conn net.Conn
+	var err error
+	switch config.Network {
+	case "", "udp":
+		config.Network = "udp"
+		conn, err = net.Dial("udp", config.Addr)
+	case "tcp":
+		conn, err = net.DialTimeout("tcp", config.Addr, 10*time.Second)
+	case "tls":
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", config.Addr, nil)
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s", config.Network)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to syslog collector: %w", err)
+	}
+	return &SyslogWriter{config: config, conn: conn}, nil
+}
+
+// Write sends input as one syslog message
+func (w *SyslogWriter) Write(input logprocessor.AnomalyInput) error {
+	payload, err := w.config.Encoder.Encode(input)
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Appendf(nil, "<%d>1 %s %s %s %d - - ",
+		w.config.Facility*8+syslogSeverityNotice,
+		time.Now().UTC().Format(time.RFC3339Nano),
+		w.config.Hostname, w.config.AppName, os.Getpid())
+	msg = append(msg, payload...)
+
+	if w.config.Network != "udp" {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	_, err = w.conn.Write(msg)
+	return err
+}
+
+// Flush does nothing; messages are sent as they are written
+func (w *SyslogWriter) Flush() error {
+	return nil
+}
+
+// Close closes the connection to the collector
+func (w *SyslogWriter) Close() error {
+	return w.conn.Close()
+}
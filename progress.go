@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"log-signal-processor/cli"
+	"log-signal-processor/logprocessor"
+)
+
+// showProgress shows the progress screen of simulator runs in a terminal
+var showProgress = flag.Bool("progress", true, "show a live progress screen with the rows processed, rows/s, running signal statistics and anomalies flagged while the simulator runs in a terminal, in place of the console logs")
+
+// progressUpdateInterval is how often the progress screen is redrawn
+const progressUpdateInterval = 100 * time.Millisecond
+
+// Progress screen of the current run, when shown
+var (
+	progress        *cli.Progress
+	progressTotal   int // Logs the run reads; zero when unknown
+	progressUpdated time.Time
+	resumeLogging   func()
+)
+
+// openProgress shows the progress screen of a run reading total logs, unless
+// -quiet or -progress=false is set, stdout is not a terminal or an output
+// file is written to it
+func openProgress(total int) {
+	if *quiet || !*showProgress || !stdoutIsTerminal() {
+		return
+	}
+	toStdout := false
+	commandLine.VisitAll(func(f *flag.Flag) {
+		toStdout = toStdout || f.Value.String() == "-"
+	})
+	if toStdout {
+		return
+	}
+
+	progress = cli.StartProgress("Processing simulated logs")
+	progressTotal = total
+	// The screen replaces the console logs, and other messages are
+	// printed above it
+	resumeLogging = logprocessor.PauseLogging()
+	log.SetOutput(progressWriter{})
+}
+
+// updateProgress redraws the progress screen, at most every
+// progressUpdateInterval
+func updateProgress() {
+	if progress == nil || time.Since(progressUpdated) < progressUpdateInterval {
+		return
+	}
+	progressUpdated = time.Now()
+	progress.Update(summary.progress(progressTotal))
+}
+
+// closeProgress shows the final statistics of the run and restores the logs
+func closeProgress() {
+	if progress == nil {
+		return
+	}
+	progress.Finish(summary.progress(progressTotal))
+	progress = nil
+	log.SetOutput(os.Stderr)
+	resumeLogging()
+}
+
+// progressWriter prints the standard logger's messages above the progress
+// screen
+type progressWriter struct{}
+
+func (progressWriter) Write(p []byte) (int, error) {
+	progress.Println(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// stdoutIsTerminal reports whether stdout is a terminal rather than a file or
+// a pipe
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// progress returns a snapshot of the summary for the progress screen of a
+// run reading total logs
+func (s *runSummary) progress(total int) cli.ProgressStats {
+	stats := cli.ProgressStats{
+		Rows:        s.Logs,
+		Total:       total,
+		Elapsed:     time.Since(s.Started),
+		ParseErrors: s.ParseErrors,
+		Anomalies:   s.Anomalies,
+		Suppressed:  s.Suppressed,
+	}
+	for name, signal := range s.Signals {
+		stats.Signals = append(stats.Signals, cli.SignalStats{
+			Name:     name,
+			Count:    signal.Count,
+			Mean:     signal.Mean,
+			Min:      signal.Min,
+			Max:      signal.Max,
+			Detected: signal.Detected,
+		})
+	}
+	sort.Slice(stats.Signals, func(i, j int) bool {
+		return stats.Signals[i].Name < stats.Signals[j].Name
+	})
+	return stats
+}
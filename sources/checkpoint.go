@@ -0,0 +1,165 @@
+package sources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CheckpointStore persists source positions so a restarted process resumes
+// where it left off. Positions are keyed by source name, then by partition
+// (see Record.Partition).
+type CheckpointStore interface {
+	Load(source string) (map[string]string, error)
+	Save(source string, positions map[string]string) error
+}
+
+// Resumable is implemented by sources that can start from saved positions.
+// Resume must be called before Read.
+type Resumable interface {
+	Resume(positions map[string]string) error
+}
+
+// FileCheckpointStore keeps every source's positions in a single JSON file,
+// rewritten atomically on each save.
+type FileCheckpointStore struct {
+	path string
+
+	mu        sync.Mutex
+	positions map[string]map[string]string
+}
+
+// NewFileCheckpointStore opens the checkpoint file at path, which need not exist yet
+func NewFileCheckpointStore(path string) (*FileCheckpointStore, error) {
+	store := &FileCheckpointStore{path: path, positions: make(map[string]map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.positions); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Load returns the saved positions for source, or nil if it has none
+func (s *FileCheckpointStore) Load(source string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := s.positions[source]
+	if saved == nil {
+		return nil, nil
+	}
+	positions := make(map[string]string, len(saved))
+	for partition, pos := range saved {
+		positions[partition] = pos
+	}
+	return positions, nil
+}
+
+// Save replaces the positions for source and writes the file
+func (s *FileCheckpointStore) Save(source string, positions map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := make(map[string]string, len(positions))
+	for partition, pos := range positions {
+		saved[partition] = pos
+	}
+	s.positions[source] = saved
+
+	data, err := json.MarshalIndent(s.positions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename so a crash never leaves a truncated checkpoint
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Checkpointer records the position of each processed record and periodically
+// saves them. Marking a record only after it has been processed gives
+// at-least-once delivery: a restart may repeat events, but never skips them.
+type Checkpointer struct {
+	store    CheckpointStore
+	source   string
+	interval time.Duration
+
+	positions map[string]string
+	dirty     bool
+	lastSave  time.Time
+
+	// BeforeSave, if set, is called before positions are saved, e.g. to flush
+	// buffered output so a saved position never runs ahead of delivered data
+	BeforeSave func() error
+}
+
+// NewCheckpointer creates a checkpointer saving at most once per interval
+func NewCheckpointer(store CheckpointStore, source string, interval time.Duration) *Checkpointer {
+	return &Checkpointer{
+		store:     store,
+		source:    source,
+		interval:  interval,
+		positions: make(map[string]string),
+		lastSave:  time.Now(),
+	}
+}
+
+// Mark records that record has been fully processed, saving if the interval has elapsed
+func (c *Checkpointer) Mark(record Record) error {
+	if record.Position == "" {
+		return nil
+	}
+	c.positions[record.Partition] = record.Position
+	c.dirty = true
+
+	if time.Since(c.lastSave) < c.interval {
+		return nil
+	}
+	return c.Flush()
+}
+
+// Flush saves any positions marked since the last save
+func (c *Checkpointer) Flush() error {
+	if !c.dirty {
+		return nil
+	}
+	if c.BeforeSave != nil {
+		if err := c.BeforeSave(); err != nil {
+			return err
+		}
+	}
+	if err := c.store.Save(c.source, c.positions); err != nil {
+		return err
+	}
+	c.dirty = false
+	c.lastSave = time.Now()
+	return nil
+}
@@ -0,0 +1,378 @@
+package sources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log-signal-processor/metrics"
+	"log-signal-processor/sigv4"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Shard iterator types used when no checkpoint exists for a shard
+const (
+	KinesisStartTrimHorizon = "TRIM_HORIZON"
+	KinesisStartLatest      = "LATEST"
+)
+
+// malformedRecordsMetric counts Kinesis records skipped because their payload
+// is not a JSON object
+const malformedRecordsMetric = "source_malformed_records"
+
+// KinesisConfig selects the stream to consume
+type KinesisConfig struct {
+	StreamName string
+	Region     string
+	Endpoint   string // Overrides the service endpoint (e.g. LocalStack)
+	StartAt    string // TRIM_HORIZON or LATEST for shards without a checkpoint
+	// StartingSequences maps shard IDs to the last processed sequence number;
+	// consumption resumes after these positions.
+	StartingSequences map[string]string
+	PollInterval      time.Duration
+}
+
+// KinesisSource consumes every shard of a Kinesis stream. Record payloads must
+// be JSON objects (e.g. DMS or DynamoDB Streams change records); other
+// payloads are logged, counted and skipped. Records are partitioned by shard
+// ID with the sequence number as their position.
+type KinesisSource struct {
+	config KinesisConfig
+	signer *sigv4.Signer
+	http   *http.Client
+
+	mu         sync.Mutex
+	checkpoint map[string]string // Shard ID -> last emitted sequence number
+}
+
+// NewKinesisSource creates a consumer for the configured stream
+func NewKinesisSource(config KinesisConfig) (*KinesisSource, error) {
+	if config.StreamName == "" {
+		return nil, fmt.Errorf("kinesis stream name is required")
+	}
+	if config.Region == "" {
+		config.Region = sigv4.RegionFromEnv("us-east-1")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://kinesis.%s.amazonaws.com", config.Region)
+	}
+	if config.StartAt == "" {
+		config.StartAt = KinesisStartTrimHorizon
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+
+	checkpoint := make(map[string]string, len(config.StartingSequences))
+	for shard, seq := range config.StartingSequences {
+		checkpoint[shard] = seq
+	}
+
+	return &KinesisSource{
+		config:     config,
+		signer:     &sigv4.Signer{Credentials: sigv4.CredentialsFromEnv(), Region: config.Region, Service: "kinesis"},
+		http:       &http.Client{Timeout: 30 * time.Second},
+		checkpoint: checkpoint,
+	}, nil
+}
+
+// Read consumes all shards concurrently until ctx is cancelled or every shard
+// is closed. Shards created by resharding are read once their parents are
+// closed and fully read, so each partition key's records stay in order.
+func (s *KinesisSource) Read(ctx context.Context, out chan<- Record) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type shardResult struct {
+		shardID string
+		err     error
+	}
+	results := make(chan shardResult)
+	started := make(map[string]bool)
+	finished := make(map[string]bool)
+	running := 0
+
+	// wait stops the running shards and returns the first error
+	wait := func(err error) error {
+		cancel()
+		for ; running > 0; running-- {
+			if result := <-results; err == nil {
+				err = result.err
+			}
+		}
+		return err
+	}
+
+	for {
+		shards, err := s.listShards(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return wait(nil)
+			}
+			return wait(err)
+		}
+
+		listed := make(map[string]bool, len(shards))
+		for _, shard := range shards {
+			listed[shard.ShardId] = true
+		}
+		for _, shard := range shards {
+			if started[shard.ShardId] || !shard.parentsRead(listed, finished) {
+				continue
+			}
+			started[shard.ShardId] = true
+			running++
+			go func(shardID string) {
+				err := s.readShard(ctx, shardID, out)
+				if err != nil {
+					err = fmt.Errorf("shard %s: %w", shardID, err)
+				}
+				results <- shardResult{shardID: shardID, err: err}
+			}(shard.ShardId)
+		}
+		if running == 0 {
+			return nil
+		}
+
+		// List the shards again whenever one closes, to start its children
+		result := <-results
+		running--
+		if result.err != nil || ctx.Err() != nil {
+			return wait(result.err)
+		}
+		finished[result.shardID] = true
+	}
+}
+
+// Checkpoint returns the last emitted sequence number for each shard
+func (s *KinesisSource) Checkpoint() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	positions := make(map[string]string, len(s.checkpoint))
+	for shard, seq := range s.checkpoint {
+		positions[shard] = seq
+	}
+	return positions
+}
+
+// Resume continues each shard after its saved sequence number
+func (s *KinesisSource) Resume(positions map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for shard, seq := range positions {
+		s.checkpoint[shard] = seq
+	}
+	return nil
+}
+
+// Close releases the source
+func (s *KinesisSource) Close() error {
+	return nil
+}
+
+// readShard follows a shard's iterator chain, emitting each record
+func (s *KinesisSource) readShard(ctx context.Context, shardID string, out chan<- Record) error {
+	malformed := metrics.Default.Counter(malformedRecordsMetric)
+	iterator, err := s.shardIterator(ctx, shardID)
+	if err != nil {
+		return err
+	}
+
+	for iterator != "" {
+		var resp struct {
+			Records []struct {
+				Data           []byte
+				SequenceNumber string
+			}
+			NextShardIterator  string
+			MillisBehindLatest int64
+		}
+		err := s.call(ctx, "GetRecords", map[string]interface{}{"ShardIterator": iterator, "Limit": 1000}, &resp)
+		if err != nil {
+			if isThrottled(err) {
+				if !sleepContext(ctx, s.config.PollInterval) {
+					return nil
+				}
+				continue
+			}
+			// Iterators expire after five minutes, e.g. behind a slow consumer;
+			// a new one continues after the last emitted record
+			if isExpiredIterator(err) {
+				if iterator, err = s.shardIterator(ctx, shardID); err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		for _, rec := range resp.Records {
+			var raw map[string]interface{}
+			if err := json.Unmarshal(rec.Data, &raw); err != nil {
+				log.Printf("Skipping malformed record %s in shard %s: %v", rec.SequenceNumber, shardID, err)
+				malformed.Inc()
+			} else {
+				select {
+				case out <- Record{Raw: raw, Position: rec.SequenceNumber, Partition: shardID}:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+
+			// A skipped record is checkpointed too, so a restart does not
+			// read it again
+			s.mu.Lock()
+			s.checkpoint[shardID] = rec.SequenceNumber
+			s.mu.Unlock()
+		}
+
+		// A closed shard returns no next iterator once fully read
+		iterator = resp.NextShardIterator
+		if len(resp.Records) == 0 && resp.MillisBehindLatest == 0 {
+			if !sleepContext(ctx, s.config.PollInterval) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// shardIterator starts after the shard's checkpoint, or at StartAt without one
+func (s *KinesisSource) shardIterator(ctx context.Context, shardID string) (string, error) {
+	req := map[string]interface{}{
+		"StreamName":        s.config.StreamName,
+		"ShardId":           shardID,
+		"ShardIteratorType": s.config.StartAt,
+	}
+
+	s.mu.Lock()
+	if seq, ok := s.checkpoint[shardID]; ok {
+		req["ShardIteratorType"] = "AFTER_SEQUENCE_NUMBER"
+		req["StartingSequenceNumber"] = seq
+	}
+	s.mu.Unlock()
+
+	var resp struct{ ShardIterator string }
+	if err := s.call(ctx, "GetShardIterator", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.ShardIterator, nil
+}
+
+// kinesisShard is a shard of the stream and the shards it was split or merged from
+type kinesisShard struct {
+	ShardId               string
+	ParentShardId         string
+	AdjacentParentShardId string
+}
+
+// parentsRead reports whether the shard's parents are fully read. Parents no
+// longer listed have expired from the stream and are not waited for.
+func (shard kinesisShard) parentsRead(listed, finished map[string]bool) bool {
+	for _, parent := range []string{shard.ParentShardId, shard.AdjacentParentShardId} {
+		if parent != "" && listed[parent] && !finished[parent] {
+			return false
+		}
+	}
+	return true
+}
+
+// listShards returns every shard in the stream, open or closed
+func (s *KinesisSource) listShards(ctx context.Context) ([]kinesisShard, error) {
+	var shards []kinesisShard
+	req := map[string]interface{}{"StreamName": s.config.StreamName}
+	for {
+		var resp struct {
+			Shards    []kinesisShard
+			NextToken string
+		}
+		if err := s.call(ctx, "ListShards", req, &resp); err != nil {
+			return nil, err
+		}
+		shards = append(shards, resp.Shards...)
+		if resp.NextToken == "" {
+			return shards, nil
+		}
+		// StreamName must be omitted when paginating with NextToken
+		req = map[string]interface{}{"NextToken": resp.NextToken}
+	}
+}
+
+// kinesisError is an error response from the Kinesis API
+type kinesisError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func (e *kinesisError) Error() string {
+	return e.Type + ": " + e.Message
+}
+
+func isThrottled(err error) bool {
+	kerr, ok := err.(*kinesisError)
+	return ok && (strings.HasSuffix(kerr.Type, "ProvisionedThroughputExceededException") ||
+		strings.HasSuffix(kerr.Type, "LimitExceededException"))
+}
+
+func isExpiredIterator(err error) bool {
+	kerr, ok := err.(*kinesisError)
+	return ok && strings.HasSuffix(kerr.Type, "ExpiredIteratorException")
+}
+
+// call invokes a Kinesis JSON API action
+func (s *KinesisSource) call(ctx context.Context, action string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Kinesis_20131202."+action)
+	s.signer.Sign(req, body, time.Now())
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		kerr := &kinesisError{}
+		if json.Unmarshal(data, kerr) != nil || kerr.Type == "" {
+			return fmt.Errorf("%s: %s", action, resp.Status)
+		}
+		return kerr
+	}
+	return json.Unmarshal(data, response)
+}
+
+// sleepContext waits for d, returning false if ctx was cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,69 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"log-signal-processor/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKinesisSkipsMalformedRecords(t *testing.T) {
+	type record struct {
+		Data           []byte
+		SequenceNumber string
+	}
+	records := []record{
+		{[]byte(`{"id":"r1"}`), "1"},
+		{[]byte(`{"id":`), "2"},
+		{[]byte(`{"id":"r3"}`), "3"},
+	}
+
+	// A stream of one closed shard, returning every record in one batch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp interface{}
+		switch action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Kinesis_20131202."); action {
+		case "ListShards":
+			resp = map[string]interface{}{"Shards": []kinesisShard{{ShardId: "shard-0"}}}
+		case "GetShardIterator":
+			resp = map[string]string{"ShardIterator": "iterator"}
+		case "GetRecords":
+			resp = map[string]interface{}{"Records": records}
+		default:
+			http.Error(w, "unexpected action "+action, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	source, err := NewKinesisSource(KinesisConfig{StreamName: "changes", Endpoint: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	malformed := metrics.Default.Counter(malformedRecordsMetric)
+	before := malformed.Value()
+
+	out := make(chan Record, len(records))
+	if err := source.Read(context.Background(), out); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	close(out)
+
+	var positions []string
+	for record := range out {
+		positions = append(positions, record.Position)
+	}
+	if strings.Join(positions, ",") != "1,3" {
+		t.Errorf("read records %v, want 1 and 3", positions)
+	}
+	if got := malformed.Value() - before; got != 1 {
+		t.Errorf("counted %d malformed records, want 1", got)
+	}
+	if seq := source.Checkpoint()["shard-0"]; seq != "3" {
+		t.Errorf("checkpoint is %q, want 3", seq)
+	}
+}
@@ -0,0 +1,329 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+)
+
+// MySQLConfig defines how the replication client connects to the primary.
+type MySQLConfig struct {
+	Addr     string // host:port of the MySQL primary
+	User     string
+	Password string
+	ServerID uint32 // Must be unique among all replicas of the primary
+	Flavor   string // "mysql" or "mariadb"
+	// Start position: either a GTID set or a binlog "file:pos". When both
+	// are empty the stream starts at the primary's oldest available binlog.
+	GTIDSet  string
+	Position string
+}
+
+// MySQLSource acts as a MySQL replica and streams row events from the binlog.
+// Each changed row becomes a raw log map understood by dbparsers.MySQLLogParser.
+type MySQLSource struct {
+	config MySQLConfig
+	syncer *replication.BinlogSyncer
+
+	// Replication position tracking
+	binlogFile  string
+	committed   string // "file:pos" after the last committed transaction
+	gtidSet     mysql.GTIDSet
+	pendingGTID string // GTID of the transaction currently being streamed
+	threadID    uint32 // Connection that began the transaction being streamed
+}
+
+// NewMySQLSource creates a replication source. The connection is only opened once Read is called.
+func NewMySQLSource(config MySQLConfig) (*MySQLSource, error) {
+	if config.ServerID == 0 {
+		return nil, fmt.Errorf("mysql server id must be non-zero")
+	}
+	if config.Flavor == "" {
+		config.Flavor = mysql.MySQLFlavor
+	}
+
+	host, portStr, err := net.SplitHostPort(config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mysql address %q: %w", config.Addr, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mysql port %q: %w", portStr, err)
+	}
+
+	syncer := replication.NewBinlogSyncer(replication.BinlogSyncerConfig{
+		ServerID: config.ServerID,
+		Flavor:   config.Flavor,
+		Host:     host,
+		Port:     uint16(port),
+		User:     config.User,
+		Password: config.Password,
+	})
+
+	return &MySQLSource{config: config, syncer: syncer}, nil
+}
+
+// Read starts replication and emits one record per changed row.
+func (s *MySQLSource) Read(ctx context.Context, out chan<- Record) error {
+	streamer, err := s.startSync()
+	if err != nil {
+		return err
+	}
+
+	for {
+		ev, err := streamer.GetEvent(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		switch e := ev.Event.(type) {
+		case *replication.RotateEvent:
+			// Binlogs only rotate between transactions
+			s.binlogFile = string(e.NextLogName)
+			s.committed = fmt.Sprintf("%s:%d", s.binlogFile, e.Position)
+		case *replication.GTIDEvent:
+			if err := s.beginGTID(e); err != nil {
+				return err
+			}
+		case *replication.QueryEvent:
+			// BEGIN starts a transaction on the connection that issued it. Other
+			// statements, a DDL or the COMMIT of a non-transactional table, end one.
+			if string(e.Query) == "BEGIN" {
+				s.threadID = e.SlaveProxyID
+			} else if err := s.commit(ev.Header.LogPos); err != nil {
+				return err
+			}
+		case *replication.XIDEvent:
+			if err := s.commit(ev.Header.LogPos); err != nil {
+				return err
+			}
+		case *replication.RowsEvent:
+			pos := s.position()
+			for _, raw := range rowsEventToLogs(ev.Header, e, s.threadID) {
+				select {
+				case out <- Record{Raw: raw, Position: pos}:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// Resume starts replication from a saved position, replacing both configured
+// start positions. The saved position is used in GTID mode when it is a GTID
+// set, as saved by a run that tracked GTIDs, and as a binlog position
+// otherwise, whichever mode is configured now.
+func (s *MySQLSource) Resume(positions map[string]string) error {
+	pos := positions[""]
+	if pos == "" {
+		return nil
+	}
+	if isGTIDSet(s.config.Flavor, pos) {
+		s.config.GTIDSet, s.config.Position = pos, ""
+	} else {
+		s.config.GTIDSet, s.config.Position = "", pos
+	}
+	return nil
+}
+
+// isGTIDSet reports whether pos parses as a GTID set of flavor. A binlog
+// "file:pos" never does: MySQL GTIDs start with a server UUID and MariaDB
+// GTIDs have no colon.
+func isGTIDSet(flavor, pos string) bool {
+	_, err := mysql.ParseGTIDSet(flavor, pos)
+	return err == nil
+}
+
+// Close stops replication and releases the connection.
+func (s *MySQLSource) Close() error {
+	s.syncer.Close()
+	return nil
+}
+
+// startSync begins streaming from the configured GTID set or binlog position.
+func (s *MySQLSource) startSync() (*replication.BinlogStreamer, error) {
+	if s.config.GTIDSet != "" {
+		gset, err := mysql.ParseGTIDSet(s.config.Flavor, s.config.GTIDSet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gtid set %q: %w", s.config.GTIDSet, err)
+		}
+		s.gtidSet = gset.Clone()
+		return s.syncer.StartSyncGTID(gset)
+	}
+
+	pos := mysql.Position{Pos: 4}
+	if s.config.Position != "" {
+		name, offset, ok := strings.Cut(s.config.Position, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid binlog position %q: expected file:pos", s.config.Position)
+		}
+		n, err := strconv.ParseUint(offset, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid binlog position %q: %w", s.config.Position, err)
+		}
+		pos = mysql.Position{Name: name, Pos: uint32(n)}
+	}
+	s.binlogFile = pos.Name
+	s.committed = fmt.Sprintf("%s:%d", pos.Name, pos.Pos)
+	return s.syncer.StartSync(pos)
+}
+
+// beginGTID remembers the GTID of the transaction that is starting.
+func (s *MySQLSource) beginGTID(e *replication.GTIDEvent) error {
+	if s.gtidSet == nil {
+		return nil
+	}
+	next, err := e.GTIDNext()
+	if err != nil {
+		return err
+	}
+	s.pendingGTID = next.String()
+	return nil
+}
+
+// commit records the end of a transaction at logPos, the binlog position
+// after its last event, and adds it to the tracked GTID set.
+func (s *MySQLSource) commit(logPos uint32) error {
+	s.committed = fmt.Sprintf("%s:%d", s.binlogFile, logPos)
+	s.threadID = 0
+	if s.gtidSet == nil || s.pendingGTID == "" {
+		return nil
+	}
+	err := s.gtidSet.Update(s.pendingGTID)
+	s.pendingGTID = ""
+	return err
+}
+
+// position returns the replication position to resume the current event
+// from, as a GTID set when GTID tracking is in use or as "file:pos"
+// otherwise. Both only cover committed transactions, so resuming replays a
+// partially processed transaction rather than skipping the rest of it.
+func (s *MySQLSource) position() string {
+	if s.gtidSet != nil {
+		return s.gtidSet.String()
+	}
+	return s.committed
+}
+
+// rowsEventToLogs converts a binlog rows event into one raw log per affected
+// row, attributed to the connection with the given thread ID (0 if unknown).
+func rowsEventToLogs(header *replication.EventHeader, e *replication.RowsEvent, threadID uint32) []map[string]interface{} {
+	var operation string
+	switch e.Type() {
+	case replication.EnumRowsEventTypeInsert:
+		operation = "INSERT"
+	case replication.EnumRowsEventTypeUpdate:
+		operation = "UPDATE"
+	case replication.EnumRowsEventTypeDelete:
+		operation = "DELETE"
+	default:
+		return nil
+	}
+
+	names := e.Table.ColumnNameString()
+	timestamp := time.Unix(int64(header.Timestamp), 0)
+
+	// UPDATE events carry before/after image pairs; the others carry a single image
+	step := 1
+	if operation == "UPDATE" {
+		step = 2
+	}
+
+	logs := make([]map[string]interface{}, 0, len(e.Rows)/step)
+	for i := 0; i+step-1 < len(e.Rows); i += step {
+		var before, after map[string]interface{}
+		switch operation {
+		case "INSERT":
+			after = rowToMap(names, e.Rows[i])
+		case "DELETE":
+			before = rowToMap(names, e.Rows[i])
+		case "UPDATE":
+			before = rowToMap(names, e.Rows[i])
+			after = rowToMap(names, e.Rows[i+1])
+		}
+
+		raw := map[string]interface{}{
+			"type":            operation,
+			"database":        string(e.Table.Schema),
+			"table":           string(e.Table.Table),
+			"primary_key":     primaryKey(e.Table, names, e.Rows[i]),
+			"changed_columns": changedColumns(before, after),
+			"timestamp":       timestamp,
+			"before":          before,
+			"after":           after,
+		}
+		if threadID != 0 {
+			raw["thread_id"] = int64(threadID)
+		}
+		logs = append(logs, raw)
+	}
+	return logs
+}
+
+// rowToMap pairs row values with column names. Binlogs only carry column names
+// when binlog_row_metadata=FULL, so positional names are used as a fallback.
+func rowToMap(names []string, row []interface{}) map[string]interface{} {
+	values := make(map[string]interface{}, len(row))
+	for i, v := range row {
+		values[columnName(names, i)] = normalizeValue(v)
+	}
+	return values
+}
+
+// changedColumns lists the columns whose value differs between the images.
+func changedColumns(before, after map[string]interface{}) []string {
+	image := after
+	if image == nil {
+		image = before
+	}
+
+	columns := make([]string, 0, len(image))
+	for name := range image {
+		if before == nil || after == nil || fmt.Sprint(before[name]) != fmt.Sprint(after[name]) {
+			columns = append(columns, name)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// primaryKey builds a row identifier from the table's primary key columns, if known.
+func primaryKey(table *replication.TableMapEvent, names []string, row []interface{}) string {
+	if len(table.PrimaryKey) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(table.PrimaryKey))
+	for _, idx := range table.PrimaryKey {
+		if int(idx) < len(row) {
+			parts = append(parts, fmt.Sprintf("%s=%v", columnName(names, int(idx)), normalizeValue(row[idx])))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func columnName(names []string, i int) string {
+	if i < len(names) {
+		return names[i]
+	}
+	return fmt.Sprintf("col_%d", i)
+}
+
+// normalizeValue converts binary column values to strings so signal generators can use them.
+func normalizeValue(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
@@ -0,0 +1,43 @@
+package sources
+
+import "testing"
+
+func TestMySQLResumeDetectsGTIDSets(t *testing.T) {
+	const uuid = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	tests := []struct {
+		name       string
+		flavor     string
+		configured MySQLConfig
+		saved      string
+		gtidSet    string
+		position   string
+	}{
+		{"position", "mysql", MySQLConfig{}, "mysql-bin.000003:1200", "", "mysql-bin.000003:1200"},
+		{"gtid set", "mysql", MySQLConfig{GTIDSet: uuid + ":1-5"}, uuid + ":1-42", uuid + ":1-42", ""},
+		// A single GTID looks like file:pos
+		{"single gtid", "mysql", MySQLConfig{}, uuid + ":7", uuid + ":7", ""},
+		{"gtid set saved before -mysql-gtid was dropped", "mysql", MySQLConfig{Position: "mysql-bin.000001:4"}, uuid + ":1-42", uuid + ":1-42", ""},
+		{"position saved before -mysql-gtid was added", "mysql", MySQLConfig{GTIDSet: uuid + ":1-5"}, "mysql-bin.000003:1200", "", "mysql-bin.000003:1200"},
+		{"mariadb gtid set", "mariadb", MySQLConfig{}, "0-1-100,1-2-7", "0-1-100,1-2-7", ""},
+		{"mariadb position", "mariadb", MySQLConfig{GTIDSet: "0-1-1"}, "mariadb-bin.000002:4", "", "mariadb-bin.000002:4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.configured
+			config.Addr, config.ServerID, config.Flavor = "127.0.0.1:3306", 1001, tt.flavor
+			source, err := NewMySQLSource(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer source.Close()
+
+			if err := source.Resume(map[string]string{"": tt.saved}); err != nil {
+				t.Fatal(err)
+			}
+			if source.config.GTIDSet != tt.gtidSet || source.config.Position != tt.position {
+				t.Errorf("resumed with gtid set %q and position %q, want %q and %q",
+					source.config.GTIDSet, source.config.Position, tt.gtidSet, tt.position)
+			}
+		})
+	}
+}
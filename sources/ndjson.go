@@ -0,0 +1,81 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log-signal-processor/metrics"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic bytes used to detect compressed streams
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// maxLineSize bounds a single NDJSON log line
+const maxLineSize = 16 * 1024 * 1024
+
+// malformedLinesMetric counts NDJSON lines skipped because they are not JSON objects
+const malformedLinesMetric = "source_malformed_lines"
+
+// decompress wraps r with a decompressor chosen by file extension or, failing
+// that, by sniffing the stream's magic bytes.
+func decompress(name string, r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(4)
+
+	switch {
+	case strings.HasSuffix(name, ".gz") || bytes.HasPrefix(head, gzipMagic):
+		return gzip.NewReader(br)
+	case strings.HasSuffix(name, ".zst") || bytes.HasPrefix(head, zstdMagic):
+		dec, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+// readNDJSON decodes one raw log map per line and sends it to out. Positions
+// are reported as "<name>:<line>". Lines up to and including skipLines are
+// skipped so a partially processed file can be resumed. Lines that are not
+// JSON objects, such as the simulator's truncated noise, are logged, counted
+// and skipped.
+func readNDJSON(ctx context.Context, name string, r io.Reader, skipLines int, out chan<- Record) error {
+	malformed := metrics.Default.Counter(malformedLinesMetric)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if line <= skipLines || len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var raw map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &raw); err != nil {
+			log.Printf("Skipping malformed line %s:%d: %v", name, line, err)
+			malformed.Inc()
+			continue
+		}
+
+		select {
+		case out <- Record{Raw: raw, Position: fmt.Sprintf("%s:%d", name, line)}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,147 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log-signal-processor/metrics"
+	"log-signal-processor/objectstore"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// skippedObjectsMetric counts objects left unread, or read only in part,
+// because their content could not be decoded
+const skippedObjectsMetric = "source_skipped_objects"
+
+// ObjectStoreConfig selects the change-log files to ingest from a bucket
+type ObjectStoreConfig struct {
+	URL      string // s3://bucket/prefix or gs://bucket/prefix
+	Glob     string // Optional pattern matched against each key's base name, e.g. "*.json.gz"
+	Endpoint string // Overrides the service endpoint (e.g. MinIO)
+}
+
+// ObjectStoreSource reads NDJSON change-log files from S3 or GCS in key order.
+// Files may be plain, gzip or zstd compressed. Malformed lines are skipped, and
+// files that cannot be decompressed or read to the end are skipped from the
+// point of failure, so one bad file does not stop ingestion of the others.
+type ObjectStoreSource struct {
+	config ObjectStoreConfig
+	client *objectstore.Client
+	prefix string
+
+	// Resume point: objects before resumeKey are skipped, as are the first
+	// resumeLine lines of resumeKey itself
+	resumeKey  string
+	resumeLine int
+}
+
+// NewObjectStoreSource creates a source for the files under the configured URL
+func NewObjectStoreSource(config ObjectStoreConfig) (*ObjectStoreSource, error) {
+	storeConfig, prefix, err := objectstore.ParseURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	if config.Endpoint != "" {
+		storeConfig.Endpoint = config.Endpoint
+		storeConfig.PathStyle = true
+	}
+	if config.Glob != "" {
+		if _, err := path.Match(config.Glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", config.Glob, err)
+		}
+	}
+
+	client, err := objectstore.New(storeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectStoreSource{config: config, client: client, prefix: prefix}, nil
+}
+
+// Read lists the matching objects and emits their log lines in key order
+func (s *ObjectStoreSource) Read(ctx context.Context, out chan<- Record) error {
+	objects, err := s.client.List(ctx, s.prefix)
+	if err != nil {
+		return err
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+
+	for _, obj := range objects {
+		if s.config.Glob != "" {
+			if ok, _ := path.Match(s.config.Glob, path.Base(obj.Key)); !ok {
+				continue
+			}
+		}
+		if obj.Key < s.resumeKey {
+			continue
+		}
+		skipLines := 0
+		if obj.Key == s.resumeKey {
+			skipLines = s.resumeLine
+		}
+		if err := s.readObject(ctx, obj.Key, skipLines, out); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// readObject streams a single object's log lines after the first skipLines.
+// Only failing to fetch the object is an error; content that cannot be
+// decoded is logged and counted, and the rest of the object skipped.
+func (s *ObjectStoreSource) readObject(ctx context.Context, key string, skipLines int, out chan<- Record) error {
+	body, err := s.client.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	r, err := decompress(key, body)
+	if err == nil {
+		err = readNDJSON(ctx, key, r, skipLines, out)
+		r.Close()
+	}
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Skipping the rest of object %s: %v", key, err)
+		metrics.Default.Counter(skippedObjectsMetric).Inc()
+	}
+	return nil
+}
+
+// Resume continues after a saved "<key>:<line>" position
+func (s *ObjectStoreSource) Resume(positions map[string]string) error {
+	pos := positions[""]
+	if pos == "" {
+		return nil
+	}
+	key, line, err := parseLinePosition(pos)
+	if err != nil {
+		return err
+	}
+	s.resumeKey, s.resumeLine = key, line
+	return nil
+}
+
+// parseLinePosition splits a "<name>:<line>" position as produced by readNDJSON
+func parseLinePosition(pos string) (string, int, error) {
+	i := strings.LastIndex(pos, ":")
+	if i < 0 {
+		return "", 0, fmt.Errorf("invalid position %q: expected name:line", pos)
+	}
+	line, err := strconv.Atoi(pos[i+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid position %q: %w", pos, err)
+	}
+	return pos[:i], line, nil
+}
+
+// Close releases the source. Object reads are closed as they complete.
+func (s *ObjectStoreSource) Close() error {
+	return nil
+}
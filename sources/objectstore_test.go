@@ -0,0 +1,76 @@
+package sources
+
+import (
+	"context"
+	"encoding/xml"
+	"log-signal-processor/metrics"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestObjectStoreSkipsBadContent(t *testing.T) {
+	objects := map[string]string{
+		"logs/a.ndjson":    `{"id":"a1"}` + "\n" + `{"id":` + "\n" + `{"id":"a3"}` + "\n",
+		"logs/b.ndjson.gz": "\x1f\x8b not gzip",
+		"logs/c.ndjson":    `{"id":"c1"}` + "\n",
+	}
+
+	// A path-style bucket serving the objects and their listing
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		if r.URL.Path == "/bucket/" {
+			type content struct{ Key string }
+			var listing struct {
+				XMLName  xml.Name `xml:"ListBucketResult"`
+				Contents []content
+			}
+			for key := range objects {
+				if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+					listing.Contents = append(listing.Contents, content{Key: key})
+				}
+			}
+			xml.NewEncoder(w).Encode(listing)
+			return
+		}
+		body, ok := objects[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	source, err := NewObjectStoreSource(ObjectStoreConfig{URL: "s3://bucket/logs/", Endpoint: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	malformed := metrics.Default.Counter(malformedLinesMetric)
+	skipped := metrics.Default.Counter(skippedObjectsMetric)
+	malformedBefore, skippedBefore := malformed.Value(), skipped.Value()
+
+	out := make(chan Record, 10)
+	if err := source.Read(context.Background(), out); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	close(out)
+
+	var ids []string
+	for record := range out {
+		ids = append(ids, record.Raw.(map[string]interface{})["id"].(string))
+	}
+	sort.Strings(ids)
+	if got, want := strings.Join(ids, ","), "a1,a3,c1"; got != want {
+		t.Errorf("read %s, want %s", got, want)
+	}
+	if got := malformed.Value() - malformedBefore; got != 1 {
+		t.Errorf("counted %d malformed lines, want 1", got)
+	}
+	if got := skipped.Value() - skippedBefore; got != 1 {
+		t.Errorf("counted %d skipped objects, want 1", got)
+	}
+}
@@ -0,0 +1,325 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log-signal-processor/metrics"
+	"os"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy decides what a full Queue does with a new record
+type OverflowPolicy string
+
+const (
+	// OverflowBlock makes the producer wait, pushing backpressure onto the source
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest discards the oldest queued record to make room
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowSpill writes records beyond the in-memory limit to a temporary file
+	OverflowSpill OverflowPolicy = "spill"
+)
+
+// Queue metric names
+const (
+	queueDepthMetric   = "ingest_queue_depth"
+	queueSpilledMetric = "ingest_queue_spilled"
+	queueDroppedMetric = "ingest_queue_dropped"
+)
+
+// QueueConfig bounds the queue between a source and the signal processors
+type QueueConfig struct {
+	Size     int // Maximum number of records held in memory
+	Policy   OverflowPolicy
+	SpillDir string // Directory for the spill file; empty uses the system temp dir
+}
+
+// Queue is a bounded FIFO between a source and the signal processors, so a
+// burst of events cannot grow memory without limit. It supports a single
+// producer and a single consumer goroutine.
+type Queue struct {
+	config QueueConfig
+
+	mu       sync.Mutex
+	buf      []Record
+	spill    *spillFile
+	closed   bool
+	notEmpty chan struct{}
+	notFull  chan struct{}
+
+	depth   *metrics.Gauge
+	spilled *metrics.Gauge
+	dropped *metrics.Counter
+}
+
+// NewQueue creates a queue with the given size and overflow policy
+func NewQueue(config QueueConfig) (*Queue, error) {
+	if config.Size <= 0 {
+		return nil, fmt.Errorf("queue size must be positive")
+	}
+	if config.Policy == "" {
+		config.Policy = OverflowBlock
+	}
+
+	q := &Queue{
+		config:   config,
+		buf:      make([]Record, 0, config.Size),
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+		depth:    metrics.Default.Gauge(queueDepthMetric),
+		spilled:  metrics.Default.Gauge(queueSpilledMetric),
+		dropped:  metrics.Default.Counter(queueDroppedMetric),
+	}
+
+	switch config.Policy {
+	case OverflowBlock, OverflowDropOldest:
+	case OverflowSpill:
+		spill, err := newSpillFile(config.SpillDir)
+		if err != nil {
+			return nil, err
+		}
+		q.spill = spill
+	default:
+		return nil, fmt.Errorf("unknown overflow policy %q (expected block, drop-oldest or spill)", config.Policy)
+	}
+	return q, nil
+}
+
+// Fill pushes every record from in until it is closed or ctx is cancelled,
+// then closes the queue's input.
+func (q *Queue) Fill(ctx context.Context, in <-chan Record) error {
+	defer q.CloseInput()
+	for record := range in {
+		if err := q.Push(ctx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Push adds a record, applying the overflow policy when the queue is full
+func (q *Queue) Push(ctx context.Context, record Record) error {
+	if record.Received.IsZero() {
+		record.Received = time.Now()
+	}
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return errors.New("push to closed queue")
+		}
+
+		// Once records have spilled, newer ones follow them to disk to keep FIFO order
+		if len(q.buf) < q.config.Size && (q.spill == nil || q.spill.pending == 0) {
+			q.buf = append(q.buf, record)
+			q.updateDepth()
+			q.mu.Unlock()
+			signal(q.notEmpty)
+			return nil
+		}
+
+		switch q.config.Policy {
+		case OverflowDropOldest:
+			q.buf[0] = Record{}
+			q.buf = append(q.buf[1:], record)
+			q.dropped.Inc()
+			q.mu.Unlock()
+			signal(q.notEmpty)
+			return nil
+		case OverflowSpill:
+			err := q.spill.write(record)
+			q.updateDepth()
+			q.mu.Unlock()
+			signal(q.notEmpty)
+			return err
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notFull:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Pop removes the oldest record, waiting for one if the queue is empty. It
+// returns io.EOF once the input is closed and every record has been popped.
+func (q *Queue) Pop(ctx context.Context) (Record, error) {
+	for {
+		q.mu.Lock()
+		if len(q.buf) > 0 {
+			record := q.buf[0]
+			q.buf[0] = Record{}
+			q.buf = q.buf[1:]
+			q.updateDepth()
+			q.mu.Unlock()
+			signal(q.notFull)
+			return record, nil
+		}
+		if q.spill != nil && q.spill.pending > 0 {
+			record, err := q.spill.read()
+			q.updateDepth()
+			q.mu.Unlock()
+			return record, err
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return Record{}, io.EOF
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notEmpty:
+		case <-ctx.Done():
+			return Record{}, ctx.Err()
+		}
+	}
+}
+
+// Len returns the number of queued records, including spilled ones
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.len()
+}
+
+// CloseInput marks the end of the input. Queued records can still be popped.
+func (q *Queue) CloseInput() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	signal(q.notEmpty)
+	signal(q.notFull)
+}
+
+// Close releases the spill file. Records still queued are discarded.
+func (q *Queue) Close() error {
+	q.CloseInput()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.buf = nil
+	if q.spill == nil {
+		return nil
+	}
+	err := q.spill.remove()
+	q.spill = nil
+	q.updateDepth()
+	return err
+}
+
+func (q *Queue) len() int {
+	n := len(q.buf)
+	if q.spill != nil {
+		n += q.spill.pending
+	}
+	return n
+}
+
+// updateDepth publishes the queue depth; callers must hold q.mu
+func (q *Queue) updateDepth() {
+	q.depth.Set(int64(q.len()))
+	if q.spill != nil {
+		q.spilled.Set(int64(q.spill.pending))
+	} else {
+		q.spilled.Set(0)
+	}
+}
+
+// signal wakes a waiter without blocking if one is already pending
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// spilledRecord is the on-disk form of a Record. Raw values go through JSON,
+// so they come back in the same shapes as logs read from NDJSON files.
+type spilledRecord struct {
+	Raw       interface{} `json:"raw"`
+	Position  string      `json:"position,omitempty"`
+	Partition string      `json:"partition,omitempty"`
+	Received  time.Time   `json:"received"`
+}
+
+// spillFile is an append-only file of NDJSON records read back in order. It is
+// truncated whenever it has been fully read.
+type spillFile struct {
+	file    *os.File
+	writer  *bufio.Writer
+	reader  *bufio.Reader
+	offset  int64 // Read offset into the file
+	pending int
+}
+
+func newSpillFile(dir string) (*spillFile, error) {
+	file, err := os.CreateTemp(dir, "lsp-spill-*.ndjson")
+	if err != nil {
+		return nil, fmt.Errorf("creating spill file: %w", err)
+	}
+	return &spillFile{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+func (s *spillFile) write(record Record) error {
+	data, err := json.Marshal(spilledRecord{Raw: record.Raw, Position: record.Position, Partition: record.Partition, Received: record.Received})
+	if err != nil {
+		return fmt.Errorf("spilling record at %s: %w", record.Position, err)
+	}
+	if _, err := s.writer.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("spilling record at %s: %w", record.Position, err)
+	}
+	s.pending++
+	return nil
+}
+
+func (s *spillFile) read() (Record, error) {
+	if err := s.writer.Flush(); err != nil {
+		return Record{}, err
+	}
+	if s.reader == nil {
+		s.reader = bufio.NewReader(io.NewSectionReader(s.file, s.offset, 1<<62))
+	}
+
+	line, err := s.reader.ReadBytes('\n')
+	if err == io.EOF {
+		// The reader may have buffered a partially written tail before the
+		// last flush; read again from the end of the last complete line
+		s.reader = bufio.NewReader(io.NewSectionReader(s.file, s.offset, 1<<62))
+		line, err = s.reader.ReadBytes('\n')
+	}
+	if err != nil {
+		return Record{}, fmt.Errorf("reading spill file: %w", err)
+	}
+	s.offset += int64(len(line))
+	s.pending--
+
+	var spilled spilledRecord
+	if err := json.Unmarshal(line, &spilled); err != nil {
+		return Record{}, fmt.Errorf("reading spill file: %w", err)
+	}
+
+	// Start over once everything written has been read back
+	if s.pending == 0 {
+		if err := s.file.Truncate(0); err != nil {
+			return Record{}, err
+		}
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			return Record{}, err
+		}
+		s.offset = 0
+		s.reader = nil
+	}
+	return Record{Raw: spilled.Raw, Position: spilled.Position, Partition: spilled.Partition, Received: spilled.Received}, nil
+}
+
+func (s *spillFile) remove() error {
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
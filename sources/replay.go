@@ -0,0 +1,127 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ReplayConfig selects a recorded log file and how fast to replay it
+type ReplayConfig struct {
+	Path string
+	// Speed multiplies the original pace: 1 replays in real time, 10 ten times
+	// faster. Zero or less replays as fast as possible.
+	Speed float64
+	// TimestampField is the dot-separated path of each log's timestamp,
+	// e.g. "timestamp" or "metadata.timestamp" for DMS records.
+	TimestampField string
+}
+
+// ReplaySource re-emits a recorded NDJSON log file (optionally gzip or zstd
+// compressed), pacing events by the gaps between their original timestamps.
+type ReplaySource struct {
+	config    ReplayConfig
+	file      *os.File
+	skipLines int
+}
+
+// NewReplaySource opens the recording
+func NewReplaySource(config ReplayConfig) (*ReplaySource, error) {
+	if config.TimestampField == "" {
+		config.TimestampField = "timestamp"
+	}
+	file, err := os.Open(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplaySource{config: config, file: file}, nil
+}
+
+// Read emits the recorded events, sleeping between them to reproduce their timing
+func (s *ReplaySource) Read(ctx context.Context, out chan<- Record) error {
+	r, err := decompress(s.config.Path, s.file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.config.Path, err)
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	recorded := make(chan Record)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- readNDJSON(ctx, s.config.Path, r, s.skipLines, recorded)
+		close(recorded)
+	}()
+
+	var firstEvent, wallStart time.Time
+	for record := range recorded {
+		if s.config.Speed > 0 {
+			eventTime := s.timestamp(record.Raw)
+			if !eventTime.IsZero() {
+				if firstEvent.IsZero() {
+					firstEvent, wallStart = eventTime, time.Now()
+				}
+				// Events recorded out of order are emitted immediately
+				offset := time.Duration(float64(eventTime.Sub(firstEvent)) / s.config.Speed)
+				if wait := time.Until(wallStart.Add(offset)); wait > 0 && !sleepContext(ctx, wait) {
+					return nil
+				}
+			}
+		}
+
+		select {
+		case out <- record:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return <-errs
+}
+
+// Resume continues after a saved "<path>:<line>" position
+func (s *ReplaySource) Resume(positions map[string]string) error {
+	pos := positions[""]
+	if pos == "" {
+		return nil
+	}
+	path, line, err := parseLinePosition(pos)
+	if err != nil {
+		return err
+	}
+	if path != s.config.Path {
+		return fmt.Errorf("checkpoint is for %s, not %s", path, s.config.Path)
+	}
+	s.skipLines = line
+	return nil
+}
+
+// Close closes the recording
+func (s *ReplaySource) Close() error {
+	return s.file.Close()
+}
+
+// timestamp looks up the configured timestamp field of a raw log
+func (s *ReplaySource) timestamp(raw interface{}) time.Time {
+	value := raw
+	for _, key := range strings.Split(s.config.TimestampField, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return time.Time{}
+		}
+		value = m[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		t, _ := time.Parse(time.RFC3339Nano, v)
+		return t
+	case float64:
+		// Numeric timestamps are taken as epoch milliseconds
+		return time.UnixMilli(int64(v))
+	}
+	return time.Time{}
+}
@@ -0,0 +1,80 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"log-signal-processor/logsimulator"
+	"log-signal-processor/metrics"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplaySkipsNoise(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cap.ndjson")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Record a capture the way the simulator writes one with noise enabled:
+	// a truncated copy of JSON before every real log
+	const logs = 10
+	for i := 0; i < logs; i++ {
+		rawLog := map[string]interface{}{
+			"timestamp": "2024-01-01T00:00:00Z",
+			"table":     "users",
+			"after":     map[string]interface{}{"id": float64(i)},
+		}
+		noise := logsimulator.Malform(rawLog, logsimulator.NoiseTruncated).(string)
+		data, err := json.Marshal(rawLog)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.WriteString(noise + "\n" + string(data) + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewReplaySource(ReplayConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	malformed := metrics.Default.Counter(malformedLinesMetric)
+	before := malformed.Value()
+
+	out := make(chan Record, 2*logs)
+	if err := source.Read(context.Background(), out); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	close(out)
+
+	var ids []float64
+	for record := range out {
+		raw, ok := record.Raw.(map[string]interface{})
+		if !ok {
+			t.Fatalf("record at %s is %T, want a map", record.Position, record.Raw)
+		}
+		if after, ok := raw["after"].(map[string]interface{}); ok {
+			ids = append(ids, after["id"].(float64))
+		}
+	}
+	if len(ids) != logs {
+		t.Fatalf("replayed %d logs, want %d", len(ids), logs)
+	}
+	for i, id := range ids {
+		if id != float64(i) {
+			t.Errorf("log %d has id %v", i, id)
+		}
+	}
+
+	// Malform never returns the whole object, so no noise line is valid JSON
+	if got := malformed.Value() - before; got != logs {
+		t.Errorf("counted %d malformed lines, want %d", got, logs)
+	}
+}
@@ -0,0 +1,26 @@
+package sources
+
+import (
+	"context"
+	"time"
+)
+
+// Record is a raw log entry read from a Source together with the position it
+// was read at, so consumers can track progress through the source.
+type Record struct {
+	Raw      interface{}
+	Position string
+	// Partition identifies the independently ordered stream Position belongs
+	// to (e.g. a Kinesis shard). It is empty for single-stream sources.
+	Partition string
+	// Received is when the record entered the ingestion queue, for latency metrics
+	Received time.Time
+}
+
+// Source streams raw database logs into the parser pipeline. Read blocks,
+// sending records to out until the source is exhausted, ctx is cancelled,
+// or an error occurs.
+type Source interface {
+	Read(ctx context.Context, out chan<- Record) error
+	Close() error
+}
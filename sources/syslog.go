@@ -0,0 +1,275 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogConfig defines where the syslog listener accepts messages
+type SyslogConfig struct {
+	Network string // "udp" or "tcp"
+	Addr    string // Listen address, e.g. ":5514"
+}
+
+// SyslogSource listens for syslog messages (RFC 5424, with RFC 3164 fallback)
+// and emits each as a raw log map with the header fields and "message" body.
+// TCP streams may use octet-counting or newline framing (RFC 6587).
+type SyslogSource struct {
+	config SyslogConfig
+
+	mu       sync.Mutex
+	closers  []io.Closer
+	received uint64
+}
+
+// NewSyslogSource creates a listener; the socket is opened when Read is called
+func NewSyslogSource(config SyslogConfig) (*SyslogSource, error) {
+	switch config.Network {
+	case "udp", "tcp":
+	case "":
+		config.Network = "udp"
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s", config.Network)
+	}
+	if config.Addr == "" {
+		config.Addr = ":5514"
+	}
+	return &SyslogSource{config: config}, nil
+}
+
+// Read accepts messages until ctx is cancelled
+func (s *SyslogSource) Read(ctx context.Context, out chan<- Record) error {
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+
+	if s.config.Network == "udp" {
+		return s.readUDP(ctx, out)
+	}
+	return s.readTCP(ctx, out)
+}
+
+// Close stops listening and closes open connections
+func (s *SyslogSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.closers {
+		c.Close()
+	}
+	s.closers = nil
+	return nil
+}
+
+func (s *SyslogSource) track(c io.Closer) {
+	s.mu.Lock()
+	s.closers = append(s.closers, c)
+	s.mu.Unlock()
+}
+
+func (s *SyslogSource) readUDP(ctx context.Context, out chan<- Record) error {
+	conn, err := net.ListenPacket("udp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	s.track(conn)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if !s.emit(ctx, string(buf[:n]), out) {
+			return nil
+		}
+	}
+}
+
+func (s *SyslogSource) readTCP(ctx context.Context, out chan<- Record) error {
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	s.track(listener)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		s.track(conn)
+		go s.readConn(ctx, conn, out)
+	}
+}
+
+// readConn reads framed messages from one TCP connection
+func (s *SyslogSource) readConn(ctx context.Context, conn net.Conn, out chan<- Record) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		msg, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		if !s.emit(ctx, msg, out) {
+			return
+		}
+	}
+}
+
+// readFrame reads one message using octet-counting ("<len> <msg>") when the
+// frame starts with a digit, or newline framing otherwise
+func readFrame(r *bufio.Reader) (string, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return "", err
+	}
+
+	if first[0] >= '0' && first[0] <= '9' {
+		lenStr, err := r.ReadString(' ')
+		if err != nil {
+			return "", err
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(lenStr))
+		if err != nil {
+			return "", err
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", err
+		}
+		return string(buf), nil
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// emit parses a message and sends it on, returning false once ctx is done
+func (s *SyslogSource) emit(ctx context.Context, msg string, out chan<- Record) bool {
+	s.mu.Lock()
+	s.received++
+	pos := strconv.FormatUint(s.received, 10)
+	s.mu.Unlock()
+
+	select {
+	case out <- Record{Raw: ParseSyslogMessage(msg), Position: pos}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ParseSyslogMessage splits a syslog message into header fields and body.
+// Unparseable headers leave the whole text in "message".
+func ParseSyslogMessage(msg string) map[string]interface{} {
+	fields := map[string]interface{}{"message": msg}
+
+	msg = strings.TrimSpace(msg)
+	if !strings.HasPrefix(msg, "<") {
+		return fields
+	}
+	end := strings.IndexByte(msg, '>')
+	if end < 0 {
+		return fields
+	}
+	pri, err := strconv.Atoi(msg[1:end])
+	if err != nil {
+		return fields
+	}
+	fields["facility"] = pri / 8
+	fields["severity"] = pri % 8
+	rest := msg[end+1:]
+
+	// RFC 5424: VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD [MSG]
+	if strings.HasPrefix(rest, "1 ") {
+		parts := strings.SplitN(rest[2:], " ", 6)
+		if len(parts) == 6 {
+			if ts, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+				fields["timestamp"] = ts
+			}
+			fields["hostname"] = nilValue(parts[1])
+			fields["app_name"] = nilValue(parts[2])
+			fields["proc_id"] = nilValue(parts[3])
+			fields["msg_id"] = nilValue(parts[4])
+			fields["message"] = strings.TrimPrefix(skipStructuredData(parts[5]), "\ufeff")
+			return fields
+		}
+	}
+
+	// RFC 3164: "Mmm dd hh:mm:ss HOSTNAME TAG: MSG"
+	if len(rest) > 16 {
+		if ts, err := time.ParseInLocation(time.Stamp, rest[:15], time.Local); err == nil {
+			ts = ts.AddDate(time.Now().Year(), 0, 0)
+			fields["timestamp"] = ts
+			hostAndMsg := strings.SplitN(rest[16:], " ", 2)
+			fields["hostname"] = hostAndMsg[0]
+			if len(hostAndMsg) == 2 {
+				tag, body, ok := strings.Cut(hostAndMsg[1], ": ")
+				if ok {
+					fields["app_name"] = tag
+					fields["message"] = body
+				} else {
+					fields["message"] = hostAndMsg[1]
+				}
+			}
+			return fields
+		}
+	}
+
+	fields["message"] = rest
+	return fields
+}
+
+// skipStructuredData drops the RFC 5424 STRUCTURED-DATA element(s) before the message
+func skipStructuredData(s string) string {
+	if strings.HasPrefix(s, "-") {
+		return strings.TrimPrefix(strings.TrimPrefix(s, "-"), " ")
+	}
+
+	depth := 0
+	escaped := false
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 && (i+1 == len(s) || s[i+1] != '[') {
+				return strings.TrimPrefix(s[i+1:], " ")
+			}
+		}
+	}
+	return s
+}
+
+// nilValue maps the RFC 5424 NILVALUE "-" to an empty string
+func nilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"log-signal-processor/logprocessor"
+)
+
+// Command-line flags for headless runs, e.g. in CI
+var (
+	quiet       = flag.Bool("quiet", false, "suppress the console logs and progress output, leaving stdout to -summary-json; the simulator then needs -preset or -config instead of the TUI")
+	summaryJSON = flag.Bool("summary-json", false, "print a JSON summary of the run to stdout when it ends: logs read, parse errors, inputs, anomalies flagged by detector, rule and severity, and statistics of every signal")
+)
+
+// runSummary counts what a run processed, for -summary-json
+type runSummary struct {
+	Mode            string                    `json:"mode"` // simulate, serve or the source, e.g. replay
+	Started         time.Time                 `json:"started"`
+	DurationSeconds float64                   `json:"duration_seconds"`
+	Logs            int                       `json:"logs"`         // Logs read from the simulator or source
+	ParseErrors     int                       `json:"parse_errors"` // Logs that failed to parse
+	Inputs          int                       `json:"inputs"`       // Anomaly inputs written, DDL events included
+	DDLEvents       int                       `json:"ddl_events"`
+	Anomalies       int                       `json:"anomalies"`  // Flagged inputs that were not suppressed
+	Suppressed      int                       `json:"suppressed"` // Flagged inputs silenced by a suppression rule or as duplicates
+	Severities      map[string]int            `json:"severities"` // Anomalies by severity
+	Detectors       map[string]int            `json:"detectors"`  // Anomalies by detector flagging them
+	Rules           map[string]int            `json:"rules"`      // Anomalies by matched rule
+	Signals         map[string]*signalSummary `json:"signals"`
+}
+
+// signalSummary describes the values of one signal across the inputs of a run
+type signalSummary struct {
+	Count    int     `json:"count"`
+	Mean     float64 `json:"mean"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Detected int     `json:"detected"` // Detections of the signal in anomalies
+
+	sum float64
+}
+
+// summary is the summary of the current run
+var summary = runSummary{
+	Started:    time.Now(),
+	Severities: make(map[string]int),
+	Detectors:  make(map[string]int),
+	Rules:      make(map[string]int),
+	Signals:    make(map[string]*signalSummary),
+}
+
+// observe counts an input as it is emitted, with names labelling its signal
+// vector
+func (s *runSummary) observe(input logprocessor.AnomalyInput, names []string) {
+	s.Inputs++
+	if logprocessor.DDLKind(input.Operation) != "" {
+		s.DDLEvents++
+	} else {
+		for i, value := range input.SignalVector {
+			if i >= len(names) || math.IsNaN(value) {
+				continue
+			}
+			signal := s.Signals[names[i]]
+			if signal == nil {
+				signal = &signalSummary{Min: value, Max: value}
+				s.Signals[names[i]] = signal
+			}
+			signal.Count++
+			signal.sum += value
+			signal.Mean = signal.sum / float64(signal.Count)
+			signal.Min = math.Min(signal.Min, value)
+			signal.Max = math.Max(signal.Max, value)
+		}
+	}
+	if !input.Anomalous {
+		return
+	}
+	if input.Suppressed != "" {
+		s.Suppressed++
+		return
+	}
+	s.Anomalies++
+	if input.Severity != "" {
+		s.Severities[string(input.Severity)]++
+	}
+	detectors := make(map[string]bool)
+	for _, detection := range input.Detections {
+		detectors[detection.Detector] = true
+		if signal := s.Signals[detection.Signal]; signal != nil {
+			signal.Detected++
+		}
+	}
+	for detector := range detectors {
+		s.Detectors[detector]++
+	}
+	for _, rule := range input.MatchedRules {
+		s.Rules[rule]++
+	}
+}
+
+// finishRun logs the timings of a run, or prints its summary with
+// -summary-json
+func finishRun(mode string) {
+	logprocessor.LogTimingSummary()
+	if !*summaryJSON {
+		return
+	}
+	summary.Mode = mode
+	summary.DurationSeconds = time.Since(summary.Started).Seconds()
+	data, err := json.Marshal(summary)
+	if err != nil {
+		log.Fatalf("Failed to encode run summary: %v", err)
+	}
+	os.Stdout.Write(append(data, '\n'))
+}